@@ -2,37 +2,41 @@ module unreal.sh/neo
 
 go 1.21.4
 
+require (
+	github.com/bwmarrin/discordgo v0.28.1
+	github.com/cenkalti/dominantcolor v1.0.3
+	github.com/dhowden/tag v0.0.0-20240417053706-3d75831295e8
+	github.com/disgoorg/disgolink/v3 v3.0.2
+	github.com/disgoorg/snowflake/v2 v2.0.3
+	github.com/gorilla/websocket v1.5.3
+	github.com/joho/godotenv v1.5.1
+	github.com/lmittmann/tint v1.0.5
+	github.com/lucasb-eyer/go-colorful v1.2.0
+	github.com/supabase-community/postgrest-go v0.0.11
+	github.com/supabase-community/supabase-go v0.0.4
+	github.com/zekrotja/ken v0.20.1
+	golang.org/x/exp v0.0.0-20240808152545-0cdaa3abc0fa
+	golang.org/x/text v0.17.0
+)
+
 require (
 	github.com/Necroforger/dgwidgets v0.0.0-20190131052008-56c8c1ca33e0 // indirect
-	github.com/bwmarrin/discordgo v0.28.1 // indirect
-	github.com/cenkalti/dominantcolor v1.0.3 // indirect
 	github.com/cespare/xxhash/v2 v2.2.0 // indirect
 	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
-	github.com/disgoorg/disgolink/v3 v3.0.2 // indirect
 	github.com/disgoorg/json v1.1.0 // indirect
-	github.com/disgoorg/snowflake/v2 v2.0.3 // indirect
 	github.com/go-redis/redis/v8 v8.11.5 // indirect
 	github.com/google/uuid v1.6.0 // indirect
-	github.com/gorilla/websocket v1.5.3 // indirect
-	github.com/joho/godotenv v1.5.1 // indirect
-	github.com/lmittmann/tint v1.0.5 // indirect
-	github.com/lucasb-eyer/go-colorful v1.2.0 // indirect
 	github.com/matryer/goscript v0.0.0-20170731125849-1a0cb0e0df70 // indirect
 	github.com/nfnt/resize v0.0.0-20180221191011-83c6a9932646 // indirect
 	github.com/oliamb/cutter v0.2.2 // indirect
 	github.com/rs/xid v1.5.0 // indirect
 	github.com/supabase-community/functions-go v0.0.0-20220927045802-22373e6cb51d // indirect
 	github.com/supabase-community/gotrue-go v1.2.0 // indirect
-	github.com/supabase-community/postgrest-go v0.0.11 // indirect
 	github.com/supabase-community/storage-go v0.7.0 // indirect
-	github.com/supabase-community/supabase-go v0.0.4 // indirect
 	github.com/tomnomnom/linkheader v0.0.0-20180905144013-02ca5825eb80 // indirect
 	github.com/zekrotja/dgrs v0.5.7 // indirect
-	github.com/zekrotja/ken v0.20.1 // indirect
 	github.com/zekrotja/safepool v1.1.0 // indirect
 	golang.org/x/crypto v0.11.0 // indirect
-	golang.org/x/exp v0.0.0-20240808152545-0cdaa3abc0fa // indirect
 	golang.org/x/image v0.18.0 // indirect
 	golang.org/x/sys v0.10.0 // indirect
-	golang.org/x/text v0.17.0 // indirect
 )
@@ -0,0 +1,128 @@
+package slash
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zekrotja/ken"
+	"unreal.sh/neo/internal/middlewares"
+	"unreal.sh/neo/internal/services/music"
+
+	embedutils "unreal.sh/neo/internal/utils/embedutils"
+)
+
+type BackCommand struct{}
+
+var (
+	_ ken.Command            = (*BackCommand)(nil)
+	_ ken.SlashCommand       = (*BackCommand)(nil)
+	_ ken.GuildScopedCommand = (*BackCommand)(nil)
+
+	_ middlewares.RequiresDJCommand = (*BackCommand)(nil)
+)
+
+func (c *BackCommand) Name() string {
+	return "back"
+}
+
+func (c *BackCommand) Description() string {
+	return "Plays the previous song again."
+}
+
+func (c *BackCommand) Version() string {
+	return "1.0.0"
+}
+
+func (c *BackCommand) RequiresDJ() bool {
+	return true
+}
+
+func (c *BackCommand) Type() discordgo.ApplicationCommandType {
+	return discordgo.ChatApplicationCommand
+}
+
+func (c *BackCommand) Options() []*discordgo.ApplicationCommandOption {
+	return []*discordgo.ApplicationCommandOption{}
+}
+
+func (c *BackCommand) Guild() string {
+	return os.Getenv("MISFITS_GUILD_ID")
+}
+
+func (c *BackCommand) Run(ctx ken.Context) (err error) {
+	if err = ctx.Defer(); err != nil {
+		return nil
+	}
+
+	session := ctx.GetSession()
+
+	musicService := ctx.Get("MusicService").(*music.MusicService)
+	if musicService == nil {
+		slog.Error("Music service not found in context.")
+		return nil
+	}
+
+	guild, err := ctx.Guild()
+	if err != nil {
+		slog.Error("Failed to fetch guild.")
+		return err
+	}
+
+	var isBotInVoiceChannel bool
+	var botVoiceState *discordgo.VoiceState
+	for _, state := range guild.VoiceStates {
+		if state.UserID == session.State.User.ID {
+			isBotInVoiceChannel = true
+			botVoiceState = state
+		}
+	}
+
+	if !isBotInVoiceChannel {
+		ctx.RespondMessage("I'm not connected to a voice channel.")
+		return nil
+	}
+
+	var voiceChannelID *string
+	for _, state := range guild.VoiceStates {
+		if state.UserID == ctx.User().ID {
+			voiceChannelID = &state.ChannelID
+		}
+	}
+
+	if voiceChannelID == nil {
+		ctx.RespondMessage("You need to be in a voice channel to use this command.")
+		return nil
+	} else if *voiceChannelID != botVoiceState.ChannelID {
+		ctx.RespondMessage("You have to be in the same voice channel as me to use this command.")
+		return nil
+	}
+
+	musicSession := musicService.GetMusicSession(guild.ID)
+	if musicSession == nil {
+		ctx.RespondMessage("I'm not playing anything right now.")
+		return nil
+	}
+
+	opCtx, cancel := musicService.OperationContext()
+	defer cancel()
+
+	track, err := musicSession.Back(opCtx)
+	if errors.Is(err, music.ErrNoHistory) {
+		ctx.RespondEmbed(embedutils.CreateErrorEmbed("There's no previous track to go back to."))
+		return nil
+	} else if err != nil {
+		slog.Error("Failed to go back to the previous track.", slog.String("error", err.Error()))
+		return err
+	}
+
+	embed := embedutils.CreateBasicEmbed("⏮ **Went back to** " + music.TrackLink(track))
+	err = ctx.RespondEmbed(embed)
+	if err != nil {
+		slog.Error("Failed to respond to command.", slog.String("error", err.Error()))
+		return err
+	}
+
+	return nil
+}
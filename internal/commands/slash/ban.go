@@ -1,11 +1,13 @@
 package slash
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/zekrotja/ken"
+	"unreal.sh/neo/internal/database"
 	"unreal.sh/neo/internal/middlewares"
 	embedutils "unreal.sh/neo/internal/utils/embedutils"
 )
@@ -72,6 +74,11 @@ func (c *BanCommand) Run(ctx ken.Context) (err error) {
 		return
 	}
 
+	db := ctx.Get("Database").(*database.Database)
+	if db == nil {
+		return errors.New("failed to get Database")
+	}
+
 	session := ctx.GetSession()
 
 	guild, err := ctx.Guild()
@@ -124,6 +131,14 @@ func (c *BanCommand) Run(ctx ken.Context) (err error) {
 				fmt.Sprintf("Banned %s for `%s`", user.String(), reason),
 			)
 
+			embed.Footer = recordInfraction(db, database.Infraction{
+				GuildID:     guild.ID,
+				Type:        database.InfractionBan,
+				UserID:      user.ID,
+				ModeratorID: ctx.User().ID,
+				Reason:      reason,
+			})
+
 			ctx.FollowUpEmbed(embed).Send()
 
 			return true
@@ -0,0 +1,165 @@
+package slash
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zekrotja/ken"
+
+	"unreal.sh/neo/internal/database"
+	"unreal.sh/neo/internal/middlewares"
+
+	embedutils "unreal.sh/neo/internal/utils/embedutils"
+)
+
+type CaseCommand struct{}
+
+var (
+	_ ken.Command            = (*CaseCommand)(nil)
+	_ ken.SlashCommand       = (*CaseCommand)(nil)
+	_ ken.GuildScopedCommand = (*CaseCommand)(nil)
+
+	_ middlewares.RequiresPermissionCommand = (*CaseCommand)(nil)
+)
+
+func (c *CaseCommand) Name() string {
+	return "case"
+}
+
+func (c *CaseCommand) Description() string {
+	return "Shows and edits moderation cases."
+}
+
+func (c *CaseCommand) Version() string {
+	return "1.0.0"
+}
+
+func (c *CaseCommand) RequiresPermission() int64 {
+	return discordgo.PermissionModerateMembers
+}
+
+func (c *CaseCommand) Type() discordgo.ApplicationCommandType {
+	return discordgo.ChatApplicationCommand
+}
+
+func (c *CaseCommand) Options() []*discordgo.ApplicationCommandOption {
+	idOption := &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionInteger,
+		Name:        "id",
+		Description: "The number of the case.",
+		Required:    true,
+	}
+
+	return []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "show",
+			Description: "Shows a case.",
+			Options:     []*discordgo.ApplicationCommandOption{idOption},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "edit-reason",
+			Description: "Changes the reason of a case.",
+			Options: []*discordgo.ApplicationCommandOption{
+				idOption,
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "reason",
+					Description: "The new reason.",
+					Required:    true,
+				},
+			},
+		},
+	}
+}
+
+func (c *CaseCommand) Guild() string {
+	return os.Getenv("MISFITS_GUILD_ID")
+}
+
+func (c *CaseCommand) Run(ctx ken.Context) (err error) {
+	err = ctx.HandleSubCommands(
+		ken.SubCommandHandler{
+			Name: "show",
+			Run:  c.show,
+		},
+		ken.SubCommandHandler{
+			Name: "edit-reason",
+			Run:  c.editReason,
+		},
+	)
+
+	return err
+}
+
+func (c *CaseCommand) show(ctx ken.SubCommandContext) error {
+	if err := ctx.Defer(); err != nil {
+		return err
+	}
+
+	db := ctx.Get("Database").(*database.Database)
+	if db == nil {
+		return errors.New("failed to get Database")
+	}
+
+	infraction, ok := c.find(ctx, db)
+	if !ok {
+		return nil
+	}
+
+	return ctx.FollowUpEmbed(infractionEmbed(&infraction)).Send().Error
+}
+
+func (c *CaseCommand) editReason(ctx ken.SubCommandContext) error {
+	if err := ctx.Defer(); err != nil {
+		return err
+	}
+
+	db := ctx.Get("Database").(*database.Database)
+	if db == nil {
+		return errors.New("failed to get Database")
+	}
+
+	infraction, ok := c.find(ctx, db)
+	if !ok {
+		return nil
+	}
+
+	now := time.Now()
+	infraction.Reason = ctx.Options().GetByName("reason").StringValue()
+	infraction.EditedBy = ctx.User().ID
+	infraction.EditedAt = &now
+
+	infraction, err := db.UpdateInfraction(infraction)
+	if err != nil {
+		slog.Error("Failed to update infraction.", slog.String("error", err.Error()))
+		ctx.FollowUpEmbed(embedutils.CreateErrorEmbed("Failed to change the reason.")).Send()
+		return nil
+	}
+
+	return ctx.FollowUpEmbed(infractionEmbed(&infraction)).Send().Error
+}
+
+// find returns the case the id option refers to, telling the user if there's none.
+func (c *CaseCommand) find(ctx ken.SubCommandContext, db *database.Database) (infraction database.Infraction, ok bool) {
+	caseID := ctx.Options().GetByName("id").IntValue()
+
+	infraction, exists, err := db.GetInfraction(ctx.GetEvent().GuildID, caseID)
+	if err != nil {
+		slog.Error("Failed to get infraction.", slog.String("error", err.Error()))
+		ctx.FollowUpEmbed(embedutils.CreateErrorEmbed("Failed to get the case.")).Send()
+		return infraction, false
+	}
+
+	if !exists {
+		ctx.FollowUpEmbed(embedutils.CreateErrorEmbed(fmt.Sprintf("There's no case #%d.", caseID))).Send()
+		return infraction, false
+	}
+
+	return infraction, true
+}
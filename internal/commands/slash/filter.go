@@ -0,0 +1,315 @@
+package slash
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/disgoorg/disgolink/v3/lavalink"
+	"github.com/zekrotja/ken"
+
+	"unreal.sh/neo/internal/database"
+	"unreal.sh/neo/internal/middlewares"
+	"unreal.sh/neo/internal/services/music"
+	embedutils "unreal.sh/neo/internal/utils/embedutils"
+)
+
+type FilterCommand struct{}
+
+var (
+	_ ken.Command            = (*FilterCommand)(nil)
+	_ ken.SlashCommand       = (*FilterCommand)(nil)
+	_ ken.GuildScopedCommand = (*FilterCommand)(nil)
+
+	_ middlewares.RequiresDJCommand = (*FilterCommand)(nil)
+)
+
+func (c *FilterCommand) Name() string {
+	return "filter"
+}
+
+func (c *FilterCommand) Description() string {
+	return "Applies audio filters to the music."
+}
+
+func (c *FilterCommand) Version() string {
+	return "1.0.0"
+}
+
+func (c *FilterCommand) RequiresDJ() bool {
+	return true
+}
+
+func (c *FilterCommand) Type() discordgo.ApplicationCommandType {
+	return discordgo.ChatApplicationCommand
+}
+
+func (c *FilterCommand) Options() []*discordgo.ApplicationCommandOption {
+	presets := []*discordgo.ApplicationCommandOptionChoice{
+		{Name: "Bass Boost", Value: "bassboost"},
+		{Name: "Nightcore", Value: "nightcore"},
+		{Name: "Vaporwave", Value: "vaporwave"},
+		{Name: "8D", Value: "8d"},
+		{Name: "Karaoke", Value: "karaoke"},
+		{Name: "Soft", Value: "soft"},
+	}
+
+	minBand, minGain, minSpeed := 0.0, -0.25, 0.25
+
+	return []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "preset",
+			Description: "Applies a filter preset.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "preset",
+					Description: "The preset to apply.",
+					Required:    true,
+					Choices:     presets,
+				},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "equalizer",
+			Description: "Sets the gain of an equalizer band.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "band",
+					Description: "The band to change, from 0 (25Hz) to 14 (16kHz).",
+					Required:    true,
+					MinValue:    &minBand,
+					MaxValue:    14,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionNumber,
+					Name:        "gain",
+					Description: "The gain of the band, from -0.25 (muted) to 1 (doubled).",
+					Required:    true,
+					MinValue:    &minGain,
+					MaxValue:    1,
+				},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "speed",
+			Description: "Sets the playback speed and pitch.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionNumber,
+					Name:        "speed",
+					Description: "The playback speed, where 1 is normal.",
+					Required:    true,
+					MinValue:    &minSpeed,
+					MaxValue:    3,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionNumber,
+					Name:        "pitch",
+					Description: "The pitch, where 1 is normal. Defaults to the speed.",
+					Required:    false,
+					MinValue:    &minSpeed,
+					MaxValue:    3,
+				},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "clear",
+			Description: "Removes all filters.",
+		},
+	}
+}
+
+func (c *FilterCommand) Guild() string {
+	return os.Getenv("MISFITS_GUILD_ID")
+}
+
+func (c *FilterCommand) Run(ctx ken.Context) (err error) {
+	if err = ctx.Defer(); err != nil {
+		return nil
+	}
+
+	session := ctx.GetSession()
+
+	musicService := ctx.Get("MusicService").(*music.MusicService)
+	if musicService == nil {
+		slog.Error("Music service not found in context.")
+		return nil
+	}
+
+	guild, err := ctx.Guild()
+	if err != nil {
+		slog.Error("Failed to fetch guild.")
+		return err
+	}
+
+	var isBotInVoiceChannel bool
+	var botVoiceState *discordgo.VoiceState
+	for _, state := range guild.VoiceStates {
+		if state.UserID == session.State.User.ID {
+			isBotInVoiceChannel = true
+			botVoiceState = state
+		}
+	}
+
+	if !isBotInVoiceChannel {
+		ctx.RespondMessage("I'm not connected to a voice channel.")
+		return nil
+	}
+
+	var voiceChannelID *string
+	for _, state := range guild.VoiceStates {
+		if state.UserID == ctx.User().ID {
+			voiceChannelID = &state.ChannelID
+		}
+	}
+
+	if voiceChannelID == nil {
+		ctx.RespondMessage("You need to be in a voice channel to use this command.")
+		return nil
+	} else if *voiceChannelID != botVoiceState.ChannelID {
+		ctx.RespondMessage("You have to be in the same voice channel as me to use this command.")
+		return nil
+	}
+
+	if musicService.GetMusicSession(guild.ID) == nil {
+		slog.Error("Music session not found. Something's really wrong here.")
+		ctx.RespondMessage("I'm not playing anything right now.")
+		return nil
+	}
+
+	err = ctx.HandleSubCommands(
+		ken.SubCommandHandler{
+			Name: "preset",
+			Run:  c.preset,
+		},
+		ken.SubCommandHandler{
+			Name: "equalizer",
+			Run:  c.equalizer,
+		},
+		ken.SubCommandHandler{
+			Name: "speed",
+			Run:  c.speed,
+		},
+		ken.SubCommandHandler{
+			Name: "clear",
+			Run:  c.clear,
+		},
+	)
+
+	return err
+}
+
+func (c *FilterCommand) preset(ctx ken.SubCommandContext) error {
+	name := ctx.Options().GetByName("preset").StringValue()
+
+	preset, exists := music.FilterPresets[name]
+	if !exists {
+		ctx.FollowUpMessage("That preset doesn't exist.").Send()
+		return nil
+	}
+
+	return c.apply(ctx, func(filters lavalink.Filters) lavalink.Filters {
+		return music.MergeFilters(filters, preset)
+	})
+}
+
+func (c *FilterCommand) equalizer(ctx ken.SubCommandContext) error {
+	band := int(ctx.Options().GetByName("band").IntValue())
+	gain := float32(ctx.Options().GetByName("gain").FloatValue())
+
+	return c.apply(ctx, func(filters lavalink.Filters) lavalink.Filters {
+		// Copy the equalizer, since it may be shared with a preset.
+		var equalizer lavalink.Equalizer
+		if filters.Equalizer != nil {
+			equalizer = *filters.Equalizer
+		}
+
+		equalizer[band] = gain
+		filters.Equalizer = &equalizer
+
+		return filters
+	})
+}
+
+func (c *FilterCommand) speed(ctx ken.SubCommandContext) error {
+	speed := ctx.Options().GetByName("speed").FloatValue()
+
+	pitch := speed
+	pitchArg, hasPitchArg := ctx.Options().GetByNameOptional("pitch")
+	if hasPitchArg {
+		pitch = pitchArg.FloatValue()
+	}
+
+	return c.apply(ctx, func(filters lavalink.Filters) lavalink.Filters {
+		filters.Timescale = &lavalink.Timescale{Speed: speed, Pitch: pitch, Rate: 1.0}
+		return filters
+	})
+}
+
+func (c *FilterCommand) clear(ctx ken.SubCommandContext) error {
+	return c.apply(ctx, func(filters lavalink.Filters) lavalink.Filters {
+		return lavalink.Filters{}
+	})
+}
+
+// apply updates the current session's filters with f, persists them and
+// responds with the resulting list of active filters.
+func (c *FilterCommand) apply(ctx ken.SubCommandContext, f func(lavalink.Filters) lavalink.Filters) error {
+	musicService := ctx.Get("MusicService").(*music.MusicService)
+
+	db := ctx.Get("Database").(*database.Database)
+	if db == nil {
+		return errors.New("failed to get Database")
+	}
+
+	guildID := ctx.GetEvent().GuildID
+
+	// The session may have ended since the command started, like when the bot was disconnected.
+	musicSession := musicService.GetMusicSession(guildID)
+	if musicSession == nil {
+		ctx.FollowUpMessage("I'm not playing anything right now.").Send()
+		return nil
+	}
+
+	opCtx, cancel := musicService.OperationContext()
+	defer cancel()
+
+	filters, err := musicSession.UpdateFilters(opCtx, f)
+	if err != nil {
+		slog.Error("Failed to set filters.", slog.String("error", err.Error()))
+		ctx.FollowUpEmbed(embedutils.CreateErrorEmbed("Failed to apply filters.")).Send()
+		return nil
+	}
+
+	descriptions := music.DescribeFilters(filters)
+
+	var persisted *lavalink.Filters
+	if len(descriptions) > 0 {
+		persisted = &filters
+	}
+
+	_, err = db.SetMusicFilters(guildID, persisted)
+	if err != nil {
+		slog.Error("Failed to persist filters.", slog.String("guild_id", guildID), slog.String("error", err.Error()))
+	}
+
+	var description string
+	if len(descriptions) == 0 {
+		description = "🎛 **Cleared all filters.**"
+	} else {
+		description = fmt.Sprintf("🎛 **Active filters**\n%s", "• "+strings.Join(descriptions, "\n• "))
+	}
+
+	ctx.FollowUpEmbed(embedutils.CreateBasicEmbed(description)).Send()
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package slash
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zekrotja/ken"
+	"unreal.sh/neo/internal/services/music"
+	"unreal.sh/neo/pkg/widgets"
+
+	embedutils "unreal.sh/neo/internal/utils/embedutils"
+	sliceutils "unreal.sh/neo/internal/utils/sliceutils"
+	stringutils "unreal.sh/neo/internal/utils/stringutils"
+)
+
+type HistoryCommand struct{}
+
+var (
+	_ ken.Command            = (*HistoryCommand)(nil)
+	_ ken.SlashCommand       = (*HistoryCommand)(nil)
+	_ ken.GuildScopedCommand = (*HistoryCommand)(nil)
+)
+
+func (c *HistoryCommand) Name() string {
+	return "history"
+}
+
+func (c *HistoryCommand) Description() string {
+	return "Shows the recently played tracks."
+}
+
+func (c *HistoryCommand) Version() string {
+	return "1.0.0"
+}
+
+func (c *HistoryCommand) Type() discordgo.ApplicationCommandType {
+	return discordgo.ChatApplicationCommand
+}
+
+func (c *HistoryCommand) Options() []*discordgo.ApplicationCommandOption {
+	return []*discordgo.ApplicationCommandOption{}
+}
+
+func (c *HistoryCommand) Guild() string {
+	return os.Getenv("MISFITS_GUILD_ID")
+}
+
+func (c *HistoryCommand) Run(ctx ken.Context) (err error) {
+	if err = ctx.Defer(); err != nil {
+		return nil
+	}
+
+	musicService := ctx.Get("MusicService").(*music.MusicService)
+	if musicService == nil {
+		slog.Error("Music service not found in context.")
+		return nil
+	}
+
+	opCtx, cancel := musicService.OperationContext()
+	defer cancel()
+
+	history, err := musicService.History(opCtx, ctx.GetEvent().GuildID)
+	if err != nil {
+		slog.Error("Failed to get music history.", slog.String("error", err.Error()))
+		ctx.FollowUpEmbed(embedutils.CreateErrorEmbed("Failed to get the recently played tracks.")).Send()
+		return nil
+	}
+
+	// Newest first.
+	list := make([]string, len(history))
+	for i, entry := range history {
+		uri := ""
+		if entry.Track.Info.URI != nil {
+			uri = *entry.Track.Info.URI
+		}
+
+		list[len(history)-1-i] = fmt.Sprintf("**[%s](%s)** — Requested by %s, %s <t:%d:R>",
+			stringutils.Truncate(entry.Track.Info.Title, 30), uri, entry.Data.Requester(),
+			entry.Outcome, entry.EndedAt.Unix())
+	}
+
+	split := sliceutils.Chunk(list, 10)
+	pages := make([]*discordgo.MessageEmbed, 0)
+
+	if len(history) == 0 {
+		embed := embedutils.CreateBasicEmbed("Nothing was played yet.")
+		embed.Title = "📜  **History**"
+		pages = append(pages, embed)
+	} else {
+		for i, chunk := range split {
+			var description string
+
+			for j, item := range chunk {
+				description += fmt.Sprintf("**%d.** %s\n", i*10+j+1, item)
+			}
+
+			embed := embedutils.CreateBasicEmbed(description)
+			embed.Title = fmt.Sprintf("📜  **History** (Page %d/%d)", i+1, len(split))
+
+			pages = append(pages, embed)
+		}
+	}
+
+	paginator := widgets.NewPaginator(&ctx)
+	paginator.Add(pages...)
+
+	err = paginator.Spawn()
+	if err != nil {
+		slog.Error("Failed to spawn paginator.")
+		slog.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
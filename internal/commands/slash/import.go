@@ -0,0 +1,249 @@
+package slash
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zekrotja/ken"
+
+	"unreal.sh/neo/internal/services/music"
+	embedutils "unreal.sh/neo/internal/utils/embedutils"
+	stringutils "unreal.sh/neo/internal/utils/stringutils"
+)
+
+const (
+	importProgressInterval = 2 * time.Second // Editing the progress message more often gets rate limited.
+	importFailuresShown    = 10
+)
+
+type ImportCommand struct{}
+
+var (
+	_ ken.Command            = (*ImportCommand)(nil)
+	_ ken.SlashCommand       = (*ImportCommand)(nil)
+	_ ken.GuildScopedCommand = (*ImportCommand)(nil)
+)
+
+func (c *ImportCommand) Name() string {
+	return "import"
+}
+
+func (c *ImportCommand) Description() string {
+	return "Enqueues the tracks of a playlist file."
+}
+
+func (c *ImportCommand) Version() string {
+	return "1.0.0"
+}
+
+func (c *ImportCommand) Type() discordgo.ApplicationCommandType {
+	return discordgo.ChatApplicationCommand
+}
+
+func (c *ImportCommand) Options() []*discordgo.ApplicationCommandOption {
+	return []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionAttachment,
+			Name:        "file",
+			Description: "An M3U, PLS or XSPF playlist, an exported queue, or a text file with a link or song name on each line.",
+			Required:    true,
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "source",
+			Description: "Where to search for entries that aren't links. Defaults to the server's search sources.",
+			Required:    false,
+			Choices:     searchSourceChoices(),
+		},
+	}
+}
+
+func (c *ImportCommand) Guild() string {
+	return os.Getenv("MISFITS_GUILD_ID")
+}
+
+func (c *ImportCommand) Run(ctx ken.Context) (err error) {
+	session := ctx.GetSession()
+
+	if err = ctx.Defer(); err != nil {
+		return err
+	}
+
+	musicService := ctx.Get("MusicService").(*music.MusicService)
+	if musicService == nil {
+		slog.Error("Music service not found in context.")
+		return nil
+	}
+
+	attachmentID := ctx.Options().GetByName("file").StringValue()
+	attachment, ok := ctx.GetEvent().ApplicationCommandData().Resolved.Attachments[attachmentID]
+	if !ok {
+		slog.Error("Attachment not found in the interaction's resolved data.")
+		return nil
+	}
+
+	guild, err := ctx.Guild()
+	if err != nil {
+		slog.Error("Failed to fetch guild.")
+		return err
+	}
+
+	textChannel, err := ctx.Channel()
+	if err != nil {
+		slog.Error("Failed to fetch text channel.")
+		return err
+	}
+
+	var voiceChannelID *string
+	for _, state := range guild.VoiceStates {
+		if state.UserID == ctx.User().ID {
+			voiceChannelID = &state.ChannelID
+		}
+	}
+
+	if voiceChannelID == nil {
+		ctx.RespondMessage("You need to be in a voice channel to use this command.")
+		return nil
+	}
+
+	if attachment.Size > music.MaxPlaylistFileSize {
+		ctx.FollowUpEmbed(embedutils.CreateErrorEmbed(
+			fmt.Sprintf("That file is too large. Playlist files can be up to %d KB.", music.MaxPlaylistFileSize/1024))).Send()
+		return nil
+	}
+
+	importCtx, cancel := musicService.ImportContext()
+	defer cancel()
+
+	entries, err := music.DownloadPlaylistFile(importCtx, attachment.Filename, attachment.URL)
+	if errors.Is(err, music.ErrEmptyPlaylistFile) {
+		ctx.FollowUpEmbed(embedutils.CreateErrorEmbed("I couldn't find any tracks in that file.")).Send()
+		return nil
+	} else if err != nil {
+		slog.Error("Failed to read playlist file.", slog.String("file", attachment.Filename), slog.String("error", err.Error()))
+		ctx.FollowUpEmbed(embedutils.CreateErrorEmbed("I couldn't read that file. Is it a playlist?")).Send()
+		return nil
+	}
+
+	err = session.ChannelVoiceJoinManual(guild.ID, *voiceChannelID, false, false)
+	if err != nil {
+		slog.Error("Failed to join voice channel.")
+		return err
+	}
+
+	settings := musicService.MusicSettings(guild.ID)
+
+	sources := musicService.SearchSources(guild.ID)
+	if sourceArg, hasSourceArg := ctx.Options().GetByNameOptional("source"); hasSourceArg {
+		sources = []string{sourceArg.StringValue()}
+	}
+
+	var notes string
+	if limit := settings.MaxPlaylistSize; limit > 0 && len(entries) > limit {
+		notes += fmt.Sprintf("Only the first %d entries were imported, since that's this server's limit.\n", limit)
+		entries = entries[:limit]
+	}
+
+	slog.Info(fmt.Sprintf("Importing %d entries from %s.", len(entries), attachment.Filename))
+
+	progressEmbed := func(done int) *discordgo.MessageEmbed {
+		return embedutils.CreateBasicEmbed(fmt.Sprintf("📥 Importing **%s**...\n`%d/%d` entries loaded",
+			attachment.Filename, done, len(entries)))
+	}
+
+	msg := ctx.FollowUpEmbed(progressEmbed(0)).Send()
+	if msg.Error != nil {
+		slog.Error(fmt.Sprintf("Failed to send import progress message: %s", msg.Error.Error()))
+	}
+
+	musicSession := musicService.MusicSession(guild.ID, textChannel.ID)
+
+	var failed []string
+	done, loaded, rejected := 0, 0, 0
+	lastProgress := time.Now()
+
+	musicService.ImportPlaylistEntries(importCtx, entries, sources, func(imported music.ImportedEntry) bool {
+		done++
+
+		if msg.Error == nil && time.Since(lastProgress) >= importProgressInterval {
+			msg.EditEmbed(progressEmbed(done))
+			lastProgress = time.Now()
+		}
+
+		if imported.Err != nil {
+			slog.Warn("Failed to load playlist file entry.",
+				slog.String("entry", imported.Entry.Query()), slog.String("error", imported.Err.Error()))
+			failed = append(failed, imported.Entry.String())
+			return true
+		}
+
+		for i := range imported.Tracks {
+			request := music.NewTrackRequestData(ctx.User().ID, nil, imported.Source)
+
+			_, err := musicSession.PlayOrEnqueue(importCtx, &imported.Tracks[i], request)
+			if errors.Is(err, music.ErrUserQueueFull) || errors.Is(err, music.ErrQueueFull) {
+				notes += fmt.Sprintf("The rest were left out. %s\n", music.RejectionMessage(settings, err))
+				return false
+			} else if music.RejectionMessage(settings, err) != "" {
+				rejected++
+				continue
+			} else if err != nil {
+				slog.Error(fmt.Sprintf("Failed to play or enqueue track: %s", err.Error()))
+				continue
+			}
+
+			loaded++
+		}
+
+		return true
+	})
+
+	if importCtx.Err() != nil {
+		notes += "The import took too long, so the rest of the entries were left out.\n"
+	}
+
+	if rejected == 1 {
+		notes += "1 track was skipped, since it's too long, a duplicate or a stream this server doesn't allow.\n"
+	} else if rejected > 1 {
+		notes += fmt.Sprintf("%d tracks were skipped, since they're too long, duplicates or streams this server doesn't allow.\n", rejected)
+	}
+
+	var description string
+	if loaded == 1 {
+		description = fmt.Sprintf("Imported **%s** with %d track.\n\n", attachment.Filename, loaded)
+	} else {
+		description = fmt.Sprintf("Imported **%s** with %d tracks.\n\n", attachment.Filename, loaded)
+	}
+
+	if len(failed) > 0 {
+		description += fmt.Sprintf("**Couldn't load %d of the entries:**\n", len(failed))
+
+		for i, entry := range failed {
+			if i == importFailuresShown {
+				description += fmt.Sprintf("...and %d more.\n", len(failed)-importFailuresShown)
+				break
+			}
+
+			description += fmt.Sprintf("• %s\n", stringutils.Truncate(entry, 60))
+		}
+
+		description += "\n"
+	}
+
+	embed := embedutils.CreateBasicEmbed(strings.TrimSpace(description + notes))
+
+	if msg.Error == nil {
+		if err := msg.EditEmbed(embed); err == nil {
+			return nil
+		}
+	}
+
+	ctx.FollowUpEmbed(embed).Send()
+
+	return nil
+}
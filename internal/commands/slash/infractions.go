@@ -0,0 +1,206 @@
+package slash
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zekrotja/ken"
+
+	"unreal.sh/neo/internal/database"
+	"unreal.sh/neo/internal/middlewares"
+	"unreal.sh/neo/internal/utils/datetime"
+	"unreal.sh/neo/pkg/widgets"
+
+	embedutils "unreal.sh/neo/internal/utils/embedutils"
+	sliceutils "unreal.sh/neo/internal/utils/sliceutils"
+	stringutils "unreal.sh/neo/internal/utils/stringutils"
+)
+
+type InfractionsCommand struct{}
+
+var (
+	_ ken.Command            = (*InfractionsCommand)(nil)
+	_ ken.SlashCommand       = (*InfractionsCommand)(nil)
+	_ ken.GuildScopedCommand = (*InfractionsCommand)(nil)
+
+	_ middlewares.RequiresPermissionCommand = (*InfractionsCommand)(nil)
+)
+
+func (c *InfractionsCommand) Name() string {
+	return "infractions"
+}
+
+func (c *InfractionsCommand) Description() string {
+	return "Shows the cases of a member."
+}
+
+func (c *InfractionsCommand) Version() string {
+	return "1.0.0"
+}
+
+func (c *InfractionsCommand) RequiresPermission() int64 {
+	return discordgo.PermissionModerateMembers
+}
+
+func (c *InfractionsCommand) Type() discordgo.ApplicationCommandType {
+	return discordgo.ChatApplicationCommand
+}
+
+func (c *InfractionsCommand) Options() []*discordgo.ApplicationCommandOption {
+	return []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionUser,
+			Name:        "user",
+			Description: "The member to show the cases of.",
+			Required:    true,
+		},
+	}
+}
+
+func (c *InfractionsCommand) Guild() string {
+	return os.Getenv("MISFITS_GUILD_ID")
+}
+
+func (c *InfractionsCommand) Run(ctx ken.Context) (err error) {
+	if err = ctx.Defer(); err != nil {
+		return
+	}
+
+	db := ctx.Get("Database").(*database.Database)
+	if db == nil {
+		return errors.New("failed to get Database")
+	}
+
+	user := ctx.Options().GetByName("user").UserValue(ctx)
+
+	infractions, err := db.GetInfractions(ctx.GetEvent().GuildID, user.ID)
+	if err != nil {
+		slog.Error("Failed to get infractions.", slog.String("error", err.Error()))
+		ctx.FollowUpEmbed(embedutils.CreateErrorEmbed("Failed to get the cases.")).Send()
+		return nil
+	}
+
+	list := make([]string, len(infractions))
+	for i, infraction := range infractions {
+		list[i] = fmt.Sprintf("**#%d** %s — %s, by <@%s> <t:%d:R>", infraction.CaseID, infractionTypeName(&infraction),
+			stringutils.Truncate(infraction.Reason, 40), infraction.ModeratorID, infraction.CreatedAt.Unix())
+	}
+
+	split := sliceutils.Chunk(list, 10)
+	pages := make([]*discordgo.MessageEmbed, 0)
+
+	if len(list) == 0 {
+		embed := embedutils.CreateBasicEmbed(fmt.Sprintf("%s has a clean record.", user.Mention()))
+		embed.Title = fmt.Sprintf("📁  **%s**", user.String())
+		pages = append(pages, embed)
+	} else {
+		for i, chunk := range split {
+			embed := embedutils.CreateBasicEmbed(strings.Join(chunk, "\n"))
+			embed.Title = fmt.Sprintf("📁  **%s** (Page %d/%d)", user.String(), i+1, len(split))
+			embed.Footer = &discordgo.MessageEmbedFooter{Text: pluralCases(len(list))}
+
+			pages = append(pages, embed)
+		}
+	}
+
+	paginator := widgets.NewPaginator(&ctx)
+	paginator.Add(pages...)
+
+	err = paginator.Spawn()
+	if err != nil {
+		slog.Error("Failed to spawn paginator.")
+		slog.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// recordInfraction stores a moderation action as a case and returns a footer naming it,
+// for the embed confirming the action. The action was taken either way, so failing to record it is only noted.
+func recordInfraction(db *database.Database, infraction database.Infraction) *discordgo.MessageEmbedFooter {
+	infraction, err := db.CreateInfraction(infraction)
+	if err != nil {
+		slog.Error("Failed to record infraction.", slog.String("guild_id", infraction.GuildID),
+			slog.String("type", string(infraction.Type)), slog.String("error", err.Error()))
+
+		return &discordgo.MessageEmbedFooter{Text: "Failed to record a case for this."}
+	}
+
+	return &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("Case #%d", infraction.CaseID)}
+}
+
+// infractionEmbed shows everything recorded about a case.
+func infractionEmbed(infraction *database.Infraction) *discordgo.MessageEmbed {
+	embed := embedutils.CreateBasicEmbed("")
+	embed.Title = fmt.Sprintf("📁  **Case #%d** — %s", infraction.CaseID, infractionTypeName(infraction))
+	embed.Timestamp = infraction.CreatedAt.Format(time.RFC3339)
+	embed.Fields = []*discordgo.MessageEmbedField{
+		{
+			Name:   "User",
+			Value:  fmt.Sprintf("<@%s>", infraction.UserID),
+			Inline: true,
+		},
+		{
+			Name:   "Moderator",
+			Value:  fmt.Sprintf("<@%s>", infraction.ModeratorID),
+			Inline: true,
+		},
+	}
+
+	if infraction.Duration > 0 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   "Duration",
+			Value:  datetime.Pretty(time.Duration(infraction.Duration) * time.Millisecond),
+			Inline: true,
+		})
+	}
+
+	embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+		Name:  "Reason",
+		Value: fmt.Sprintf("```%s```", infraction.Reason),
+	})
+
+	if infraction.EditedAt != nil {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  "Edited",
+			Value: fmt.Sprintf("By <@%s> <t:%d:R>", infraction.EditedBy, infraction.EditedAt.Unix()),
+		})
+	}
+
+	return embed
+}
+
+func infractionTypeName(infraction *database.Infraction) string {
+	switch infraction.Type {
+	case database.InfractionBan:
+		return "Ban"
+	case database.InfractionUnban:
+		return "Unban"
+	case database.InfractionKick:
+		return "Kick"
+	case database.InfractionSoftban:
+		return "Softban"
+	case database.InfractionTimeout:
+		return "Timeout"
+	case database.InfractionWarn:
+		return "Warning"
+	case database.InfractionNote:
+		return "Note"
+	default:
+		return string(infraction.Type)
+	}
+}
+
+func pluralCases(n int) string {
+	if n == 1 {
+		return "1 case"
+	}
+
+	return fmt.Sprintf("%d cases", n)
+}
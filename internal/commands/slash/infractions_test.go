@@ -0,0 +1,29 @@
+package slash
+
+import (
+	"testing"
+
+	"unreal.sh/neo/internal/database"
+)
+
+func TestInfractionTypeName(t *testing.T) {
+	tests := []struct {
+		infractionType database.InfractionType
+		expected       string
+	}{
+		{database.InfractionBan, "Ban"},
+		{database.InfractionUnban, "Unban"},
+		{database.InfractionKick, "Kick"},
+		{database.InfractionSoftban, "Softban"},
+		{database.InfractionTimeout, "Timeout"},
+		{database.InfractionWarn, "Warning"},
+		{database.InfractionNote, "Note"},
+		{database.InfractionType("mute"), "mute"}, // Types this version doesn't know are shown as they're stored.
+	}
+
+	for _, test := range tests {
+		if name := infractionTypeName(&database.Infraction{Type: test.infractionType}); name != test.expected {
+			t.Errorf("infractionTypeName(%q) = %q, expected %q", test.infractionType, name, test.expected)
+		}
+	}
+}
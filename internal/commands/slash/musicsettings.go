@@ -0,0 +1,524 @@
+package slash
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zekrotja/ken"
+
+	"unreal.sh/neo/internal/database"
+	"unreal.sh/neo/internal/middlewares"
+	"unreal.sh/neo/internal/services/music"
+	"unreal.sh/neo/internal/utils/datetime"
+	embedutils "unreal.sh/neo/internal/utils/embedutils"
+	sliceutils "unreal.sh/neo/internal/utils/sliceutils"
+)
+
+type MusicSettingsCommand struct{}
+
+var (
+	_ ken.Command            = (*MusicSettingsCommand)(nil)
+	_ ken.SlashCommand       = (*MusicSettingsCommand)(nil)
+	_ ken.GuildScopedCommand = (*MusicSettingsCommand)(nil)
+
+	_ middlewares.RequiresPermissionCommand = (*MusicSettingsCommand)(nil)
+)
+
+func (c *MusicSettingsCommand) Name() string {
+	return "musicsettings"
+}
+
+func (c *MusicSettingsCommand) Description() string {
+	return "Configures how music works in this server."
+}
+
+func (c *MusicSettingsCommand) Version() string {
+	return "1.0.0"
+}
+
+func (c *MusicSettingsCommand) RequiresPermission() int64 {
+	return discordgo.PermissionManageServer
+}
+
+func (c *MusicSettingsCommand) Type() discordgo.ApplicationCommandType {
+	return discordgo.ChatApplicationCommand
+}
+
+func (c *MusicSettingsCommand) Options() []*discordgo.ApplicationCommandOption {
+	minPercentage, minLimit := 1.0, 0.0
+
+	return []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "show",
+			Description: "Shows the music settings.",
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "sources",
+			Description: "Sets the search sources, tried in order until one finds something.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "sources",
+					Description: "A comma-separated list, like \"deezer, youtube, soundcloud\". Leave empty to use the defaults.",
+					Required:    false,
+				},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "dj",
+			Description: "Sets the DJ role, which can control playback for everyone.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionRole,
+					Name:        "role",
+					Description: "The DJ role. Leave empty to let everyone control playback, though skipping takes a vote.",
+					Required:    false,
+				},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "votes",
+			Description: "Sets how many listeners have to vote to skip a track.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "percentage",
+					Description: "The percentage of listeners in the voice channel.",
+					Required:    true,
+					MinValue:    &minPercentage,
+					MaxValue:    100,
+				},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "queue",
+			Description: "Sets how the queue is shared between listeners.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "fair",
+					Description: "Whether requesters take turns in the queue instead of first come, first served.",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "user-limit",
+					Description: "How many tracks a single user may have queued. 0 means unlimited.",
+					Required:    false,
+					MinValue:    &minLimit,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "playlist-limit",
+					Description: "How many tracks of a playlist get imported. 0 means unlimited.",
+					Required:    false,
+					MinValue:    &minLimit,
+				},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "limits",
+			Description: "Sets which tracks can be queued.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "track-length",
+					Description: "The longest track that can be queued, in minutes. 0 means unlimited.",
+					Required:    false,
+					MinValue:    &minLimit,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "queue-length",
+					Description: "How many tracks the queue can hold. 0 means unlimited.",
+					Required:    false,
+					MinValue:    &minLimit,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "duplicates",
+					Description: "Whether tracks already queued or played recently can be queued again.",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "streams",
+					Description: "Whether livestreams can be queued.",
+					Required:    false,
+				},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "autoplay",
+			Description: "Sets whether related tracks keep playing once the queue runs dry.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "enabled",
+					Description: "Whether autoplay is on. Anything someone queues replaces what autoplay picked.",
+					Required:    true,
+				},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "requests",
+			Description: "Sets the request channel, where any message is played as a song request.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:         discordgo.ApplicationCommandOptionChannel,
+					Name:         "channel",
+					Description:  "The request channel. Leave empty to turn the request channel off.",
+					Required:     false,
+					ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+				},
+			},
+		},
+	}
+}
+
+func (c *MusicSettingsCommand) Guild() string {
+	return os.Getenv("MISFITS_GUILD_ID")
+}
+
+func (c *MusicSettingsCommand) Run(ctx ken.Context) (err error) {
+	err = ctx.HandleSubCommands(
+		ken.SubCommandHandler{
+			Name: "show",
+			Run:  c.show,
+		},
+		ken.SubCommandHandler{
+			Name: "sources",
+			Run:  c.sources,
+		},
+		ken.SubCommandHandler{
+			Name: "dj",
+			Run:  c.dj,
+		},
+		ken.SubCommandHandler{
+			Name: "votes",
+			Run:  c.votes,
+		},
+		ken.SubCommandHandler{
+			Name: "queue",
+			Run:  c.queue,
+		},
+		ken.SubCommandHandler{
+			Name: "limits",
+			Run:  c.limits,
+		},
+		ken.SubCommandHandler{
+			Name: "autoplay",
+			Run:  c.autoplay,
+		},
+		ken.SubCommandHandler{
+			Name: "requests",
+			Run:  c.requests,
+		},
+	)
+
+	return err
+}
+
+func (c *MusicSettingsCommand) show(ctx ken.SubCommandContext) error {
+	if err := ctx.Defer(); err != nil {
+		return err
+	}
+
+	musicService := ctx.Get("MusicService").(*music.MusicService)
+	if musicService == nil {
+		return errors.New("failed to get MusicService")
+	}
+
+	guildID := ctx.GetEvent().GuildID
+
+	sources := sliceutils.Map(musicService.SearchSources(guildID), func(id string) string {
+		source, _ := music.LookupSearchSource(id)
+		return source.Name
+	})
+
+	embed := embedutils.CreateBasicEmbed("Check out how music works in this server!")
+	embed.Title = "🎧  **Music Settings**"
+	settings := musicService.MusicSettings(guildID)
+
+	dj := "Everyone, though skipping takes a vote"
+	if settings.DJRoleID != "" {
+		dj = fmt.Sprintf("<@&%s>", settings.DJRoleID)
+	}
+
+	threshold := settings.SkipVoteThreshold
+	if threshold <= 0 {
+		threshold = music.DefaultSkipVoteThreshold
+	}
+
+	requestChannel := "None"
+	if settings.RequestChannelID != "" {
+		requestChannel = fmt.Sprintf("<#%s>", settings.RequestChannelID)
+	}
+
+	trackLength := "Unlimited"
+	if settings.MaxTrackLength > 0 {
+		trackLength = datetime.Pretty(time.Duration(settings.MaxTrackLength) * time.Second)
+	}
+
+	embed.Fields = []*discordgo.MessageEmbedField{
+		{
+			Name:  "Search sources",
+			Value: strings.Join(sources, " → "),
+		},
+		{
+			Name:   "DJ role",
+			Value:  dj,
+			Inline: true,
+		},
+		{
+			Name:   "Votes to skip",
+			Value:  fmt.Sprintf("%d%% of listeners", threshold),
+			Inline: true,
+		},
+		{
+			Name:   "Fair queue",
+			Value:  formatToggle(settings.FairQueue),
+			Inline: true,
+		},
+		{
+			Name:   "Tracks per user",
+			Value:  formatLimit(settings.MaxUserQueue),
+			Inline: true,
+		},
+		{
+			Name:   "Tracks per playlist",
+			Value:  formatLimit(settings.MaxPlaylistSize),
+			Inline: true,
+		},
+		{
+			Name:   "Track length",
+			Value:  trackLength,
+			Inline: true,
+		},
+		{
+			Name:   "Queue length",
+			Value:  formatLimit(settings.MaxQueueLength),
+			Inline: true,
+		},
+		{
+			Name:   "Duplicates",
+			Value:  formatToggle(!settings.RejectDuplicates),
+			Inline: true,
+		},
+		{
+			Name:   "Livestreams",
+			Value:  formatToggle(!settings.BlockStreams),
+			Inline: true,
+		},
+		{
+			Name:   "Autoplay",
+			Value:  formatToggle(settings.Autoplay),
+			Inline: true,
+		},
+		{
+			Name:   "Request channel",
+			Value:  requestChannel,
+			Inline: true,
+		},
+	}
+
+	ctx.FollowUpEmbed(embed).Send()
+
+	return nil
+}
+
+func (c *MusicSettingsCommand) sources(ctx ken.SubCommandContext) error {
+	var list string
+	listArg, hasListArg := ctx.Options().GetByNameOptional("sources")
+	if hasListArg {
+		list = listArg.StringValue()
+	}
+
+	sources, err := music.ParseSearchSources(list)
+	if err != nil {
+		ctx.SetEphemeral(true)
+		ctx.RespondEmbed(embedutils.CreateErrorEmbed(fmt.Sprintf("%s. Available sources are: %s.", err.Error(),
+			strings.Join(sliceutils.Map(music.SearchSources, func(source music.SearchSource) string {
+				return fmt.Sprintf("`%s`", source.ID)
+			}), ", "))))
+		return nil
+	}
+
+	return c.update(ctx, func(settings *database.MusicSettings) {
+		settings.SearchSources = sources
+	})
+}
+
+func (c *MusicSettingsCommand) dj(ctx ken.SubCommandContext) error {
+	var roleID string
+	roleArg, hasRoleArg := ctx.Options().GetByNameOptional("role")
+	if hasRoleArg {
+		roleID = roleArg.RoleValue(ctx).ID
+	}
+
+	return c.update(ctx, func(settings *database.MusicSettings) {
+		settings.DJRoleID = roleID
+	})
+}
+
+func (c *MusicSettingsCommand) votes(ctx ken.SubCommandContext) error {
+	percentage := int(ctx.Options().GetByName("percentage").IntValue())
+
+	return c.update(ctx, func(settings *database.MusicSettings) {
+		settings.SkipVoteThreshold = percentage
+	})
+}
+
+func (c *MusicSettingsCommand) queue(ctx ken.SubCommandContext) error {
+	return c.update(ctx, func(settings *database.MusicSettings) {
+		if fairArg, ok := ctx.Options().GetByNameOptional("fair"); ok {
+			settings.FairQueue = fairArg.BoolValue()
+		}
+
+		if userLimitArg, ok := ctx.Options().GetByNameOptional("user-limit"); ok {
+			settings.MaxUserQueue = int(userLimitArg.IntValue())
+		}
+
+		if playlistLimitArg, ok := ctx.Options().GetByNameOptional("playlist-limit"); ok {
+			settings.MaxPlaylistSize = int(playlistLimitArg.IntValue())
+		}
+	})
+}
+
+func (c *MusicSettingsCommand) limits(ctx ken.SubCommandContext) error {
+	return c.update(ctx, func(settings *database.MusicSettings) {
+		if trackLengthArg, ok := ctx.Options().GetByNameOptional("track-length"); ok {
+			settings.MaxTrackLength = int(trackLengthArg.IntValue()) * 60
+		}
+
+		if queueLengthArg, ok := ctx.Options().GetByNameOptional("queue-length"); ok {
+			settings.MaxQueueLength = int(queueLengthArg.IntValue())
+		}
+
+		if duplicatesArg, ok := ctx.Options().GetByNameOptional("duplicates"); ok {
+			settings.RejectDuplicates = !duplicatesArg.BoolValue()
+		}
+
+		if streamsArg, ok := ctx.Options().GetByNameOptional("streams"); ok {
+			settings.BlockStreams = !streamsArg.BoolValue()
+		}
+	})
+}
+
+func (c *MusicSettingsCommand) autoplay(ctx ken.SubCommandContext) error {
+	enabled := ctx.Options().GetByName("enabled").BoolValue()
+
+	return c.update(ctx, func(settings *database.MusicSettings) {
+		settings.Autoplay = enabled
+	})
+}
+
+func (c *MusicSettingsCommand) requests(ctx ken.SubCommandContext) error {
+	var channelID string
+	channelArg, hasChannelArg := ctx.Options().GetByNameOptional("channel")
+	if hasChannelArg {
+		channelID = channelArg.ChannelValue(ctx).ID
+	}
+
+	saved, err := c.save(ctx, func(settings *database.MusicSettings) {
+		settings.RequestChannelID = channelID
+	})
+	if !saved {
+		return err
+	}
+
+	if musicService, ok := ctx.Get("MusicService").(*music.MusicService); ok && musicService != nil {
+		opCtx, cancel := musicService.OperationContext()
+		defer cancel()
+
+		guildID := ctx.GetEvent().GuildID
+
+		err = musicService.SetRequestChannel(opCtx, guildID, channelID)
+		if err != nil {
+			slog.Error("Failed to set up request channel.", slog.String("guild_id", guildID), slog.String("error", err.Error()))
+		}
+
+		if err != nil && channelID != "" {
+			ctx.RespondEmbed(embedutils.CreateErrorEmbed(fmt.Sprintf(
+				"Failed to post the request panel in <#%s>. Make sure I can send, pin and delete messages there.", channelID)))
+			return nil
+		}
+	}
+
+	return c.show(ctx)
+}
+
+// update applies f to the guild's music settings, persists them and shows the result.
+func (c *MusicSettingsCommand) update(ctx ken.SubCommandContext, f func(*database.MusicSettings)) error {
+	if saved, err := c.save(ctx, f); !saved {
+		return err
+	}
+
+	return c.show(ctx)
+}
+
+// save applies f to the guild's music settings, persists them and applies them to the guild's session.
+// It returns whether the settings were saved, having told the user if they weren't.
+func (c *MusicSettingsCommand) save(ctx ken.SubCommandContext, f func(*database.MusicSettings)) (bool, error) {
+	db := ctx.Get("Database").(*database.Database)
+	if db == nil {
+		return false, errors.New("failed to get Database")
+	}
+
+	guildID := ctx.GetEvent().GuildID
+
+	settings, _, err := db.GetOrCreateGuildSettings(guildID)
+	if err != nil {
+		return false, err
+	}
+
+	f(&settings.MusicSettings)
+
+	_, err = db.SetMusicSettings(guildID, settings.MusicSettings)
+	if err != nil {
+		slog.Error("Failed to update music settings.", slog.String("guild_id", guildID), slog.String("error", err.Error()))
+		ctx.RespondEmbed(embedutils.CreateErrorEmbed("Failed to update the music settings."))
+		return false, nil
+	}
+
+	if musicService, ok := ctx.Get("MusicService").(*music.MusicService); ok && musicService != nil {
+		opCtx, cancel := musicService.OperationContext()
+		defer cancel()
+
+		err = musicService.ApplySettings(opCtx, guildID, settings.MusicSettings)
+		if err != nil {
+			slog.Error("Failed to apply music settings.", slog.String("guild_id", guildID), slog.String("error", err.Error()))
+		}
+	}
+
+	return true, nil
+}
+
+func formatToggle(enabled bool) string {
+	if enabled {
+		return "Enabled"
+	}
+
+	return "Disabled"
+}
+
+func formatLimit(limit int) string {
+	if limit <= 0 {
+		return "Unlimited"
+	}
+
+	return fmt.Sprint(limit)
+}
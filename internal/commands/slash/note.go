@@ -0,0 +1,94 @@
+package slash
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zekrotja/ken"
+
+	"unreal.sh/neo/internal/database"
+	"unreal.sh/neo/internal/middlewares"
+
+	embedutils "unreal.sh/neo/internal/utils/embedutils"
+)
+
+type NoteCommand struct{}
+
+var (
+	_ ken.Command            = (*NoteCommand)(nil)
+	_ ken.SlashCommand       = (*NoteCommand)(nil)
+	_ ken.GuildScopedCommand = (*NoteCommand)(nil)
+
+	_ middlewares.RequiresPermissionCommand = (*NoteCommand)(nil)
+)
+
+func (c *NoteCommand) Name() string {
+	return "note"
+}
+
+func (c *NoteCommand) Description() string {
+	return "Adds a note to a member's record, without telling them."
+}
+
+func (c *NoteCommand) Version() string {
+	return "1.0.0"
+}
+
+func (c *NoteCommand) RequiresPermission() int64 {
+	return discordgo.PermissionModerateMembers
+}
+
+func (c *NoteCommand) Type() discordgo.ApplicationCommandType {
+	return discordgo.ChatApplicationCommand
+}
+
+func (c *NoteCommand) Options() []*discordgo.ApplicationCommandOption {
+	return []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionUser,
+			Name:        "user",
+			Description: "The member to add the note for.",
+			Required:    true,
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "note",
+			Description: "The note.",
+			Required:    true,
+		},
+	}
+}
+
+func (c *NoteCommand) Guild() string {
+	return os.Getenv("MISFITS_GUILD_ID")
+}
+
+func (c *NoteCommand) Run(ctx ken.Context) (err error) {
+	// Notes are for moderators only.
+	ctx.SetEphemeral(true)
+
+	if err = ctx.Defer(); err != nil {
+		return
+	}
+
+	db := ctx.Get("Database").(*database.Database)
+	if db == nil {
+		return errors.New("failed to get Database")
+	}
+
+	user := ctx.Options().GetByName("user").UserValue(ctx)
+	note := ctx.Options().GetByName("note").StringValue()
+
+	embed := embedutils.CreateSuccessEmbed(fmt.Sprintf("Added a note for %s: `%s`", user.String(), note))
+	embed.Footer = recordInfraction(db, database.Infraction{
+		GuildID:     ctx.GetEvent().GuildID,
+		Type:        database.InfractionNote,
+		UserID:      user.ID,
+		ModeratorID: ctx.User().ID,
+		Reason:      note,
+	})
+
+	return ctx.FollowUpEmbed(embed).Send().Error
+}
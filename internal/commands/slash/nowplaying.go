@@ -1,17 +1,13 @@
 package slash
 
 import (
-	"fmt"
 	"log/slog"
-	"math"
 	"os"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/zekrotja/ken"
 	"unreal.sh/neo/internal/services/music"
-	"unreal.sh/neo/internal/utils/datetime"
-	"unreal.sh/neo/internal/utils/static"
-	stringutils "unreal.sh/neo/internal/utils/stringutils"
+	embedutils "unreal.sh/neo/internal/utils/embedutils"
 )
 
 type NowPlayingCommand struct{}
@@ -27,7 +23,7 @@ func (c *NowPlayingCommand) Name() string {
 }
 
 func (c *NowPlayingCommand) Description() string {
-	return "Tells the current song and it's position."
+	return "Shows the player panel with the current song and its controls."
 }
 
 func (c *NowPlayingCommand) Version() string {
@@ -47,6 +43,7 @@ func (c *NowPlayingCommand) Guild() string {
 }
 
 func (c *NowPlayingCommand) Run(ctx ken.Context) (err error) {
+	ctx.SetEphemeral(true)
 	if err = ctx.Defer(); err != nil {
 		return nil
 	}
@@ -101,53 +98,29 @@ func (c *NowPlayingCommand) Run(ctx ken.Context) (err error) {
 		return nil
 	}
 
-	track := musicSession.CurrentTrack
+	opCtx, cancel := musicService.OperationContext()
+	defer cancel()
 
-	remaining := datetime.Pretty(datetime.ToDuration(musicSession.Remaining()))
-
-	var description string
-	description += fmt.Sprintf("**[%s](%s)**\n", stringutils.Truncate(track.Info.Title, 30), *track.Info.URI)
-	description += fmt.Sprintf("`%s` remaining.\n\n", remaining)
-
-	max := 5
-	for i, t := range musicSession.Queue {
-		if i >= max {
-			description += fmt.Sprintf("And %d more...", len(musicSession.Queue)-max)
-			break
-		}
-
-		description += fmt.Sprintf("**%d.** [%s](%s)\n", i+1, stringutils.Truncate(t.Info.Title, 30), *t.Info.URI)
-	}
-
-	var progressBar string
-
-	duration := float64(musicSession.CurrentTrack.Info.Length.Seconds())
-	elapsed := float64(musicSession.Position().Seconds())
-	position := int(math.Floor((elapsed * 20.0) / duration))
-
-	for i := 0; i < 20; i++ {
-		if i == position {
-			progressBar += "🔵"
-		} else {
-			progressBar += "▬"
-		}
+	state, err := musicSession.State(opCtx)
+	if err != nil {
+		slog.Error("Failed to get music session state.", slog.String("error", err.Error()))
+		return err
 	}
 
-	embed := &discordgo.MessageEmbed{
-		Title:       "🎶 **Now Playing**",
-		Description: description,
-		Color:       static.ColorEmbedGray,
-		Footer: &discordgo.MessageEmbedFooter{
-			Text: fmt.Sprintf("%s \u200B %s / %s", progressBar,
-				datetime.Pretty(datetime.ToDuration(musicSession.Position())),
-				datetime.Pretty(datetime.ToDuration(musicSession.CurrentTrack.Info.Length))),
-		},
+	if !state.IsPlaying() {
+		ctx.RespondMessage("I'm not playing anything right now.")
+		return nil
 	}
 
-	err = ctx.RespondEmbed(embed)
+	// Move the panel down here, so it's easy to find.
+	err = musicSession.PostPanel(opCtx, ctx.GetEvent().ChannelID)
 	if err != nil {
-		slog.Error("Failed to respond to command.", slog.String("error", err.Error()))
+		slog.Error("Failed to post player panel.", slog.String("error", err.Error()))
+		ctx.FollowUpEmbed(embedutils.CreateErrorEmbed("Failed to show what's playing.")).Send()
+		return nil
 	}
 
+	ctx.FollowUpEmbed(embedutils.CreateBasicEmbed("🎶 Here's what's playing!")).Send()
+
 	return nil
 }
@@ -6,6 +6,7 @@ import (
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/zekrotja/ken"
+	"unreal.sh/neo/internal/middlewares"
 	"unreal.sh/neo/internal/services/music"
 
 	embedutils "unreal.sh/neo/internal/utils/embedutils"
@@ -17,6 +18,8 @@ var (
 	_ ken.Command            = (*PauseCommand)(nil)
 	_ ken.SlashCommand       = (*PauseCommand)(nil)
 	_ ken.GuildScopedCommand = (*PauseCommand)(nil)
+
+	_ middlewares.RequiresDJCommand = (*PauseCommand)(nil)
 )
 
 func (c *PauseCommand) Name() string {
@@ -31,6 +34,10 @@ func (c *PauseCommand) Version() string {
 	return "1.0.0"
 }
 
+func (c *PauseCommand) RequiresDJ() bool {
+	return true
+}
+
 func (c *PauseCommand) Type() discordgo.ApplicationCommandType {
 	return discordgo.ChatApplicationCommand
 }
@@ -98,7 +105,14 @@ func (c *PauseCommand) Run(ctx ken.Context) (err error) {
 		return nil
 	}
 
-	musicSession.Pause()
+	opCtx, cancel := musicService.OperationContext()
+	defer cancel()
+
+	err = musicSession.Pause(opCtx)
+	if err != nil {
+		slog.Error("Failed to pause track.", slog.String("error", err.Error()))
+		return err
+	}
 
 	embed := embedutils.CreateBasicEmbed("⏸ **Paused**")
 	err = ctx.RespondEmbed(embed)
@@ -1,7 +1,6 @@
 package slash
 
 import (
-	"context"
 	"fmt"
 	"log/slog"
 	"os"
@@ -12,6 +11,7 @@ import (
 	"github.com/zekrotja/ken"
 
 	"unreal.sh/neo/internal/services/music"
+	"unreal.sh/neo/internal/utils/datetime"
 	embedutils "unreal.sh/neo/internal/utils/embedutils"
 	"unreal.sh/neo/internal/utils/static"
 	stringutils "unreal.sh/neo/internal/utils/stringutils"
@@ -20,9 +20,10 @@ import (
 type PlayCommand struct{}
 
 var (
-	_ ken.Command            = (*PlayCommand)(nil)
-	_ ken.SlashCommand       = (*PlayCommand)(nil)
-	_ ken.GuildScopedCommand = (*PlayCommand)(nil)
+	_ ken.Command             = (*PlayCommand)(nil)
+	_ ken.SlashCommand        = (*PlayCommand)(nil)
+	_ ken.GuildScopedCommand  = (*PlayCommand)(nil)
+	_ ken.AutocompleteCommand = (*PlayCommand)(nil)
 )
 
 func (c *PlayCommand) Name() string {
@@ -47,7 +48,27 @@ func (c *PlayCommand) Options() []*discordgo.ApplicationCommandOption {
 			Type:        discordgo.ApplicationCommandOptionString,
 			Name:        "query",
 			Description: "The song's name or link.",
-			Required:    true,
+			Required:    false,
+		},
+		{
+			Type:         discordgo.ApplicationCommandOptionString,
+			Name:         "library",
+			Description:  "A song from the server's own library, instead of a query.",
+			Required:     false,
+			Autocomplete: true,
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "source",
+			Description: "Where to search for the song. Defaults to the server's search sources.",
+			Required:    false,
+			Choices:     searchSourceChoices(),
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionBoolean,
+			Name:        "pick",
+			Description: "Lets you pick from the search results instead of playing the first one.",
+			Required:    false,
 		},
 	}
 }
@@ -57,6 +78,72 @@ func (c *PlayCommand) Guild() string {
 }
 
 func (c *PlayCommand) Run(ctx ken.Context) (err error) {
+	query := ""
+	if queryArg, hasQueryArg := ctx.Options().GetByNameOptional("query"); hasQueryArg {
+		query = queryArg.StringValue()
+	}
+
+	// Library tracks are loaded like any other query, just with a prefix.
+	if libraryArg, hasLibraryArg := ctx.Options().GetByNameOptional("library"); hasLibraryArg {
+		musicService := ctx.Get("MusicService").(*music.MusicService)
+		if musicService == nil || musicService.Library() == nil {
+			ctx.SetEphemeral(true)
+			return ctx.RespondMessage("This server has no music library.")
+		}
+
+		query = music.LibraryPrefix + libraryArg.StringValue()
+	}
+
+	if query == "" {
+		ctx.SetEphemeral(true)
+		return ctx.RespondMessage("Tell me what to play, either with a query or from the library.")
+	}
+
+	pick := false
+	pickArg, hasPickArg := ctx.Options().GetByNameOptional("pick")
+	if hasPickArg {
+		pick = pickArg.BoolValue()
+	}
+
+	source := ""
+	sourceArg, hasSourceArg := ctx.Options().GetByNameOptional("source")
+	if hasSourceArg {
+		source = sourceArg.StringValue()
+	}
+
+	return c.play(ctx, query, source, pick, defaultSearchResults)
+}
+
+// Autocomplete suggests tracks from the library as the user types.
+func (c *PlayCommand) Autocomplete(ctx *ken.AutocompleteContext) ([]*discordgo.ApplicationCommandOptionChoice, error) {
+	input, ok := ctx.GetInput("library")
+	if !ok {
+		return nil, nil
+	}
+
+	musicService := ctx.Get("MusicService").(*music.MusicService)
+	if musicService == nil || musicService.Library() == nil {
+		return nil, nil
+	}
+
+	entries := musicService.Library().Search(input, music.LibrarySearchLimit)
+
+	choices := make([]*discordgo.ApplicationCommandOptionChoice, len(entries))
+	for i, entry := range entries {
+		choices[i] = &discordgo.ApplicationCommandOptionChoice{
+			// Choice names can be 100 characters long at most.
+			Name:  fmt.Sprintf("%s (%s)", stringutils.Truncate(entry.String(), 80), datetime.Pretty(datetime.ToDuration(entry.Length))),
+			Value: entry.ID,
+		}
+	}
+
+	return choices, nil
+}
+
+// play joins the user's voice channel and plays or enqueues the tracks found for query.
+// A non-empty source forces a search source instead of the guild's configured ones.
+// If pick is set, the user picks from the first few search results instead of getting the first one.
+func (c *PlayCommand) play(ctx ken.Context, query string, source string, pick bool, results int) (err error) {
 	session := ctx.GetSession()
 
 	if err = ctx.Defer(); err != nil {
@@ -99,82 +186,93 @@ func (c *PlayCommand) Run(ctx ken.Context) (err error) {
 		return err
 	}
 
-	query := ctx.Options().GetByName("query").StringValue()
+	// A source can be forced either with the option or by prefixing the query, like "soundcloud:lofi".
+	sources := musicService.SearchSources(guild.ID)
+	if prefixed, rest, ok := music.SplitSourcePrefix(query); ok {
+		sources = []string{prefixed.ID}
+		query = rest
+	} else if source != "" {
+		sources = []string{source}
+	}
 
 	if !stringutils.IsUrl(query) {
-		slog.Info(fmt.Sprintf("Searching for \"%s\" on Deezer.", query))
-		query = fmt.Sprintf("dzsearch:%s", query)
+		slog.Info(fmt.Sprintf("Searching for \"%s\".", query), slog.Any("sources", sources))
 	} else {
 		slog.Info("Query is an URL. Loading directly.")
 	}
 
 	musicSession := musicService.MusicSession(guild.ID, textChannel.ID)
+	settings := musicService.MusicSettings(guild.ID)
+
+	opCtx, cancel := musicService.OperationContext()
+	defer cancel()
 
-	musicService.LavalinkClient.BestNode().LoadTracksHandler(context.TODO(), query, disgolink.NewResultHandler(
+	result, usedSource, err := musicService.LoadTracks(opCtx, query, sources)
+
+	music.HandleLoadResult(result, err, disgolink.NewResultHandler(
 		// Loaded a single track
 		func(track lavalink.Track) {
 			slog.Info(fmt.Sprintf("Found track %s.", track.Info.Title))
-			request := music.NewTrackRequestData(ctx.User().ID, nil)
-			musicSession.PlayOrEnqueue(&track, request)
+			request := music.NewTrackRequestData(ctx.User().ID, nil, usedSource)
+			enqueued, err := musicSession.PlayOrEnqueue(opCtx, &track, request)
+			if rejected := music.RejectionMessage(settings, err); rejected != "" {
+				ctx.FollowUpEmbed(embedutils.CreateErrorEmbed(rejected)).Send()
+				return
+			} else if err != nil {
+				slog.Error(fmt.Sprintf("Failed to play or enqueue track: %s", err.Error()))
+				ctx.FollowUpMessage("Failed to play or enqueue track.").Send()
+				return
+			}
+
+			followUpPlayOrEnqueue(ctx, &track, request, enqueued)
 		},
 
 		// Loaded a playlist
 		func(playlist lavalink.Playlist) {
 			slog.Info(fmt.Sprintf("Found a playlist %s with %d tracks.", playlist.Info.Name, len(playlist.Tracks)))
 
+			loaded, notes := musicSession.EnqueueAll(opCtx, ctx.User().ID, settings, playlist.Tracks, usedSource)
+
 			var description string
-			if len(playlist.Tracks) == 1 {
-				description = fmt.Sprintf("Loaded playlist **%s** with %d track.\n\n", playlist.Info.Name, len(playlist.Tracks))
+			if loaded == 1 {
+				description = fmt.Sprintf("Loaded playlist **%s** with %d track.\n\n", playlist.Info.Name, loaded)
 			} else {
-				description = fmt.Sprintf("Loaded playlist **%s** with %d tracks.\n\n", playlist.Info.Name, len(playlist.Tracks))
+				description = fmt.Sprintf("Loaded playlist **%s** with %d tracks.\n\n", playlist.Info.Name, loaded)
 			}
 
-			embed := embedutils.CreateBasicEmbed(description)
+			embed := embedutils.CreateBasicEmbed(description + notes)
 			ctx.FollowUpEmbed(embed).Send()
-
-			for _, track := range playlist.Tracks {
-				request := music.NewTrackRequestData(ctx.User().ID, nil)
-				musicSession.PlayOrEnqueue(&track, request)
-			}
 		},
 
 		// Loaded a search result
 		func(tracks []lavalink.Track) {
 			if len(tracks) == 0 {
-				ctx.FollowUpMessage("No results found.")
+				ctx.FollowUpMessage("No results found.").Send()
 				return
 			}
 
 			slog.Info(fmt.Sprintf("Found %d tracks.", len(tracks)))
 
-			request := music.NewTrackRequestData(ctx.User().ID, nil)
-			enqueued, err := musicSession.PlayOrEnqueue(&tracks[0], request)
-			if err != nil {
-				slog.Error(fmt.Sprintf("Failed to play or enqueue track: %s", err.Error()))
-				ctx.FollowUpMessage("Failed to play or enqueue track.")
+			if pick {
+				err := spawnTrackPicker(ctx, musicService, tracks, usedSource, results)
+				if err != nil {
+					slog.Error(fmt.Sprintf("Failed to spawn track picker: %s", err.Error()))
+				}
 				return
 			}
 
-			if enqueued {
-				slog.Info(fmt.Sprintf("Enqueued %s.", tracks[0].Info.Title))
-
-				description := fmt.Sprintf("▶ **Enqueued** [%s](%s)",
-					stringutils.Truncate(tracks[0].Info.Title, 30), *tracks[0].Info.URI)
-
-				embed := &discordgo.MessageEmbed{
-					Color:       static.ColorEmbedGray,
-					Description: description,
-				}
-
-				if m := ctx.FollowUpEmbed(embed).Send(); m.Error != nil {
-					slog.Error(fmt.Sprintf("Failed to send enqueued message: %s", m.Error.Error()))
-					return
-				}
-
+			request := music.NewTrackRequestData(ctx.User().ID, nil, usedSource)
+			enqueued, err := musicSession.PlayOrEnqueue(opCtx, &tracks[0], request)
+			if rejected := music.RejectionMessage(settings, err); rejected != "" {
+				ctx.FollowUpEmbed(embedutils.CreateErrorEmbed(rejected)).Send()
+				return
+			} else if err != nil {
+				slog.Error(fmt.Sprintf("Failed to play or enqueue track: %s", err.Error()))
+				ctx.FollowUpMessage("Failed to play or enqueue track.").Send()
 				return
-			} else {
-				ctx.FollowUpMessage("Here we go!").Send()
 			}
+
+			followUpPlayOrEnqueue(ctx, &tracks[0], request, enqueued)
 		},
 
 		// Nothing matching the query found
@@ -186,7 +284,7 @@ func (c *PlayCommand) Run(ctx ken.Context) (err error) {
 
 		// Something went wrong
 		func(err error) {
-			slog.Error("Something went wrong while loading the track.")
+			slog.Error("Something went wrong while loading the track.", slog.String("error", err.Error()))
 			ctx.SetEphemeral(true)
 			ctx.FollowUpMessage("Something went wrong while loading the track.").Send()
 		},
@@ -195,10 +293,49 @@ func (c *PlayCommand) Run(ctx ken.Context) (err error) {
 	return nil
 }
 
+// followUpPlayOrEnqueue tells the user whether their track started playing or was enqueued.
+func followUpPlayOrEnqueue(ctx ken.ContextResponder, track *lavalink.Track, request music.TrackRequestData, enqueued bool) {
+	if !enqueued {
+		ctx.FollowUpMessage("Here we go!").Send()
+		return
+	}
+
+	slog.Info(fmt.Sprintf("Enqueued %s.", track.Info.Title))
+
+	description := "▶ **Enqueued** " + music.TrackLink(track)
+
+	if source, ok := music.LookupSearchSource(request.Source); ok {
+		description += fmt.Sprintf(" from %s", source.Name)
+	} else if request.Source == music.LibrarySource {
+		description += " from the library"
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Color:       static.ColorEmbedGray,
+		Description: description,
+	}
+
+	if m := ctx.FollowUpEmbed(embed).Send(); m.Error != nil {
+		slog.Error(fmt.Sprintf("Failed to send enqueued message: %s", m.Error.Error()))
+	}
+}
+
+func searchSourceChoices() []*discordgo.ApplicationCommandOptionChoice {
+	choices := make([]*discordgo.ApplicationCommandOptionChoice, len(music.SearchSources))
+	for i, source := range music.SearchSources {
+		choices[i] = &discordgo.ApplicationCommandOptionChoice{
+			Name:  source.Name,
+			Value: source.ID,
+		}
+	}
+
+	return choices
+}
+
 func GetPlayingNotificationEmbed(track *lavalink.Track) *discordgo.MessageEmbed {
 	return &discordgo.MessageEmbed{
 		Title:       "🎶 **Now Playing**",
 		Color:       static.ColorEmbedGray,
-		Description: fmt.Sprintf("[%s](%s)", stringutils.Truncate(track.Info.Title, 30), *track.Info.URI),
+		Description: music.TrackLink(track),
 	}
 }
@@ -0,0 +1,752 @@
+package slash
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/disgoorg/disgolink/v3/lavalink"
+	"github.com/zekrotja/ken"
+
+	"unreal.sh/neo/internal/database"
+	"unreal.sh/neo/internal/services/music"
+	"unreal.sh/neo/pkg/widgets"
+
+	embedutils "unreal.sh/neo/internal/utils/embedutils"
+	sliceutils "unreal.sh/neo/internal/utils/sliceutils"
+	stringutils "unreal.sh/neo/internal/utils/stringutils"
+)
+
+const (
+	playlistScopePersonal = "personal"
+	playlistScopeServer   = "server"
+)
+
+type PlaylistCommand struct{}
+
+var (
+	_ ken.Command            = (*PlaylistCommand)(nil)
+	_ ken.SlashCommand       = (*PlaylistCommand)(nil)
+	_ ken.GuildScopedCommand = (*PlaylistCommand)(nil)
+)
+
+func (c *PlaylistCommand) Name() string {
+	return "playlist"
+}
+
+func (c *PlaylistCommand) Description() string {
+	return "Manages saved playlists."
+}
+
+func (c *PlaylistCommand) Version() string {
+	return "1.0.0"
+}
+
+func (c *PlaylistCommand) Type() discordgo.ApplicationCommandType {
+	return discordgo.ChatApplicationCommand
+}
+
+func (c *PlaylistCommand) Options() []*discordgo.ApplicationCommandOption {
+	minPosition := 1.0
+
+	name := func(description string) *discordgo.ApplicationCommandOption {
+		return &discordgo.ApplicationCommandOption{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "name",
+			Description: description,
+			Required:    true,
+			MaxLength:   music.MaxPlaylistNameLength,
+		}
+	}
+
+	scope := &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionString,
+		Name:        "scope",
+		Description: "Whether it's one of your own playlists or one shared with the server. Defaults to your own.",
+		Required:    false,
+		Choices: []*discordgo.ApplicationCommandOptionChoice{
+			{Name: "Personal", Value: playlistScopePersonal},
+			{Name: "Server", Value: playlistScopeServer},
+		},
+	}
+
+	return []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "save",
+			Description: "Saves the current track and the queue as a playlist, replacing it if it exists.",
+			Options:     []*discordgo.ApplicationCommandOption{name("The playlist's name."), scope},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "load",
+			Description: "Enqueues the tracks of a saved playlist.",
+			Options:     []*discordgo.ApplicationCommandOption{name("The playlist's name."), scope},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "add",
+			Description: "Adds a track to a saved playlist, creating it if it doesn't exist.",
+			Options: []*discordgo.ApplicationCommandOption{
+				name("The playlist's name."),
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "query",
+					Description: "The song's name or link. Defaults to the current track.",
+					Required:    false,
+				},
+				scope,
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "remove",
+			Description: "Removes a track from a saved playlist.",
+			Options: []*discordgo.ApplicationCommandOption{
+				name("The playlist's name."),
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "position",
+					Description: "The track's position in the playlist, as shown by /playlist show.",
+					Required:    true,
+					MinValue:    &minPosition,
+				},
+				scope,
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "list",
+			Description: "Lists your playlists and the server's.",
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "show",
+			Description: "Shows the tracks of a saved playlist.",
+			Options:     []*discordgo.ApplicationCommandOption{name("The playlist's name."), scope},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "delete",
+			Description: "Deletes a saved playlist.",
+			Options:     []*discordgo.ApplicationCommandOption{name("The playlist's name."), scope},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "rename",
+			Description: "Renames a saved playlist.",
+			Options: []*discordgo.ApplicationCommandOption{
+				name("The playlist's current name."),
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "new-name",
+					Description: "The playlist's new name.",
+					Required:    true,
+					MaxLength:   music.MaxPlaylistNameLength,
+				},
+				scope,
+			},
+		},
+	}
+}
+
+func (c *PlaylistCommand) Guild() string {
+	return os.Getenv("MISFITS_GUILD_ID")
+}
+
+func (c *PlaylistCommand) Run(ctx ken.Context) (err error) {
+	err = ctx.HandleSubCommands(
+		ken.SubCommandHandler{
+			Name: "save",
+			Run:  c.save,
+		},
+		ken.SubCommandHandler{
+			Name: "load",
+			Run:  c.load,
+		},
+		ken.SubCommandHandler{
+			Name: "add",
+			Run:  c.add,
+		},
+		ken.SubCommandHandler{
+			Name: "remove",
+			Run:  c.remove,
+		},
+		ken.SubCommandHandler{
+			Name: "list",
+			Run:  c.list,
+		},
+		ken.SubCommandHandler{
+			Name: "show",
+			Run:  c.show,
+		},
+		ken.SubCommandHandler{
+			Name: "delete",
+			Run:  c.delete,
+		},
+		ken.SubCommandHandler{
+			Name: "rename",
+			Run:  c.rename,
+		},
+	)
+
+	return err
+}
+
+func (c *PlaylistCommand) save(ctx ken.SubCommandContext) error {
+	if err := ctx.Defer(); err != nil {
+		return err
+	}
+
+	db := ctx.Get("Database").(*database.Database)
+	if db == nil {
+		return errors.New("failed to get Database")
+	}
+
+	musicService := ctx.Get("MusicService").(*music.MusicService)
+	if musicService == nil {
+		return errors.New("failed to get MusicService")
+	}
+
+	var tracks []lavalink.Track
+	if musicSession := musicService.GetMusicSession(ctx.GetEvent().GuildID); musicSession != nil {
+		opCtx, cancel := musicService.OperationContext()
+		defer cancel()
+
+		state, err := musicSession.State(opCtx)
+		if err != nil {
+			slog.Error("Failed to get music session state.", slog.String("error", err.Error()))
+			return err
+		}
+
+		tracks = state.Tracks()
+	}
+
+	if len(tracks) == 0 {
+		ctx.FollowUpEmbed(embedutils.CreateErrorEmbed("There's nothing to save, since I'm not playing anything and the queue is empty.")).Send()
+		return nil
+	}
+
+	name := c.name(ctx, "name")
+	ownerID, guildID := c.scope(ctx)
+
+	playlist, exists, err := db.GetPlaylist(ownerID, guildID, name)
+	if err != nil {
+		return c.fail(ctx, "Failed to get the playlist.", err)
+	}
+
+	if exists && !c.owns(ctx, &playlist) {
+		return nil
+	}
+
+	note := ""
+	if len(tracks) > music.MaxSavedPlaylistTracks {
+		note = fmt.Sprintf("\nOnly the first %d tracks were saved, since that's as many as a playlist can hold.", music.MaxSavedPlaylistTracks)
+	}
+
+	playlist.Tracks = music.SavedTracks(tracks)
+
+	if exists {
+		_, err = db.UpdatePlaylist(playlist)
+	} else {
+		playlist.OwnerID, playlist.GuildID, playlist.Name = ctx.User().ID, guildID, name
+		_, err = db.CreatePlaylist(playlist)
+	}
+
+	if err != nil {
+		return c.fail(ctx, "Failed to save the playlist.", err)
+	}
+
+	ctx.FollowUpEmbed(embedutils.CreateBasicEmbed(
+		fmt.Sprintf("💾 Saved %s to %s.%s", pluralTracks(len(playlist.Tracks)), describePlaylist(&playlist), note))).Send()
+
+	return nil
+}
+
+func (c *PlaylistCommand) load(ctx ken.SubCommandContext) error {
+	session := ctx.GetSession()
+
+	if err := ctx.Defer(); err != nil {
+		return err
+	}
+
+	db := ctx.Get("Database").(*database.Database)
+	if db == nil {
+		return errors.New("failed to get Database")
+	}
+
+	musicService := ctx.Get("MusicService").(*music.MusicService)
+	if musicService == nil {
+		return errors.New("failed to get MusicService")
+	}
+
+	playlist, ok, err := c.find(ctx, db)
+	if !ok {
+		return err
+	}
+
+	if len(playlist.Tracks) == 0 {
+		ctx.FollowUpEmbed(embedutils.CreateErrorEmbed("That playlist is empty.")).Send()
+		return nil
+	}
+
+	guild, err := ctx.Guild()
+	if err != nil {
+		slog.Error("Failed to fetch guild.")
+		return err
+	}
+
+	textChannel, err := ctx.Channel()
+	if err != nil {
+		slog.Error("Failed to fetch text channel.")
+		return err
+	}
+
+	var voiceChannelID *string
+	for _, state := range guild.VoiceStates {
+		if state.UserID == ctx.User().ID {
+			voiceChannelID = &state.ChannelID
+		}
+	}
+
+	if voiceChannelID == nil {
+		ctx.FollowUpMessage("You need to be in a voice channel to use this command.").Send()
+		return nil
+	}
+
+	err = session.ChannelVoiceJoinManual(guild.ID, *voiceChannelID, false, false)
+	if err != nil {
+		slog.Error("Failed to join voice channel.")
+		return err
+	}
+
+	musicSession := musicService.MusicSession(guild.ID, textChannel.ID)
+	settings := musicService.MusicSettings(guild.ID)
+
+	opCtx, cancel := musicService.OperationContext()
+	defer cancel()
+
+	loaded, notes := musicSession.EnqueueAll(opCtx, ctx.User().ID, settings, playlist.Tracks, "")
+
+	ctx.FollowUpEmbed(embedutils.CreateBasicEmbed(
+		fmt.Sprintf("Loaded %s with %s.\n\n%s", describePlaylist(&playlist), pluralTracks(loaded), notes))).Send()
+
+	return nil
+}
+
+func (c *PlaylistCommand) add(ctx ken.SubCommandContext) error {
+	if err := ctx.Defer(); err != nil {
+		return err
+	}
+
+	db := ctx.Get("Database").(*database.Database)
+	if db == nil {
+		return errors.New("failed to get Database")
+	}
+
+	musicService := ctx.Get("MusicService").(*music.MusicService)
+	if musicService == nil {
+		return errors.New("failed to get MusicService")
+	}
+
+	name := c.name(ctx, "name")
+	ownerID, guildID := c.scope(ctx)
+
+	playlist, exists, err := db.GetPlaylist(ownerID, guildID, name)
+	if err != nil {
+		return c.fail(ctx, "Failed to get the playlist.", err)
+	}
+
+	if exists && !c.owns(ctx, &playlist) {
+		return nil
+	}
+
+	opCtx, cancel := musicService.OperationContext()
+	defer cancel()
+
+	var tracks []lavalink.Track
+	if queryArg, hasQueryArg := ctx.Options().GetByNameOptional("query"); hasQueryArg {
+		tracks, err = c.loadQuery(opCtx, musicService, ctx.GetEvent().GuildID, queryArg.StringValue())
+		if errors.Is(err, music.ErrNoSearchResults) {
+			ctx.FollowUpEmbed(embedutils.CreateErrorEmbed("No results found.")).Send()
+			return nil
+		} else if err != nil {
+			return c.fail(ctx, "Something went wrong while loading the track.", err)
+		}
+	} else if musicSession := musicService.GetMusicSession(ctx.GetEvent().GuildID); musicSession != nil {
+		state, err := musicSession.State(opCtx)
+		if err != nil {
+			slog.Error("Failed to get music session state.", slog.String("error", err.Error()))
+			return err
+		}
+
+		if state.CurrentTrack != nil {
+			tracks = []lavalink.Track{*state.CurrentTrack}
+		}
+	}
+
+	if len(tracks) == 0 {
+		ctx.FollowUpEmbed(embedutils.CreateErrorEmbed("I'm not playing anything right now, so tell me what to add.")).Send()
+		return nil
+	}
+
+	var added int
+	playlist.Tracks, added = music.AppendSavedTracks(playlist.Tracks, tracks)
+	if added == 0 {
+		ctx.FollowUpEmbed(embedutils.CreateErrorEmbed(
+			fmt.Sprintf("That playlist is full. Playlists can hold up to %d tracks.", music.MaxSavedPlaylistTracks))).Send()
+		return nil
+	}
+
+	if exists {
+		_, err = db.UpdatePlaylist(playlist)
+	} else {
+		playlist.OwnerID, playlist.GuildID, playlist.Name = ctx.User().ID, guildID, name
+		_, err = db.CreatePlaylist(playlist)
+	}
+
+	if err != nil {
+		return c.fail(ctx, "Failed to save the playlist.", err)
+	}
+
+	description := pluralTracks(added)
+	if added == 1 {
+		description = fmt.Sprintf("**%s**", music.TrackLink(&tracks[0]))
+	}
+
+	ctx.FollowUpEmbed(embedutils.CreateBasicEmbed(fmt.Sprintf("➕ Added %s to %s.", description, describePlaylist(&playlist)))).Send()
+
+	return nil
+}
+
+// loadQuery loads the tracks to add to a playlist: a single track, the first search result, or a whole playlist.
+func (c *PlaylistCommand) loadQuery(opCtx context.Context, musicService *music.MusicService, guildID string, query string) ([]lavalink.Track, error) {
+	sources := musicService.SearchSources(guildID)
+	if prefixed, rest, ok := music.SplitSourcePrefix(query); ok {
+		sources = []string{prefixed.ID}
+		query = rest
+	}
+
+	result, _, err := musicService.LoadTracks(opCtx, query, sources)
+	if err != nil {
+		return nil, err
+	}
+
+	switch data := result.Data.(type) {
+	case lavalink.Track:
+		return []lavalink.Track{data}, nil
+	case lavalink.Search:
+		if len(data) > 0 {
+			return data[:1], nil
+		}
+	case lavalink.Playlist:
+		return data.Tracks, nil
+	case lavalink.Exception:
+		return nil, data
+	}
+
+	return nil, music.ErrNoSearchResults
+}
+
+func (c *PlaylistCommand) remove(ctx ken.SubCommandContext) error {
+	if err := ctx.Defer(); err != nil {
+		return err
+	}
+
+	db := ctx.Get("Database").(*database.Database)
+	if db == nil {
+		return errors.New("failed to get Database")
+	}
+
+	playlist, ok, err := c.find(ctx, db)
+	if !ok || !c.owns(ctx, &playlist) {
+		return err
+	}
+
+	position := int(ctx.Options().GetByName("position").IntValue())
+	if position > len(playlist.Tracks) {
+		ctx.FollowUpEmbed(embedutils.CreateErrorEmbed(
+			fmt.Sprintf("%s only has %s.", describePlaylist(&playlist), pluralTracks(len(playlist.Tracks))))).Send()
+		return nil
+	}
+
+	removed := playlist.Tracks[position-1]
+	playlist.Tracks = append(playlist.Tracks[:position-1], playlist.Tracks[position:]...)
+
+	if _, err := db.UpdatePlaylist(playlist); err != nil {
+		return c.fail(ctx, "Failed to save the playlist.", err)
+	}
+
+	ctx.FollowUpEmbed(embedutils.CreateBasicEmbed(fmt.Sprintf("➖ Removed **%s** from %s.",
+		music.TrackLink(&removed), describePlaylist(&playlist)))).Send()
+
+	return nil
+}
+
+func (c *PlaylistCommand) list(ctx ken.SubCommandContext) error {
+	if err := ctx.Defer(); err != nil {
+		return err
+	}
+
+	db := ctx.Get("Database").(*database.Database)
+	if db == nil {
+		return errors.New("failed to get Database")
+	}
+
+	personal, err := db.GetPlaylists(ctx.User().ID, "")
+	if err != nil {
+		return c.fail(ctx, "Failed to get the playlists.", err)
+	}
+
+	shared, err := db.GetPlaylists(ctx.User().ID, ctx.GetEvent().GuildID)
+	if err != nil {
+		return c.fail(ctx, "Failed to get the playlists.", err)
+	}
+
+	describe := func(playlists []database.Playlist, owners bool) string {
+		if len(playlists) == 0 {
+			return "None yet."
+		}
+
+		lines := sliceutils.Map(playlists, func(playlist database.Playlist) string {
+			line := fmt.Sprintf("**%s** — %s", playlist.Name, pluralTracks(len(playlist.Tracks)))
+			if owners {
+				line += fmt.Sprintf(", by <@%s>", playlist.OwnerID)
+			}
+
+			return line
+		})
+
+		return stringutils.Truncate(strings.Join(lines, "\n"), 1000)
+	}
+
+	embed := embedutils.CreateBasicEmbed("")
+	embed.Title = "💾  **Playlists**"
+	embed.Fields = []*discordgo.MessageEmbedField{
+		{
+			Name:  "Yours",
+			Value: describe(personal, false),
+		},
+		{
+			Name:  "This server's",
+			Value: describe(shared, true),
+		},
+	}
+
+	ctx.FollowUpEmbed(embed).Send()
+
+	return nil
+}
+
+func (c *PlaylistCommand) show(ctx ken.SubCommandContext) error {
+	if err := ctx.Defer(); err != nil {
+		return err
+	}
+
+	db := ctx.Get("Database").(*database.Database)
+	if db == nil {
+		return errors.New("failed to get Database")
+	}
+
+	playlist, ok, err := c.find(ctx, db)
+	if !ok {
+		return err
+	}
+
+	list := make([]string, len(playlist.Tracks))
+	for i, track := range playlist.Tracks {
+		list[i] = fmt.Sprintf("**%d.** **%s** — %s", i+1, music.TrackLink(&track), stringutils.Truncate(track.Info.Author, 20))
+	}
+
+	split := sliceutils.Chunk(list, 10)
+	pages := make([]*discordgo.MessageEmbed, 0)
+
+	if len(list) == 0 {
+		embed := embedutils.CreateBasicEmbed("This playlist is empty.")
+		embed.Title = fmt.Sprintf("💾  **%s**", playlist.Name)
+		pages = append(pages, embed)
+	} else {
+		for i, chunk := range split {
+			embed := embedutils.CreateBasicEmbed(strings.Join(chunk, "\n"))
+			embed.Title = fmt.Sprintf("💾  **%s** (Page %d/%d)", playlist.Name, i+1, len(split))
+			embed.Footer = &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("%s, saved by %s", pluralTracks(len(list)), c.ownerName(ctx, &playlist))}
+
+			pages = append(pages, embed)
+		}
+	}
+
+	kctx := ken.Context(ctx)
+	paginator := widgets.NewPaginator(&kctx)
+	paginator.Add(pages...)
+
+	err = paginator.Spawn()
+	if err != nil {
+		slog.Error("Failed to spawn paginator.")
+		slog.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (c *PlaylistCommand) delete(ctx ken.SubCommandContext) error {
+	if err := ctx.Defer(); err != nil {
+		return err
+	}
+
+	db := ctx.Get("Database").(*database.Database)
+	if db == nil {
+		return errors.New("failed to get Database")
+	}
+
+	playlist, ok, err := c.find(ctx, db)
+	if !ok || !c.owns(ctx, &playlist) {
+		return err
+	}
+
+	if err := db.DeletePlaylist(playlist.ID); err != nil {
+		return c.fail(ctx, "Failed to delete the playlist.", err)
+	}
+
+	ctx.FollowUpEmbed(embedutils.CreateBasicEmbed(fmt.Sprintf("🗑 Deleted %s.", describePlaylist(&playlist)))).Send()
+
+	return nil
+}
+
+func (c *PlaylistCommand) rename(ctx ken.SubCommandContext) error {
+	if err := ctx.Defer(); err != nil {
+		return err
+	}
+
+	db := ctx.Get("Database").(*database.Database)
+	if db == nil {
+		return errors.New("failed to get Database")
+	}
+
+	playlist, ok, err := c.find(ctx, db)
+	if !ok || !c.owns(ctx, &playlist) {
+		return err
+	}
+
+	newName := c.name(ctx, "new-name")
+
+	_, taken, err := db.GetPlaylist(playlist.OwnerID, playlist.GuildID, newName)
+	if err != nil {
+		return c.fail(ctx, "Failed to get the playlist.", err)
+	}
+
+	if taken {
+		ctx.FollowUpEmbed(embedutils.CreateErrorEmbed(fmt.Sprintf("There's already a playlist called **%s**.", newName))).Send()
+		return nil
+	}
+
+	oldName := describePlaylist(&playlist)
+	playlist.Name = newName
+
+	if _, err := db.UpdatePlaylist(playlist); err != nil {
+		return c.fail(ctx, "Failed to rename the playlist.", err)
+	}
+
+	ctx.FollowUpEmbed(embedutils.CreateBasicEmbed(fmt.Sprintf("✏ Renamed %s to **%s**.", oldName, newName))).Send()
+
+	return nil
+}
+
+// name returns a playlist name option, without surrounding whitespace.
+func (c *PlaylistCommand) name(ctx ken.SubCommandContext, option string) string {
+	return strings.TrimSpace(ctx.Options().GetByName(option).StringValue())
+}
+
+// scope returns which playlists the command is about: the user's own, with an empty
+// guild ID, or the server's.
+func (c *PlaylistCommand) scope(ctx ken.SubCommandContext) (ownerID string, guildID string) {
+	scope := playlistScopePersonal
+	if scopeArg, hasScopeArg := ctx.Options().GetByNameOptional("scope"); hasScopeArg {
+		scope = scopeArg.StringValue()
+	}
+
+	return ctx.User().ID, playlistGuildID(scope, ctx.GetEvent().GuildID)
+}
+
+// playlistGuildID returns the guild ID playlists in a scope are stored under, which is empty for personal ones.
+func playlistGuildID(scope string, guildID string) string {
+	if scope == playlistScopeServer {
+		return guildID
+	}
+
+	return ""
+}
+
+// find gets the playlist named by the command's options.
+// If it doesn't exist, the user is told so and ok is false.
+func (c *PlaylistCommand) find(ctx ken.SubCommandContext, db *database.Database) (playlist database.Playlist, ok bool, err error) {
+	name := c.name(ctx, "name")
+	ownerID, guildID := c.scope(ctx)
+
+	playlist, exists, err := db.GetPlaylist(ownerID, guildID, name)
+	if err != nil {
+		return playlist, false, c.fail(ctx, "Failed to get the playlist.", err)
+	}
+
+	if !exists {
+		where := "You don't have"
+		if guildID != "" {
+			where = "This server doesn't have"
+		}
+
+		ctx.FollowUpEmbed(embedutils.CreateErrorEmbed(fmt.Sprintf("%s a playlist called **%s**.", where, name))).Send()
+		return playlist, false, nil
+	}
+
+	return playlist, true, nil
+}
+
+// owns returns whether the user owns a playlist, telling them it isn't theirs to change if not.
+func (c *PlaylistCommand) owns(ctx ken.SubCommandContext, playlist *database.Playlist) bool {
+	if playlist.IsOwnedBy(ctx.User().ID) {
+		return true
+	}
+
+	ctx.FollowUpEmbed(embedutils.CreateErrorEmbed(
+		fmt.Sprintf("Only <@%s>, who saved %s, can change it.", playlist.OwnerID, describePlaylist(playlist)))).Send()
+
+	return false
+}
+
+// ownerName returns the username of a playlist's owner, or a mention if it can't be fetched.
+func (c *PlaylistCommand) ownerName(ctx ken.SubCommandContext, playlist *database.Playlist) string {
+	user, err := ctx.GetSession().User(playlist.OwnerID)
+	if err != nil {
+		return "someone"
+	}
+
+	return user.Username
+}
+
+// fail logs a database error and tells the user what failed.
+func (c *PlaylistCommand) fail(ctx ken.SubCommandContext, message string, err error) error {
+	slog.Error(message, slog.String("error", err.Error()))
+	ctx.FollowUpEmbed(embedutils.CreateErrorEmbed(message)).Send()
+
+	return nil
+}
+
+func describePlaylist(playlist *database.Playlist) string {
+	if playlist.IsPersonal() {
+		return fmt.Sprintf("your playlist **%s**", playlist.Name)
+	}
+
+	return fmt.Sprintf("the server playlist **%s**", playlist.Name)
+}
+
+func pluralTracks(n int) string {
+	if n == 1 {
+		return "1 track"
+	}
+
+	return fmt.Sprintf("%d tracks", n)
+}
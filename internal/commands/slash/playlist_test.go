@@ -0,0 +1,20 @@
+package slash
+
+import "testing"
+
+func TestPlaylistGuildID(t *testing.T) {
+	tests := []struct {
+		scope    string
+		expected string
+	}{
+		{playlistScopePersonal, ""},
+		{playlistScopeServer, "10"},
+		{"", ""}, // Playlists are personal unless the server's are asked for.
+	}
+
+	for _, test := range tests {
+		if guildID := playlistGuildID(test.scope, "10"); guildID != test.expected {
+			t.Errorf("playlistGuildID(%q) = %q, expected %q", test.scope, guildID, test.expected)
+		}
+	}
+}
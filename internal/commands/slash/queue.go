@@ -1,9 +1,11 @@
 package slash
 
 import (
+	"bytes"
 	"fmt"
 	"log/slog"
 	"os"
+	"time"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/zekrotja/ken"
@@ -40,7 +42,31 @@ func (c *QueueCommand) Type() discordgo.ApplicationCommandType {
 }
 
 func (c *QueueCommand) Options() []*discordgo.ApplicationCommandOption {
-	return []*discordgo.ApplicationCommandOption{}
+	return []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "show",
+			Description: "Shows the track queue.",
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "export",
+			Description: "Uploads the current track and the queue as a file.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "format",
+					Description: "The file's format. JSON files can be imported again with /import exactly as they are.",
+					Required:    true,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "M3U", Value: string(music.ExportFormatM3U)},
+						{Name: "JSON", Value: string(music.ExportFormatJSON)},
+						{Name: "Text", Value: string(music.ExportFormatText)},
+					},
+				},
+			},
+		},
+	}
 }
 
 func (c *QueueCommand) Guild() string {
@@ -48,22 +74,35 @@ func (c *QueueCommand) Guild() string {
 }
 
 func (c *QueueCommand) Run(ctx ken.Context) (err error) {
-	if err = ctx.Defer(); err != nil {
-		return nil
-	}
+	err = ctx.HandleSubCommands(
+		ken.SubCommandHandler{
+			Name: "show",
+			Run:  c.show,
+		},
+		ken.SubCommandHandler{
+			Name: "export",
+			Run:  c.export,
+		},
+	)
+
+	return err
+}
 
+// state checks that the user is listening along and returns the session's state,
+// or nil if something was wrong and the user was told so already.
+func (c *QueueCommand) state(ctx ken.SubCommandContext) (*music.SessionState, error) {
 	session := ctx.GetSession()
 
 	musicService := ctx.Get("MusicService").(*music.MusicService)
 	if musicService == nil {
 		slog.Error("Music service not found in context.")
-		return nil
+		return nil, nil
 	}
 
 	guild, err := ctx.Guild()
 	if err != nil {
 		slog.Error("Failed to fetch guild.")
-		return err
+		return nil, err
 	}
 
 	var isBotInVoiceChannel bool
@@ -77,7 +116,7 @@ func (c *QueueCommand) Run(ctx ken.Context) (err error) {
 
 	if !isBotInVoiceChannel {
 		ctx.RespondMessage("I'm not connected to a voice channel.")
-		return nil
+		return nil, nil
 	}
 
 	var voiceChannelID *string
@@ -89,25 +128,47 @@ func (c *QueueCommand) Run(ctx ken.Context) (err error) {
 
 	if voiceChannelID == nil {
 		ctx.RespondMessage("You need to be in a voice channel to use this command.")
-		return nil
+		return nil, nil
 	} else if *voiceChannelID != botVoiceState.ChannelID {
 		ctx.RespondMessage("You have to be in the same voice channel as me to use this command.")
-		return nil
+		return nil, nil
 	}
 
 	musicSession := musicService.GetMusicSession(guild.ID)
 	if musicSession == nil {
 		slog.Error("Music session not found. Something's really wrong here.")
 		ctx.RespondMessage("I'm not playing anything right now.")
+		return nil, nil
+	}
+
+	opCtx, cancel := musicService.OperationContext()
+	defer cancel()
+
+	state, err := musicSession.State(opCtx)
+	if err != nil {
+		slog.Error("Failed to get music session state.", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	return &state, nil
+}
+
+func (c *QueueCommand) show(ctx ken.SubCommandContext) (err error) {
+	if err = ctx.Defer(); err != nil {
 		return nil
 	}
 
-	queue := musicSession.Queue
+	state, err := c.state(ctx)
+	if state == nil {
+		return err
+	}
+
+	queue := state.Queue
+	starts := state.EstimatedStarts()
 	list := make([]string, len(queue))
 
 	for i, track := range queue {
-		var data music.TrackRequestData
-		track.UserData.Unmarshal(&data)
+		data := state.Data[i]
 
 		author := "Unknown"
 		user, err := ctx.GetSession().User(data.AuthorID)
@@ -115,8 +176,13 @@ func (c *QueueCommand) Run(ctx ken.Context) (err error) {
 			author = user.Username
 		}
 
-		list[i] = fmt.Sprintf("**%d.** **[%s](%s)** — Requested by %s", i+1,
-			stringutils.Truncate(track.Info.Title, 30), *track.Info.URI, author)
+		start := "unknown"
+		if starts[i] >= 0 {
+			start = fmt.Sprintf("<t:%d:R>", time.Now().Add(starts[i]).Unix())
+		}
+
+		list[i] = fmt.Sprintf("**%d.** **[%s](%s)** — Requested by %s, starts %s", i+1,
+			stringutils.Truncate(track.Info.Title, 30), *track.Info.URI, author, start)
 	}
 
 	split := sliceutils.Chunk(list, 5)
@@ -141,7 +207,8 @@ func (c *QueueCommand) Run(ctx ken.Context) (err error) {
 		}
 	}
 
-	paginator := widgets.NewPaginator(&ctx)
+	kctx := ken.Context(ctx)
+	paginator := widgets.NewPaginator(&kctx)
 	paginator.Add(pages...)
 
 	err = paginator.Spawn()
@@ -153,3 +220,74 @@ func (c *QueueCommand) Run(ctx ken.Context) (err error) {
 
 	return nil
 }
+
+func (c *QueueCommand) export(ctx ken.SubCommandContext) (err error) {
+	if err = ctx.Defer(); err != nil {
+		return nil
+	}
+
+	state, err := c.state(ctx)
+	if state == nil {
+		return err
+	}
+
+	if state.CurrentTrack == nil && len(state.Queue) == 0 {
+		ctx.FollowUpMessage("There's nothing to export, since I'm not playing anything and the queue is empty.").Send()
+		return nil
+	}
+
+	names := make(map[string]string)
+	for _, data := range append(state.Data, c.currentRequest(state)) {
+		if _, ok := names[data.AuthorID]; ok || data.AuthorID == "" {
+			continue
+		}
+
+		if user, err := ctx.GetSession().User(data.AuthorID); err == nil {
+			names[data.AuthorID] = user.Username
+		}
+	}
+
+	format := music.ExportFormat(ctx.Options().GetByName("format").StringValue())
+
+	data, extension, err := music.ExportQueue(*state, format, names)
+	if err != nil {
+		slog.Error("Failed to export queue.", slog.String("error", err.Error()))
+		return err
+	}
+
+	tracks := len(state.Queue)
+	if state.CurrentTrack != nil {
+		tracks++
+	}
+
+	description := fmt.Sprintf("📤 Exported %d tracks.", tracks)
+	if tracks == 1 {
+		description = "📤 Exported 1 track."
+	}
+
+	m := ctx.FollowUp(true, &discordgo.WebhookParams{
+		Embeds: []*discordgo.MessageEmbed{embedutils.CreateBasicEmbed(description)},
+		Files: []*discordgo.File{
+			{
+				Name:        fmt.Sprintf("queue-%s.%s", time.Now().Format("2006-01-02"), extension),
+				ContentType: "text/plain",
+				Reader:      bytes.NewReader(data),
+			},
+		},
+	}).Send()
+	if m.Error != nil {
+		slog.Error(fmt.Sprintf("Failed to send exported queue: %s", m.Error.Error()))
+	}
+
+	return nil
+}
+
+// currentRequest returns the request data of the current track, if there is one.
+func (c *QueueCommand) currentRequest(state *music.SessionState) music.TrackRequestData {
+	var data music.TrackRequestData
+	if state.CurrentTrack != nil {
+		state.CurrentTrack.UserData.Unmarshal(&data)
+	}
+
+	return data
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/zekrotja/ken"
+	"unreal.sh/neo/internal/middlewares"
 	"unreal.sh/neo/internal/services/music"
 	embedutils "unreal.sh/neo/internal/utils/embedutils"
 )
@@ -16,6 +17,8 @@ var (
 	_ ken.Command            = (*ResumeCommand)(nil)
 	_ ken.SlashCommand       = (*ResumeCommand)(nil)
 	_ ken.GuildScopedCommand = (*ResumeCommand)(nil)
+
+	_ middlewares.RequiresDJCommand = (*ResumeCommand)(nil)
 )
 
 func (c *ResumeCommand) Name() string {
@@ -30,6 +33,10 @@ func (c *ResumeCommand) Version() string {
 	return "1.0.0"
 }
 
+func (c *ResumeCommand) RequiresDJ() bool {
+	return true
+}
+
 func (c *ResumeCommand) Type() discordgo.ApplicationCommandType {
 	return discordgo.ChatApplicationCommand
 }
@@ -97,7 +104,10 @@ func (c *ResumeCommand) Run(ctx ken.Context) (err error) {
 		return nil
 	}
 
-	err = musicSession.Resume()
+	opCtx, cancel := musicService.OperationContext()
+	defer cancel()
+
+	err = musicSession.Resume(opCtx)
 	if err != nil {
 		slog.Error("Failed to resume track.", slog.String("error", err.Error()))
 		return err
@@ -0,0 +1,219 @@
+package slash
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/disgoorg/disgolink/v3/lavalink"
+	"github.com/zekrotja/ken"
+
+	"unreal.sh/neo/internal/services/music"
+	"unreal.sh/neo/internal/utils/datetime"
+	embedutils "unreal.sh/neo/internal/utils/embedutils"
+	stringutils "unreal.sh/neo/internal/utils/stringutils"
+)
+
+const (
+	defaultSearchResults = 5
+	maxSearchResults     = 25 // Discord doesn't allow more options in a select menu.
+	searchPickerTimeout  = 60 * time.Second
+)
+
+type SearchCommand struct{}
+
+var (
+	_ ken.Command            = (*SearchCommand)(nil)
+	_ ken.SlashCommand       = (*SearchCommand)(nil)
+	_ ken.GuildScopedCommand = (*SearchCommand)(nil)
+)
+
+func (c *SearchCommand) Name() string {
+	return "search"
+}
+
+func (c *SearchCommand) Description() string {
+	return "Searches for a song and lets you pick which one to play."
+}
+
+func (c *SearchCommand) Version() string {
+	return "1.0.0"
+}
+
+func (c *SearchCommand) Type() discordgo.ApplicationCommandType {
+	return discordgo.ChatApplicationCommand
+}
+
+func (c *SearchCommand) Options() []*discordgo.ApplicationCommandOption {
+	minResults := 1.0
+
+	return []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "query",
+			Description: "The song's name.",
+			Required:    true,
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "source",
+			Description: "Where to search for the song. Defaults to the server's search sources.",
+			Required:    false,
+			Choices:     searchSourceChoices(),
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionInteger,
+			Name:        "results",
+			Description: "How many results to show.",
+			Required:    false,
+			MinValue:    &minResults,
+			MaxValue:    maxSearchResults,
+		},
+	}
+}
+
+func (c *SearchCommand) Guild() string {
+	return os.Getenv("MISFITS_GUILD_ID")
+}
+
+func (c *SearchCommand) Run(ctx ken.Context) (err error) {
+	query := ctx.Options().GetByName("query").StringValue()
+
+	results := defaultSearchResults
+	resultsArg, hasResultsArg := ctx.Options().GetByNameOptional("results")
+	if hasResultsArg {
+		results = int(resultsArg.IntValue())
+	}
+
+	source := ""
+	sourceArg, hasSourceArg := ctx.Options().GetByNameOptional("source")
+	if hasSourceArg {
+		source = sourceArg.StringValue()
+	}
+
+	return new(PlayCommand).play(ctx, query, source, true, results)
+}
+
+// spawnTrackPicker sends a select menu with the first few tracks, letting the user
+// who ran the command pick which one to play. The menu deletes itself when nobody
+// picks a track in time.
+func spawnTrackPicker(ctx ken.Context, musicService *music.MusicService, tracks []lavalink.Track, source string, results int) error {
+	if len(tracks) > results {
+		tracks = tracks[:results]
+	}
+
+	userID := ctx.User().ID
+	guildID, channelID := ctx.GetEvent().GuildID, ctx.GetEvent().ChannelID
+	customID := fmt.Sprintf("search_pick_%s", ctx.GetEvent().ID)
+
+	options := make([]discordgo.SelectMenuOption, len(tracks))
+	for i, track := range tracks {
+		options[i] = discordgo.SelectMenuOption{
+			Label: stringutils.Truncate(track.Info.Title, 90),
+			Description: stringutils.Truncate(fmt.Sprintf("%s • %s", track.Info.Author,
+				datetime.Pretty(datetime.ToDuration(track.Info.Length))), 90),
+			Value: strconv.Itoa(i),
+		}
+	}
+
+	var description string
+	for i, track := range tracks {
+		description += fmt.Sprintf("**%d.** **%s** by %s `%s`\n", i+1,
+			stringutils.Truncate(track.Info.Title, 30), track.Info.Author,
+			datetime.Pretty(datetime.ToDuration(track.Info.Length)))
+	}
+
+	embed := embedutils.CreateBasicEmbed(description)
+	embed.Title = "🔎  **Search Results**"
+
+	picked := make(chan struct{})
+	var pickOnce sync.Once
+
+	msg := ctx.FollowUpEmbed(embed).AddComponents(func(cb *ken.ComponentBuilder) {
+		cb.AddActionsRow(func(b ken.ComponentAssembler) {
+			b.Add(discordgo.SelectMenu{
+				CustomID:    customID,
+				Placeholder: "Pick a track to play",
+				Options:     options,
+			}, func(ctx ken.ComponentContext) bool {
+				// Everyone sees the menu, so tell anyone else it isn't theirs rather than failing the interaction.
+				if ctx.User().ID != userID {
+					ctx.SetEphemeral(true)
+					ctx.RespondMessage(fmt.Sprintf("Only <@%s> can pick from these results.", userID))
+					return false
+				}
+
+				if err := ctx.Defer(); err != nil {
+					return false
+				}
+
+				values := ctx.GetData().Values
+				if len(values) == 0 {
+					return false
+				}
+
+				i, err := strconv.Atoi(values[0])
+				if err != nil || i < 0 || i >= len(tracks) {
+					return false
+				}
+
+				pickOnce.Do(func() { close(picked) })
+
+				// The bot may have left since searching, ending the session it searched for.
+				session := ctx.GetSession()
+				voiceState, err := session.State.VoiceState(guildID, userID)
+				if err != nil {
+					ctx.FollowUpMessage("You need to be in a voice channel to pick a track.").Send()
+					return true
+				}
+
+				if err := session.ChannelVoiceJoinManual(guildID, voiceState.ChannelID, false, false); err != nil {
+					slog.Error("Failed to join voice channel.", slog.String("error", err.Error()))
+					ctx.FollowUpMessage("Failed to join your voice channel.").Send()
+					return true
+				}
+
+				musicSession := musicService.MusicSession(guildID, channelID)
+
+				track := tracks[i]
+				request := music.NewTrackRequestData(userID, nil, source)
+
+				opCtx, cancel := musicService.OperationContext()
+				defer cancel()
+
+				enqueued, err := musicSession.PlayOrEnqueue(opCtx, &track, request)
+				if rejected := music.RejectionMessage(musicService.MusicSettings(guildID), err); rejected != "" {
+					ctx.FollowUpEmbed(embedutils.CreateErrorEmbed(rejected)).Send()
+					return true
+				} else if err != nil {
+					slog.Error(fmt.Sprintf("Failed to play or enqueue track: %s", err.Error()))
+					ctx.FollowUpMessage("Failed to play or enqueue track.").Send()
+					return true
+				}
+
+				followUpPlayOrEnqueue(ctx, &track, request, enqueued)
+
+				return true
+			})
+		}, true)
+	}).Send()
+
+	if msg.Error != nil {
+		return msg.Error
+	}
+
+	go func() {
+		select {
+		case <-picked:
+		case <-time.After(searchPickerTimeout):
+			msg.UnregisterComponentHandlers()
+			msg.Delete()
+		}
+	}()
+
+	return nil
+}
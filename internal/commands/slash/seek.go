@@ -0,0 +1,171 @@
+package slash
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zekrotja/ken"
+	"unreal.sh/neo/internal/middlewares"
+	"unreal.sh/neo/internal/services/music"
+	"unreal.sh/neo/internal/utils/datetime"
+
+	embedutils "unreal.sh/neo/internal/utils/embedutils"
+)
+
+type SeekCommand struct{}
+
+var (
+	_ ken.Command            = (*SeekCommand)(nil)
+	_ ken.SlashCommand       = (*SeekCommand)(nil)
+	_ ken.GuildScopedCommand = (*SeekCommand)(nil)
+
+	_ middlewares.RequiresDJCommand = (*SeekCommand)(nil)
+)
+
+func (c *SeekCommand) Name() string {
+	return "seek"
+}
+
+func (c *SeekCommand) Description() string {
+	return "Jumps to a position in the current song."
+}
+
+func (c *SeekCommand) Version() string {
+	return "1.0.0"
+}
+
+func (c *SeekCommand) RequiresDJ() bool {
+	return true
+}
+
+func (c *SeekCommand) Type() discordgo.ApplicationCommandType {
+	return discordgo.ChatApplicationCommand
+}
+
+func (c *SeekCommand) Options() []*discordgo.ApplicationCommandOption {
+	return []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "timestamp",
+			Description: "The position to jump to, like 1:23 or 83s. Use +30s or -15s to move relative to now.",
+			Required:    true,
+		},
+	}
+}
+
+func (c *SeekCommand) Guild() string {
+	return os.Getenv("MISFITS_GUILD_ID")
+}
+
+func (c *SeekCommand) Run(ctx ken.Context) (err error) {
+	if err = ctx.Defer(); err != nil {
+		return nil
+	}
+
+	session := ctx.GetSession()
+
+	musicService := ctx.Get("MusicService").(*music.MusicService)
+	if musicService == nil {
+		slog.Error("Music service not found in context.")
+		return nil
+	}
+
+	guild, err := ctx.Guild()
+	if err != nil {
+		slog.Error("Failed to fetch guild.")
+		return err
+	}
+
+	var isBotInVoiceChannel bool
+	var botVoiceState *discordgo.VoiceState
+	for _, state := range guild.VoiceStates {
+		if state.UserID == session.State.User.ID {
+			isBotInVoiceChannel = true
+			botVoiceState = state
+		}
+	}
+
+	if !isBotInVoiceChannel {
+		ctx.RespondMessage("I'm not connected to a voice channel.")
+		return nil
+	}
+
+	var voiceChannelID *string
+	for _, state := range guild.VoiceStates {
+		if state.UserID == ctx.User().ID {
+			voiceChannelID = &state.ChannelID
+		}
+	}
+
+	if voiceChannelID == nil {
+		ctx.RespondMessage("You need to be in a voice channel to use this command.")
+		return nil
+	} else if *voiceChannelID != botVoiceState.ChannelID {
+		ctx.RespondMessage("You have to be in the same voice channel as me to use this command.")
+		return nil
+	}
+
+	musicSession := musicService.GetMusicSession(guild.ID)
+	if musicSession == nil {
+		ctx.RespondMessage("I'm not playing anything right now.")
+		return nil
+	}
+
+	opCtx, cancel := musicService.OperationContext()
+	defer cancel()
+
+	state, err := musicSession.State(opCtx)
+	if err != nil {
+		slog.Error("Failed to get music session state.", slog.String("error", err.Error()))
+		return err
+	}
+
+	if !state.IsPlaying() {
+		ctx.RespondMessage("I'm not playing anything right now.")
+		return nil
+	}
+
+	timestamp := ctx.Options().GetByName("timestamp").StringValue()
+
+	offset, relative, err := datetime.ParseTimestamp(timestamp)
+	if err != nil {
+		ctx.RespondEmbed(embedutils.CreateErrorEmbed(
+			fmt.Sprintf("`%s` isn't a valid timestamp. Try something like `1:23`, `83s` or `+30s`.", timestamp)))
+		return nil
+	}
+
+	length := state.CurrentTrack.Info.Length
+
+	// Jumping past either end of the track goes to that end instead.
+	position := datetime.FromDuration(offset)
+	if relative {
+		position = min(max(position+state.Position, 0), length)
+	}
+
+	err = musicSession.Seek(opCtx, position)
+	if errors.Is(err, music.ErrNotSeekable) {
+		ctx.RespondEmbed(embedutils.CreateErrorEmbed("This track is a stream, so it can't be seeked."))
+		return nil
+	} else if errors.Is(err, music.ErrSeekOutOfRange) {
+		ctx.RespondEmbed(embedutils.CreateErrorEmbed(fmt.Sprintf("That's outside of the track, which is `%s` long.",
+			datetime.Pretty(datetime.ToDuration(length)))))
+		return nil
+	} else if err != nil {
+		slog.Error("Failed to seek track.", slog.String("error", err.Error()))
+		return err
+	}
+
+	embed := embedutils.CreateBasicEmbed(fmt.Sprintf("⏩ **Jumped to `%s`.**",
+		datetime.Pretty(datetime.ToDuration(position))))
+
+	err = ctx.RespondEmbed(embed)
+	if err != nil {
+		slog.Error("Failed to respond to command.", slog.String("error", err.Error()))
+		return err
+	}
+
+	return nil
+}
@@ -1,12 +1,20 @@
 package slash
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log/slog"
 	"os"
 
 	"github.com/bwmarrin/discordgo"
+	"github.com/disgoorg/disgolink/v3/lavalink"
 	"github.com/zekrotja/ken"
 	"unreal.sh/neo/internal/services/music"
+
+	embedutils "unreal.sh/neo/internal/utils/embedutils"
+	sliceutils "unreal.sh/neo/internal/utils/sliceutils"
+	stringutils "unreal.sh/neo/internal/utils/stringutils"
 )
 
 type SkipCommand struct{}
@@ -22,7 +30,7 @@ func (c *SkipCommand) Name() string {
 }
 
 func (c *SkipCommand) Description() string {
-	return "Skips the current song."
+	return "Skips the current song, or votes to skip it."
 }
 
 func (c *SkipCommand) Version() string {
@@ -92,13 +100,159 @@ func (c *SkipCommand) Run(ctx ken.Context) (err error) {
 		return nil
 	}
 
-	err = musicSession.Skip()
+	opCtx, cancel := musicService.OperationContext()
+	defer cancel()
+
+	state, err := musicSession.State(opCtx)
+	if err != nil {
+		slog.Error("Failed to get music session state.", slog.String("error", err.Error()))
+		return err
+	}
+
+	if !state.IsPlaying() {
+		ctx.RespondMessage("I'm not playing anything right now.")
+		return nil
+	}
+
+	var data music.TrackRequestData
+	state.CurrentTrack.UserData.Unmarshal(&data)
+
+	// DJs and whoever requested the track don't need a vote.
+	if musicService.SkipsRightAway(guild.ID, ctx.GetEvent().Member, data.AuthorID) {
+		err = musicSession.Skip(opCtx)
+		if err != nil {
+			slog.Error("Failed to skip track.", slog.String("error", err.Error()))
+			return err
+		}
+
+		ctx.RespondMessage("Skipped the current track!")
+
+		return nil
+	}
+
+	return c.vote(ctx, opCtx, musicService, musicSession, state.CurrentTrack)
+}
+
+// vote counts the user's vote to skip, starting a vote with a button for the
+// other listeners if there isn't one for the current track yet.
+func (c *SkipCommand) vote(ctx ken.Context, opCtx context.Context, musicService *music.MusicService, musicSession *music.MusicSession, track *lavalink.Track) error {
+	guildID := ctx.GetEvent().GuildID
+	title := stringutils.Truncate(track.Info.Title, 30)
+
+	threshold := musicService.MusicSettings(guildID).SkipVoteThreshold
+	if threshold <= 0 {
+		threshold = music.DefaultSkipVoteThreshold
+	}
+
+	required := func() int {
+		return music.RequiredSkipVotes(len(musicService.Listeners(guildID)), threshold)
+	}
+
+	voteEmbed := func(votes int) *discordgo.MessageEmbed {
+		embed := embedutils.CreateBasicEmbed(fmt.Sprintf("Skip **%s**?\n`%d/%d` votes", title, votes, required()))
+		embed.Title = "🗳  **Vote to skip**"
+		return embed
+	}
+
+	votes, passed, err := musicSession.AddSkipVote(opCtx, ctx.User().ID, required())
+	if !errors.Is(err, music.ErrNoSkipVote) {
+		ctx.SetEphemeral(true)
+
+		if err != nil {
+			ctx.RespondMessage("I'm not playing anything right now.")
+			return nil
+		}
+
+		if passed {
+			ctx.RespondMessage("Your vote skipped the track!")
+		} else {
+			ctx.RespondMessage(fmt.Sprintf("Voted to skip. `%d/%d` votes.", votes, required()))
+		}
+
+		return nil
+	}
+
+	if err := ctx.Defer(); err != nil {
+		return err
+	}
+
+	// The message is only known after sending it, but the vote needs to be
+	// able to clean it up when it ends.
+	var msg *ken.FollowUpMessage
+	sent := make(chan struct{})
+
+	err = musicSession.StartSkipVote(opCtx, func(passed bool) {
+		<-sent
+		if msg == nil || msg.Error != nil {
+			return
+		}
+
+		msg.UnregisterComponentHandlers()
+
+		if passed {
+			msg.EditEmbed(embedutils.CreateBasicEmbed(fmt.Sprintf("⏭ **Skipped** %s, the vote passed.", title)))
+		} else {
+			msg.EditEmbed(embedutils.CreateBasicEmbed(fmt.Sprintf("The vote to skip **%s** ended, since the track changed.", title)))
+		}
+	})
+	if err != nil {
+		close(sent)
+		ctx.FollowUpMessage("I'm not playing anything right now.").Send()
+		return nil
+	}
+
+	votes, passed, err = musicSession.AddSkipVote(opCtx, ctx.User().ID, required())
 	if err != nil {
-		slog.Error("Failed to skip track.", slog.String("error", err.Error()))
+		close(sent)
 		return err
 	}
 
-	ctx.RespondMessage("Skipped the current track!")
+	if passed {
+		close(sent)
+		ctx.FollowUpMessage("Skipped the current track!").Send()
+		return nil
+	}
+
+	msg = ctx.FollowUpEmbed(voteEmbed(votes)).AddComponents(func(cb *ken.ComponentBuilder) {
+		cb.Add(discordgo.Button{
+			Label:    "Skip",
+			Emoji:    &discordgo.ComponentEmoji{Name: "⏭"},
+			Style:    discordgo.PrimaryButton,
+			CustomID: fmt.Sprintf("skip_vote_%s", ctx.GetEvent().ID),
+		}, func(cctx ken.ComponentContext) bool {
+			// Only listeners get a say. Tell anyone else why rather than failing the interaction.
+			if !sliceutils.Contains(musicService.Listeners(guildID), cctx.User().ID) {
+				cctx.SetEphemeral(true)
+				cctx.RespondMessage("You have to be in the same voice channel as me to vote.")
+				return false
+			}
+
+			cctx.Respond(&discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseDeferredMessageUpdate,
+			})
+
+			// The command's context is long gone by the time someone clicks.
+			opCtx, cancel := musicService.OperationContext()
+			defer cancel()
+
+			votes, passed, err := musicSession.AddSkipVote(opCtx, cctx.User().ID, required())
+			if err != nil {
+				return false
+			}
+
+			// A passed vote cleans up after itself.
+			if !passed {
+				msg.EditEmbed(voteEmbed(votes))
+			}
+
+			return true
+		})
+	}).Send()
+	close(sent)
+
+	if msg.Error != nil {
+		return msg.Error
+	}
 
 	return nil
 }
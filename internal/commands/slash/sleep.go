@@ -0,0 +1,225 @@
+package slash
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zekrotja/ken"
+
+	"unreal.sh/neo/internal/middlewares"
+	"unreal.sh/neo/internal/services/music"
+	"unreal.sh/neo/internal/utils/datetime"
+	embedutils "unreal.sh/neo/internal/utils/embedutils"
+)
+
+const maxSleepDuration = 24 * time.Hour
+
+type SleepCommand struct{}
+
+var (
+	_ ken.Command            = (*SleepCommand)(nil)
+	_ ken.SlashCommand       = (*SleepCommand)(nil)
+	_ ken.GuildScopedCommand = (*SleepCommand)(nil)
+
+	_ middlewares.RequiresDJCommand = (*SleepCommand)(nil)
+)
+
+func (c *SleepCommand) Name() string {
+	return "sleep"
+}
+
+func (c *SleepCommand) Description() string {
+	return "Stops the music and leaves on its own later."
+}
+
+func (c *SleepCommand) Version() string {
+	return "1.0.0"
+}
+
+func (c *SleepCommand) RequiresDJ() bool {
+	return true
+}
+
+func (c *SleepCommand) Type() discordgo.ApplicationCommandType {
+	return discordgo.ChatApplicationCommand
+}
+
+func (c *SleepCommand) Options() []*discordgo.ApplicationCommandOption {
+	return []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "after",
+			Description: "Stops after a while.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "duration",
+					Description: "How long until stopping, like \"30m\" or \"1h30m\". Plain numbers are minutes.",
+					Required:    true,
+				},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "end-of-track",
+			Description: "Stops once the current track ends.",
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "end-of-queue",
+			Description: "Stops once the queue runs out, instead of autoplaying.",
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "cancel",
+			Description: "Cancels the sleep timer.",
+		},
+	}
+}
+
+func (c *SleepCommand) Guild() string {
+	return os.Getenv("MISFITS_GUILD_ID")
+}
+
+func (c *SleepCommand) Run(ctx ken.Context) (err error) {
+	err = ctx.HandleSubCommands(
+		ken.SubCommandHandler{
+			Name: "after",
+			Run:  c.after,
+		},
+		ken.SubCommandHandler{
+			Name: "end-of-track",
+			Run: func(ctx ken.SubCommandContext) error {
+				return c.set(ctx, music.SleepAfterTrack, 0)
+			},
+		},
+		ken.SubCommandHandler{
+			Name: "end-of-queue",
+			Run: func(ctx ken.SubCommandContext) error {
+				return c.set(ctx, music.SleepAfterQueue, 0)
+			},
+		},
+		ken.SubCommandHandler{
+			Name: "cancel",
+			Run:  c.cancel,
+		},
+	)
+
+	return err
+}
+
+func (c *SleepCommand) after(ctx ken.SubCommandContext) error {
+	d, err := datetime.ParseDuration(ctx.Options().GetByName("duration").StringValue())
+	if err != nil || d <= 0 {
+		ctx.SetEphemeral(true)
+		ctx.RespondMessage("That's not a valid duration. Try something like `30m` or `1h30m`.")
+		return nil
+	}
+
+	if d > maxSleepDuration {
+		ctx.SetEphemeral(true)
+		ctx.RespondMessage("The sleep timer can be set up to 24 hours ahead.")
+		return nil
+	}
+
+	return c.set(ctx, music.SleepAfterDuration, d)
+}
+
+func (c *SleepCommand) set(ctx ken.SubCommandContext, mode music.SleepMode, d time.Duration) error {
+	musicService, musicSession, err := c.musicSession(ctx)
+	if musicSession == nil {
+		return err
+	}
+
+	opCtx, cancel := musicService.OperationContext()
+	defer cancel()
+
+	timer, err := musicSession.SetSleepTimer(opCtx, mode, d)
+	if err != nil {
+		slog.Error("Failed to set sleep timer.", slog.String("error", err.Error()))
+		return err
+	}
+
+	return ctx.RespondEmbed(embedutils.CreateBasicEmbed(fmt.Sprintf("💤 I'll stop and leave %s.", timer)))
+}
+
+func (c *SleepCommand) cancel(ctx ken.SubCommandContext) error {
+	musicService, musicSession, err := c.musicSession(ctx)
+	if musicSession == nil {
+		return err
+	}
+
+	opCtx, cancel := musicService.OperationContext()
+	defer cancel()
+
+	err = musicSession.CancelSleepTimer(opCtx)
+	if err == music.ErrNoSleepTimer {
+		ctx.SetEphemeral(true)
+		ctx.RespondMessage("There's no sleep timer set.")
+		return nil
+	} else if err != nil {
+		slog.Error("Failed to cancel sleep timer.", slog.String("error", err.Error()))
+		return err
+	}
+
+	return ctx.RespondEmbed(embedutils.CreateBasicEmbed("⏰ Cancelled the sleep timer."))
+}
+
+// musicSession returns the session the user is listening to, or nil if they aren't
+// listening along, in which case they were told so already.
+func (c *SleepCommand) musicSession(ctx ken.SubCommandContext) (*music.MusicService, *music.MusicSession, error) {
+	session := ctx.GetSession()
+
+	musicService := ctx.Get("MusicService").(*music.MusicService)
+	if musicService == nil {
+		slog.Error("Music service not found in context.")
+		return nil, nil, nil
+	}
+
+	guild, err := ctx.Guild()
+	if err != nil {
+		slog.Error("Failed to fetch guild.")
+		return nil, nil, err
+	}
+
+	var isBotInVoiceChannel bool
+	var botVoiceState *discordgo.VoiceState
+	for _, state := range guild.VoiceStates {
+		if state.UserID == session.State.User.ID {
+			isBotInVoiceChannel = true
+			botVoiceState = state
+		}
+	}
+
+	if !isBotInVoiceChannel {
+		ctx.RespondMessage("I'm not connected to a voice channel.")
+		return nil, nil, nil
+	}
+
+	var voiceChannelID *string
+	for _, state := range guild.VoiceStates {
+		if state.UserID == ctx.User().ID {
+			voiceChannelID = &state.ChannelID
+		}
+	}
+
+	if voiceChannelID == nil {
+		ctx.RespondMessage("You need to be in a voice channel to use this command.")
+		return nil, nil, nil
+	} else if *voiceChannelID != botVoiceState.ChannelID {
+		ctx.RespondMessage("You have to be in the same voice channel as me to use this command.")
+		return nil, nil, nil
+	}
+
+	musicSession := musicService.GetMusicSession(guild.ID)
+	if musicSession == nil {
+		slog.Error("Music session not found. Something's really wrong here.")
+		ctx.RespondMessage("I'm not playing anything right now.")
+		return nil, nil, nil
+	}
+
+	return musicService, musicSession, nil
+}
@@ -1,11 +1,13 @@
 package slash
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/zekrotja/ken"
+	"unreal.sh/neo/internal/database"
 	"unreal.sh/neo/internal/middlewares"
 	embedutils "unreal.sh/neo/internal/utils/embedutils"
 )
@@ -66,6 +68,11 @@ func (c *SoftbanCommand) Run(ctx ken.Context) (err error) {
 		return
 	}
 
+	db := ctx.Get("Database").(*database.Database)
+	if db == nil {
+		return errors.New("failed to get Database")
+	}
+
 	session := ctx.GetSession()
 	ctx.SetEphemeral(true)
 
@@ -128,6 +135,14 @@ func (c *SoftbanCommand) Run(ctx ken.Context) (err error) {
 					fmt.Sprintf("Softbanned %s for `%s`", user.String(), reason),
 				)
 
+				embed.Footer = recordInfraction(db, database.Infraction{
+					GuildID:     guild.ID,
+					Type:        database.InfractionSoftban,
+					UserID:      user.ID,
+					ModeratorID: ctx.User().ID,
+					Reason:      reason,
+				})
+
 				ctx.FollowUpEmbed(embed).Send()
 
 				return true
@@ -0,0 +1,306 @@
+package slash
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zekrotja/ken"
+
+	"unreal.sh/neo/internal/services/music"
+	"unreal.sh/neo/pkg/widgets"
+
+	embedutils "unreal.sh/neo/internal/utils/embedutils"
+	sliceutils "unreal.sh/neo/internal/utils/sliceutils"
+	stringutils "unreal.sh/neo/internal/utils/stringutils"
+)
+
+// statsPeriods are how far back statistics can go, by the period option's value.
+var statsPeriods = map[string]time.Duration{
+	"day":   24 * time.Hour,
+	"week":  7 * 24 * time.Hour,
+	"month": 30 * 24 * time.Hour,
+	"year":  365 * 24 * time.Hour,
+	"all":   0,
+}
+
+const statsPageSize = 10
+
+type StatsCommand struct{}
+
+var (
+	_ ken.Command            = (*StatsCommand)(nil)
+	_ ken.SlashCommand       = (*StatsCommand)(nil)
+	_ ken.GuildScopedCommand = (*StatsCommand)(nil)
+)
+
+func (c *StatsCommand) Name() string {
+	return "stats"
+}
+
+func (c *StatsCommand) Description() string {
+	return "Shows statistics about the server and the bot."
+}
+
+func (c *StatsCommand) Version() string {
+	return "1.0.0"
+}
+
+func (c *StatsCommand) Type() discordgo.ApplicationCommandType {
+	return discordgo.ChatApplicationCommand
+}
+
+func (c *StatsCommand) Options() []*discordgo.ApplicationCommandOption {
+	return []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "music",
+			Description: "Shows what was listened to the most.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "period",
+					Description: "How far back to look. Defaults to the last month.",
+					Required:    false,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "Last day", Value: "day"},
+						{Name: "Last week", Value: "week"},
+						{Name: "Last month", Value: "month"},
+						{Name: "Last year", Value: "year"},
+						{Name: "All time", Value: "all"},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionUser,
+					Name:        "user",
+					Description: "Only count the tracks this user requested.",
+					Required:    false,
+				},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "bot",
+			Description: "Shows how the bot itself is doing.",
+		},
+	}
+}
+
+func (c *StatsCommand) Guild() string {
+	return os.Getenv("MISFITS_GUILD_ID")
+}
+
+func (c *StatsCommand) Run(ctx ken.Context) (err error) {
+	err = ctx.HandleSubCommands(
+		ken.SubCommandHandler{
+			Name: "music",
+			Run:  c.music,
+		},
+		ken.SubCommandHandler{
+			Name: "bot",
+			Run:  c.bot,
+		},
+	)
+
+	return err
+}
+
+func (c *StatsCommand) music(ctx ken.SubCommandContext) error {
+	if err := ctx.Defer(); err != nil {
+		return err
+	}
+
+	musicService := ctx.Get("MusicService").(*music.MusicService)
+	if musicService == nil {
+		return errors.New("failed to get MusicService")
+	}
+
+	period := "month"
+	if periodArg, hasPeriodArg := ctx.Options().GetByNameOptional("period"); hasPeriodArg {
+		period = periodArg.StringValue()
+	}
+
+	var since time.Time
+	if d := statsPeriods[period]; d > 0 {
+		since = time.Now().Add(-d)
+	}
+
+	var user *discordgo.User
+	if userArg, hasUserArg := ctx.Options().GetByNameOptional("user"); hasUserArg {
+		user = userArg.UserValue(ctx)
+	}
+
+	userID := ""
+	if user != nil {
+		userID = user.ID
+	}
+
+	stats, err := musicService.Stats(ctx.GetEvent().GuildID, userID, since)
+	if err != nil {
+		slog.Error("Failed to get music statistics.", slog.String("error", err.Error()))
+		ctx.FollowUpEmbed(embedutils.CreateErrorEmbed("Failed to get the music statistics.")).Send()
+		return nil
+	}
+
+	title := "📊  **Music stats**"
+	if user != nil {
+		title = fmt.Sprintf("📊  **Music stats for %s**", user.Username)
+	}
+
+	pages := []*discordgo.MessageEmbed{c.overview(stats, period)}
+
+	pages = append(pages, c.ranking("Top tracks", stats.TopTracks, func(count music.StatsCount) string {
+		return fmt.Sprintf("**%s** by %s", count.TrackLink(),
+			stringutils.Truncate(stringutils.EnsureNotEmpty(count.Author, "Unknown"), 20))
+	})...)
+
+	pages = append(pages, c.ranking("Top artists", stats.TopArtists, func(count music.StatsCount) string {
+		return fmt.Sprintf("**%s**", stringutils.Truncate(count.Name, 30))
+	})...)
+
+	// Everything counted was requested by the user already.
+	if user == nil {
+		pages = append(pages, c.ranking("Top requesters", stats.TopRequesters, func(count music.StatsCount) string {
+			return fmt.Sprintf("<@%s>", count.Name)
+		})...)
+	}
+
+	for i, page := range pages {
+		page.Title = fmt.Sprintf("%s (Page %d/%d)", title, i+1, len(pages))
+	}
+
+	kctx := ken.Context(ctx)
+	paginator := widgets.NewPaginator(&kctx)
+	paginator.Add(pages...)
+
+	err = paginator.Spawn()
+	if err != nil {
+		slog.Error("Failed to spawn paginator.")
+		slog.Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (c *StatsCommand) bot(ctx ken.SubCommandContext) error {
+	musicService := ctx.Get("MusicService").(*music.MusicService)
+	if musicService == nil {
+		return errors.New("failed to get MusicService")
+	}
+
+	tracks := musicService.TrackCacheStats()
+
+	library := "Not set up"
+	if musicService.Library() != nil {
+		library = pluralTracks(musicService.Library().Len())
+	}
+
+	embed := embedutils.CreateBasicEmbed("📊  **Bot stats**")
+	embed.Fields = []*discordgo.MessageEmbedField{
+		{
+			Name:   "Servers",
+			Value:  fmt.Sprint(len(ctx.GetSession().State.Guilds)),
+			Inline: true,
+		},
+		{
+			Name:   "Music sessions",
+			Value:  fmt.Sprint(musicService.SessionCount()),
+			Inline: true,
+		},
+		{
+			Name:   "Music library",
+			Value:  library,
+			Inline: true,
+		},
+		{
+			Name:   "Track cache",
+			Value:  fmt.Sprintf("%d cached, %.0f%% hit rate", tracks.Entries, tracks.HitRate()*100),
+			Inline: true,
+		},
+		{
+			Name:   "Track cache hits",
+			Value:  fmt.Sprint(tracks.Hits),
+			Inline: true,
+		},
+		{
+			Name:   "Track cache misses",
+			Value:  fmt.Sprint(tracks.Misses),
+			Inline: true,
+		},
+	}
+
+	return ctx.RespondEmbed(embed)
+}
+
+func (c *StatsCommand) overview(stats music.MusicStats, period string) *discordgo.MessageEmbed {
+	description := "Since " + fmt.Sprintf("<t:%d:D>", stats.Since.Unix())
+	if period == "all" {
+		description = "Of all time"
+	}
+
+	embed := embedutils.CreateBasicEmbed(description)
+	embed.Fields = []*discordgo.MessageEmbedField{
+		{
+			Name:   "Listening time",
+			Value:  formatListeningTime(stats.ListeningTime),
+			Inline: true,
+		},
+		{
+			Name:   "Played",
+			Value:  fmt.Sprint(stats.Plays),
+			Inline: true,
+		},
+		{
+			Name:   "Skipped",
+			Value:  fmt.Sprint(stats.Skips),
+			Inline: true,
+		},
+		{
+			Name:   "Failed",
+			Value:  fmt.Sprint(stats.Failures),
+			Inline: true,
+		},
+	}
+
+	return embed
+}
+
+// ranking lists counts on as many pages as they need, or a single page saying there's nothing to list.
+func (c *StatsCommand) ranking(name string, counts []music.StatsCount, describe func(music.StatsCount) string) []*discordgo.MessageEmbed {
+	if len(counts) == 0 {
+		return []*discordgo.MessageEmbed{embedutils.CreateBasicEmbed(fmt.Sprintf("**%s**\nNothing was played in this period.", name))}
+	}
+
+	list := make([]string, len(counts))
+	for i, count := range counts {
+		plays := "1 play"
+		if count.Plays != 1 {
+			plays = fmt.Sprintf("%d plays", count.Plays)
+		}
+
+		list[i] = fmt.Sprintf("**%d.** %s — %s, %s", i+1, describe(count), plays, formatListeningTime(count.ListeningTime))
+	}
+
+	return sliceutils.Map(sliceutils.Chunk(list, statsPageSize), func(chunk []string) *discordgo.MessageEmbed {
+		return embedutils.CreateBasicEmbed(fmt.Sprintf("**%s**\n%s", name, strings.Join(chunk, "\n")))
+	})
+}
+
+// formatListeningTime formats a listening time in hours and minutes, since days of it are easier to compare as hours.
+func formatListeningTime(d time.Duration) string {
+	if d < time.Minute {
+		return "less than a minute"
+	}
+
+	hours, minutes := int(d.Hours()), int(d.Minutes())%60
+	if hours == 0 {
+		return fmt.Sprintf("%dm", minutes)
+	}
+
+	return fmt.Sprintf("%dh %dm", hours, minutes)
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/zekrotja/ken"
+	"unreal.sh/neo/internal/middlewares"
 	"unreal.sh/neo/internal/services/music"
 	"unreal.sh/neo/internal/utils/static"
 )
@@ -16,6 +17,8 @@ var (
 	_ ken.Command            = (*StopCommand)(nil)
 	_ ken.SlashCommand       = (*StopCommand)(nil)
 	_ ken.GuildScopedCommand = (*StopCommand)(nil)
+
+	_ middlewares.RequiresDJCommand = (*StopCommand)(nil)
 )
 
 func (c *StopCommand) Name() string {
@@ -30,6 +33,10 @@ func (c *StopCommand) Version() string {
 	return "1.0.0"
 }
 
+func (c *StopCommand) RequiresDJ() bool {
+	return true
+}
+
 func (c *StopCommand) Type() discordgo.ApplicationCommandType {
 	return discordgo.ChatApplicationCommand
 }
@@ -97,7 +104,10 @@ func (c *StopCommand) Run(ctx ken.Context) (err error) {
 		return nil
 	}
 
-	err = musicSession.Stop()
+	opCtx, cancel := musicService.OperationContext()
+	defer cancel()
+
+	err = musicSession.Stop(opCtx)
 	if err != nil {
 		slog.Error("Failed to stop track.", slog.String("error", err.Error()))
 		return err
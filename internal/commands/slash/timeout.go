@@ -0,0 +1,133 @@
+package slash
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zekrotja/ken"
+
+	"unreal.sh/neo/internal/database"
+	"unreal.sh/neo/internal/middlewares"
+	"unreal.sh/neo/internal/utils/datetime"
+
+	embedutils "unreal.sh/neo/internal/utils/embedutils"
+)
+
+// maxTimeoutDuration is the longest Discord allows members to be timed out for.
+const maxTimeoutDuration = 28 * 24 * time.Hour
+
+type TimeoutCommand struct{}
+
+var (
+	_ ken.Command            = (*TimeoutCommand)(nil)
+	_ ken.SlashCommand       = (*TimeoutCommand)(nil)
+	_ ken.GuildScopedCommand = (*TimeoutCommand)(nil)
+
+	_ middlewares.RequiresPermissionCommand = (*TimeoutCommand)(nil)
+)
+
+func (c *TimeoutCommand) Name() string {
+	return "timeout"
+}
+
+func (c *TimeoutCommand) Description() string {
+	return "Times out a member, so they can't talk for a while."
+}
+
+func (c *TimeoutCommand) Version() string {
+	return "1.0.0"
+}
+
+func (c *TimeoutCommand) RequiresPermission() int64 {
+	return discordgo.PermissionModerateMembers
+}
+
+func (c *TimeoutCommand) Type() discordgo.ApplicationCommandType {
+	return discordgo.ChatApplicationCommand
+}
+
+func (c *TimeoutCommand) Options() []*discordgo.ApplicationCommandOption {
+	return []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionUser,
+			Name:        "user",
+			Description: "The victim.",
+			Required:    true,
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "duration",
+			Description: "How long to time them out for, like \"30m\" or \"1h30m\". Plain numbers are minutes.",
+			Required:    true,
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "reason",
+			Description: "The reason for the timeout.",
+			Required:    false,
+		},
+	}
+}
+
+func (c *TimeoutCommand) Guild() string {
+	return os.Getenv("MISFITS_GUILD_ID")
+}
+
+func (c *TimeoutCommand) Run(ctx ken.Context) (err error) {
+	d, err := datetime.ParseDuration(ctx.Options().GetByName("duration").StringValue())
+	if err != nil || d <= 0 {
+		ctx.SetEphemeral(true)
+		ctx.RespondMessage("That's not a valid duration. Try something like `30m` or `1h30m`.")
+		return nil
+	}
+
+	if d > maxTimeoutDuration {
+		ctx.SetEphemeral(true)
+		ctx.RespondMessage("Members can be timed out for up to 28 days.")
+		return nil
+	}
+
+	if err = ctx.Defer(); err != nil {
+		return
+	}
+
+	db := ctx.Get("Database").(*database.Database)
+	if db == nil {
+		return errors.New("failed to get Database")
+	}
+
+	guildID := ctx.GetEvent().GuildID
+	user := ctx.Options().GetByName("user").UserValue(ctx)
+
+	reason := "No reason provided."
+	reasonArg, hasReasonArg := ctx.Options().GetByNameOptional("reason")
+	if hasReasonArg {
+		reason = reasonArg.StringValue()
+	}
+
+	until := time.Now().Add(d)
+
+	err = ctx.GetSession().GuildMemberTimeout(guildID, user.ID, &until, discordgo.WithAuditLogReason(reason))
+	if err != nil {
+		ctx.FollowUpEmbed(embedutils.CreateErrorEmbed("Failed to time out user.")).Send()
+		return nil
+	}
+
+	embed := embedutils.CreateSuccessEmbed(
+		fmt.Sprintf("Timed out %s for %s for `%s`", user.String(), datetime.Pretty(d), reason),
+	)
+
+	embed.Footer = recordInfraction(db, database.Infraction{
+		GuildID:     guildID,
+		Type:        database.InfractionTimeout,
+		UserID:      user.ID,
+		ModeratorID: ctx.User().ID,
+		Reason:      reason,
+		Duration:    d.Milliseconds(),
+	})
+
+	return ctx.FollowUpEmbed(embed).Send().Error
+}
@@ -0,0 +1,103 @@
+package slash
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zekrotja/ken"
+
+	"unreal.sh/neo/internal/database"
+	"unreal.sh/neo/internal/middlewares"
+
+	embedutils "unreal.sh/neo/internal/utils/embedutils"
+)
+
+type UnbanCommand struct{}
+
+var (
+	_ ken.Command            = (*UnbanCommand)(nil)
+	_ ken.SlashCommand       = (*UnbanCommand)(nil)
+	_ ken.GuildScopedCommand = (*UnbanCommand)(nil)
+
+	_ middlewares.RequiresPermissionCommand = (*UnbanCommand)(nil)
+)
+
+func (c *UnbanCommand) Name() string {
+	return "unban"
+}
+
+func (c *UnbanCommand) Description() string {
+	return "Unbans a user from the server."
+}
+
+func (c *UnbanCommand) Version() string {
+	return "1.0.0"
+}
+
+func (c *UnbanCommand) RequiresPermission() int64 {
+	return discordgo.PermissionBanMembers
+}
+
+func (c *UnbanCommand) Type() discordgo.ApplicationCommandType {
+	return discordgo.ChatApplicationCommand
+}
+
+func (c *UnbanCommand) Options() []*discordgo.ApplicationCommandOption {
+	return []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionUser,
+			Name:        "user",
+			Description: "The user to unban, or their ID.",
+			Required:    true,
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "reason",
+			Description: "The reason for the unban.",
+			Required:    false,
+		},
+	}
+}
+
+func (c *UnbanCommand) Guild() string {
+	return os.Getenv("MISFITS_GUILD_ID")
+}
+
+func (c *UnbanCommand) Run(ctx ken.Context) (err error) {
+	if err = ctx.Defer(); err != nil {
+		return
+	}
+
+	db := ctx.Get("Database").(*database.Database)
+	if db == nil {
+		return errors.New("failed to get Database")
+	}
+
+	guildID := ctx.GetEvent().GuildID
+	user := ctx.Options().GetByName("user").UserValue(ctx)
+
+	reason := "No reason provided."
+	reasonArg, hasReasonArg := ctx.Options().GetByNameOptional("reason")
+	if hasReasonArg {
+		reason = reasonArg.StringValue()
+	}
+
+	err = ctx.GetSession().GuildBanDelete(guildID, user.ID, discordgo.WithAuditLogReason(reason))
+	if err != nil {
+		ctx.FollowUpEmbed(embedutils.CreateErrorEmbed("Failed to unban user. Are they banned?")).Send()
+		return nil
+	}
+
+	embed := embedutils.CreateSuccessEmbed(fmt.Sprintf("Unbanned %s for `%s`", user.String(), reason))
+	embed.Footer = recordInfraction(db, database.Infraction{
+		GuildID:     guildID,
+		Type:        database.InfractionUnban,
+		UserID:      user.ID,
+		ModeratorID: ctx.User().ID,
+		Reason:      reason,
+	})
+
+	return ctx.FollowUpEmbed(embed).Send().Error
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/zekrotja/ken"
+	"unreal.sh/neo/internal/middlewares"
 	"unreal.sh/neo/internal/services/music"
 
 	embedutils "unreal.sh/neo/internal/utils/embedutils"
@@ -18,6 +19,8 @@ var (
 	_ ken.Command            = (*VolumeCommand)(nil)
 	_ ken.SlashCommand       = (*VolumeCommand)(nil)
 	_ ken.GuildScopedCommand = (*VolumeCommand)(nil)
+
+	_ middlewares.RequiresDJCommand = (*VolumeCommand)(nil)
 )
 
 func (c *VolumeCommand) Name() string {
@@ -32,6 +35,10 @@ func (c *VolumeCommand) Version() string {
 	return "1.0.0"
 }
 
+func (c *VolumeCommand) RequiresDJ() bool {
+	return true
+}
+
 func (c *VolumeCommand) Type() discordgo.ApplicationCommandType {
 	return discordgo.ChatApplicationCommand
 }
@@ -107,7 +114,14 @@ func (c *VolumeCommand) Run(ctx ken.Context) (err error) {
 	}
 
 	volume := ctx.Options().GetByName("volume").IntValue()
-	musicSession.Volume(int(volume))
+	opCtx, cancel := musicService.OperationContext()
+	defer cancel()
+
+	err = musicSession.Volume(opCtx, int(volume))
+	if err != nil {
+		slog.Error("Failed to set volume.", slog.String("error", err.Error()))
+		return err
+	}
 
 	embed := embedutils.CreateBasicEmbed("🔊 **Set volume to `" + fmt.Sprint(volume) + "%`.**")
 	err = ctx.RespondEmbed(embed)
@@ -0,0 +1,111 @@
+package slash
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zekrotja/ken"
+
+	"unreal.sh/neo/internal/database"
+	"unreal.sh/neo/internal/middlewares"
+
+	embedutils "unreal.sh/neo/internal/utils/embedutils"
+)
+
+type WarnCommand struct{}
+
+var (
+	_ ken.Command            = (*WarnCommand)(nil)
+	_ ken.SlashCommand       = (*WarnCommand)(nil)
+	_ ken.GuildScopedCommand = (*WarnCommand)(nil)
+
+	_ middlewares.RequiresPermissionCommand = (*WarnCommand)(nil)
+)
+
+func (c *WarnCommand) Name() string {
+	return "warn"
+}
+
+func (c *WarnCommand) Description() string {
+	return "Warns a member."
+}
+
+func (c *WarnCommand) Version() string {
+	return "1.0.0"
+}
+
+func (c *WarnCommand) RequiresPermission() int64 {
+	return discordgo.PermissionModerateMembers
+}
+
+func (c *WarnCommand) Type() discordgo.ApplicationCommandType {
+	return discordgo.ChatApplicationCommand
+}
+
+func (c *WarnCommand) Options() []*discordgo.ApplicationCommandOption {
+	return []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionUser,
+			Name:        "user",
+			Description: "The victim.",
+			Required:    true,
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "reason",
+			Description: "The reason for the warning.",
+			Required:    true,
+		},
+	}
+}
+
+func (c *WarnCommand) Guild() string {
+	return os.Getenv("MISFITS_GUILD_ID")
+}
+
+func (c *WarnCommand) Run(ctx ken.Context) (err error) {
+	if err = ctx.Defer(); err != nil {
+		return
+	}
+
+	db := ctx.Get("Database").(*database.Database)
+	if db == nil {
+		return errors.New("failed to get Database")
+	}
+
+	session := ctx.GetSession()
+
+	guild, err := ctx.Guild()
+	if err != nil {
+		return err
+	}
+
+	user := ctx.Options().GetByName("user").UserValue(ctx)
+	reason := ctx.Options().GetByName("reason").StringValue()
+
+	embed := embedutils.CreateSuccessEmbed(fmt.Sprintf("Warned %s for `%s`", user.String(), reason))
+
+	// They're told about it if they accept direct messages.
+	warning := embedutils.CreateBasicEmbed(fmt.Sprintf("You were warned in **%s** for `%s`", guild.Name, reason))
+
+	channel, err := session.UserChannelCreate(user.ID)
+	if err == nil {
+		_, err = session.ChannelMessageSendEmbed(channel.ID, warning)
+	}
+
+	if err != nil {
+		embed.Description += "\nI couldn't tell them about it, though."
+	}
+
+	embed.Footer = recordInfraction(db, database.Infraction{
+		GuildID:     guild.ID,
+		Type:        database.InfractionWarn,
+		UserID:      user.ID,
+		ModeratorID: ctx.User().ID,
+		Reason:      reason,
+	})
+
+	return ctx.FollowUpEmbed(embed).Send().Error
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 
 	"github.com/bwmarrin/discordgo"
+	"github.com/disgoorg/disgolink/v3/lavalink"
 	"github.com/supabase-community/supabase-go"
 )
 
@@ -175,3 +176,36 @@ func (d *Database) DisableModule(guildID string, moduleID string) (GuildSettings
 
 	return settings, nil
 }
+
+// SetMusicFilters persists the audio filters of a guild's music sessions.
+// A nil filters value clears them.
+func (d *Database) SetMusicFilters(guildID string, filters *lavalink.Filters) (GuildSettings, error) {
+	settings, _, err := d.GetOrCreateGuildSettings(guildID)
+	if err != nil {
+		return settings, err
+	}
+
+	settings.MusicFilters = filters
+	settings, err = d.UpdateGuildSettings(settings)
+	if err != nil {
+		return settings, err
+	}
+
+	return settings, nil
+}
+
+// SetMusicSettings persists the music configuration of a guild.
+func (d *Database) SetMusicSettings(guildID string, musicSettings MusicSettings) (GuildSettings, error) {
+	settings, _, err := d.GetOrCreateGuildSettings(guildID)
+	if err != nil {
+		return settings, err
+	}
+
+	settings.MusicSettings = musicSettings
+	settings, err = d.UpdateGuildSettings(settings)
+	if err != nil {
+		return settings, err
+	}
+
+	return settings, nil
+}
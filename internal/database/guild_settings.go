@@ -1,10 +1,16 @@
 package database
 
-import "encoding/json"
+import (
+	"encoding/json"
+
+	"github.com/disgoorg/disgolink/v3/lavalink"
+)
 
 type GuildSettings struct {
-	GuildID        string   `json:"guild_id"`
-	EnabledModules []string `json:"enabled_modules"`
+	GuildID        string            `json:"guild_id"`
+	EnabledModules []string          `json:"enabled_modules"`
+	MusicFilters   *lavalink.Filters `json:"music_filters"`
+	MusicSettings  MusicSettings     `json:"music_settings"`
 }
 
 func (g *GuildSettings) String() string {
@@ -0,0 +1,154 @@
+package database
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/supabase-community/postgrest-go"
+)
+
+const (
+	InfractionsTable = "infractions"
+)
+
+// InfractionType is the kind of moderation action an infraction records.
+type InfractionType string
+
+const (
+	InfractionBan     InfractionType = "ban"
+	InfractionUnban   InfractionType = "unban"
+	InfractionKick    InfractionType = "kick"
+	InfractionSoftban InfractionType = "softban"
+	InfractionTimeout InfractionType = "timeout"
+	InfractionWarn    InfractionType = "warn"
+	InfractionNote    InfractionType = "note"
+)
+
+// maxCaseAttempts is how often creating an infraction is tried when other infractions
+// of the same guild keep taking the next case number first.
+const maxCaseAttempts = 5
+
+// Infraction is a moderation action taken against a user, numbered per guild as a case.
+// Case numbers are unique per guild, which the table enforces with a unique constraint on (guild_id, case_id).
+type Infraction struct {
+	ID          int64          `json:"id,omitempty"`
+	GuildID     string         `json:"guild_id"`
+	CaseID      int64          `json:"case_id"`
+	Type        InfractionType `json:"type"`
+	UserID      string         `json:"user_id"`
+	ModeratorID string         `json:"moderator_id"`
+	Reason      string         `json:"reason"`
+	Duration    int64          `json:"duration,omitempty"` // In milliseconds, for timeouts.
+	CreatedAt   time.Time      `json:"created_at"`
+	EditedBy    string         `json:"edited_by,omitempty"` // Who last changed the reason, if anyone.
+	EditedAt    *time.Time     `json:"edited_at,omitempty"`
+}
+
+// CreateInfraction stores an infraction as the guild's next case.
+func (d *Database) CreateInfraction(infraction Infraction) (Infraction, error) {
+	res := make([]Infraction, 1)
+
+	infraction.CreatedAt = time.Now()
+
+	for attempt := 0; attempt < maxCaseAttempts; attempt++ {
+		caseID, err := d.nextCaseID(infraction.GuildID)
+		if err != nil {
+			return infraction, err
+		}
+
+		infraction.CaseID = caseID
+
+		q := d.client.From(InfractionsTable).Insert(infraction, false, "", "", "exact")
+		count, err := q.ExecuteTo(&res)
+		if isUniqueViolation(err) {
+			// Another infraction got the case number in the meantime.
+			continue
+		}
+
+		if err != nil {
+			return infraction, err
+		}
+
+		if count == 0 {
+			return infraction, errors.New("failed to insert infraction")
+		}
+
+		return res[0], nil
+	}
+
+	return infraction, errors.New("failed to assign a case number")
+}
+
+// nextCaseID returns the case number following the guild's latest case.
+func (d *Database) nextCaseID(guildID string) (int64, error) {
+	res := make([]Infraction, 0)
+
+	f := d.client.From(InfractionsTable).Select("case_id", "exact", false).Eq("guild_id", guildID).
+		Order("case_id", &postgrest.OrderOpts{Ascending: false}).Limit(1, "")
+	_, err := f.ExecuteTo(&res)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(res) == 0 {
+		return 1, nil
+	}
+
+	return res[0].CaseID + 1, nil
+}
+
+// isUniqueViolation returns whether an error is PostgreSQL's unique_violation, which PostgREST reports with its code.
+func isUniqueViolation(err error) bool {
+	return err != nil && strings.HasPrefix(err.Error(), "(23505)")
+}
+
+// GetInfraction returns one of a guild's cases.
+func (d *Database) GetInfraction(guildID string, caseID int64) (infraction Infraction, exists bool, err error) {
+	res := make([]Infraction, 1)
+
+	f := d.client.From(InfractionsTable).Select("*", "exact", false).Eq("guild_id", guildID).
+		Eq("case_id", strconv.FormatInt(caseID, 10)).Limit(1, "")
+	count, err := f.ExecuteTo(&res)
+	if err != nil {
+		return infraction, false, err
+	}
+
+	if count == 0 {
+		return infraction, false, nil
+	}
+
+	return res[0], true, nil
+}
+
+// GetInfractions returns a user's infractions in a guild, newest first.
+func (d *Database) GetInfractions(guildID string, userID string) ([]Infraction, error) {
+	res := make([]Infraction, 0)
+
+	f := d.client.From(InfractionsTable).Select("*", "exact", false).Eq("guild_id", guildID).Eq("user_id", userID).
+		Order("case_id", &postgrest.OrderOpts{Ascending: false})
+	_, err := f.ExecuteTo(&res)
+	if err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+// UpdateInfraction stores the changes made to an infraction.
+func (d *Database) UpdateInfraction(infraction Infraction) (Infraction, error) {
+	res := make([]Infraction, 1)
+
+	q := d.client.From(InfractionsTable).Update(infraction, "", "exact").Eq("id", strconv.FormatInt(infraction.ID, 10))
+	count, err := q.ExecuteTo(&res)
+	if err != nil {
+		return infraction, err
+	}
+
+	if count == 0 {
+		return infraction, errors.New("failed to update infraction")
+	}
+
+	return res[0], nil
+}
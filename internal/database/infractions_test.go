@@ -0,0 +1,25 @@
+package database
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestIsUniqueViolation(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected bool
+	}{
+		{errors.New(`(23505) duplicate key value violates unique constraint "infractions_guild_id_case_id_key"`), true},
+		{errors.New("(23503) insert or update on table \"infractions\" violates foreign key constraint"), false},
+		{errors.New("connection refused"), false},
+		{errors.New("failed (23505)"), false},
+		{nil, false},
+	}
+
+	for _, test := range tests {
+		if violation := isUniqueViolation(test.err); violation != test.expected {
+			t.Errorf("isUniqueViolation(%v) = %t, expected %t", test.err, violation, test.expected)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package database
+
+import (
+	"errors"
+	"time"
+
+	"github.com/supabase-community/postgrest-go"
+)
+
+const (
+	MusicHistoryTable = "music_history"
+)
+
+// MusicHistoryEntry is a track that was played in a guild.
+type MusicHistoryEntry struct {
+	GuildID       string    `json:"guild_id"`
+	Track         string    `json:"track"` // The encoded track, so it can be played again.
+	Title         string    `json:"title"`
+	Author        string    `json:"author"`
+	URI           string    `json:"uri"`
+	Identifier    string    `json:"identifier"`  // The track's ID within its source.
+	SourceName    string    `json:"source_name"` // Where the track was loaded from, like youtube.
+	Length        int64     `json:"length"`      // In milliseconds.
+	RequestedBy   string    `json:"requested_by"`
+	RequestSource string    `json:"request_source,omitempty"`
+	Outcome       string    `json:"outcome"`
+	ListenedFor   int64     `json:"listened_for"` // In milliseconds, not counting pauses.
+	EndedAt       time.Time `json:"ended_at"`
+}
+
+// AddMusicHistoryEntry stores a played track.
+func (d *Database) AddMusicHistoryEntry(entry MusicHistoryEntry) error {
+	res := make([]MusicHistoryEntry, 1)
+
+	q := d.client.From(MusicHistoryTable).Insert(entry, false, "", "", "exact")
+	count, err := q.ExecuteTo(&res)
+	if err != nil {
+		return err
+	}
+
+	if count == 0 {
+		return errors.New("failed to insert music history entry")
+	}
+
+	return nil
+}
+
+// GetMusicHistory returns up to limit of the tracks most recently played in a guild, newest first.
+func (d *Database) GetMusicHistory(guildID string, limit int) ([]MusicHistoryEntry, error) {
+	res := make([]MusicHistoryEntry, 0)
+
+	f := d.client.From(MusicHistoryTable).Select("*", "exact", false).Eq("guild_id", guildID).
+		Order("ended_at", &postgrest.OrderOpts{Ascending: false}).Limit(limit, "")
+	_, err := f.ExecuteTo(&res)
+	if err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+// MusicHistoryPageSize is how many played tracks are fetched per request. PostgREST servers
+// usually cap how many rows a request returns, so larger amounts are fetched a page at a time.
+const MusicHistoryPageSize = 1000
+
+// GetMusicHistorySince returns up to limit of the tracks played in a guild since a point in time, newest first.
+// If userID isn't empty, only the tracks that user requested are returned.
+func (d *Database) GetMusicHistorySince(guildID string, userID string, since time.Time, limit int) ([]MusicHistoryEntry, error) {
+	res := make([]MusicHistoryEntry, 0)
+
+	for len(res) < limit {
+		page := make([]MusicHistoryEntry, 0)
+
+		f := d.client.From(MusicHistoryTable).Select("*", "exact", false).Eq("guild_id", guildID).
+			Gte("ended_at", since.UTC().Format(time.RFC3339))
+		if userID != "" {
+			f = f.Eq("requested_by", userID)
+		}
+
+		to := min(len(res)+MusicHistoryPageSize, limit) - 1
+
+		count, err := f.Order("ended_at", &postgrest.OrderOpts{Ascending: false}).Range(len(res), to, "").ExecuteTo(&page)
+		if err != nil {
+			return res, err
+		}
+
+		res = append(res, page...)
+
+		// A page may be cut short by the server's cap, so only the total count tells whether there are more.
+		if len(page) == 0 || int64(len(res)) >= count {
+			break
+		}
+	}
+
+	return res, nil
+}
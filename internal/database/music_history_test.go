@@ -0,0 +1,83 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// cappedHistoryServer serves rows of the music history the way PostgREST does,
+// returning no more than maxRows per request however many were asked for.
+func cappedHistoryServer(t *testing.T, rows []MusicHistoryEntry, maxRows int) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rest/v1/"+MusicHistoryTable {
+			http.NotFound(w, r)
+			return
+		}
+
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+		if err != nil {
+			limit = len(rows)
+		}
+
+		from := min(offset, len(rows))
+		to := min(from+min(limit, maxRows), len(rows))
+
+		w.Header().Set("Content-Range", fmt.Sprintf("%d-%d/%d", from, to-1, len(rows)))
+		json.NewEncoder(w).Encode(rows[from:to])
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestGetMusicHistorySincePages(t *testing.T) {
+	rows := make([]MusicHistoryEntry, 8)
+	for i := range rows {
+		rows[i] = MusicHistoryEntry{GuildID: "1", Title: fmt.Sprintf("track %d", i)}
+	}
+
+	server := cappedHistoryServer(t, rows, 3)
+
+	t.Setenv("DATABASE_URL", server.URL)
+	t.Setenv("DATABASE_KEY", "key")
+
+	db, err := NewDatabase()
+	if err != nil {
+		t.Fatalf("NewDatabase: %v", err)
+	}
+
+	tests := []struct {
+		limit    int
+		expected int
+	}{
+		{100, 8}, // More than the server returns at once.
+		{5, 5},
+		{2, 2},
+	}
+
+	for _, test := range tests {
+		history, err := db.GetMusicHistorySince("1", "", time.Time{}, test.limit)
+		if err != nil {
+			t.Fatalf("GetMusicHistorySince(%d): %v", test.limit, err)
+		}
+
+		if len(history) != test.expected {
+			t.Errorf("GetMusicHistorySince(%d) returned %d rows, expected %d", test.limit, len(history), test.expected)
+			continue
+		}
+
+		for i, entry := range history {
+			if entry.Title != rows[i].Title {
+				t.Errorf("GetMusicHistorySince(%d)[%d] = %q, expected %q", test.limit, i, entry.Title, rows[i].Title)
+			}
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package database
+
+import (
+	"time"
+
+	"github.com/disgoorg/disgolink/v3/lavalink"
+)
+
+const (
+	MusicSessionsTable = "music_sessions"
+)
+
+// MusicSessionSnapshot is what the bot needs to pick up a guild's music session after a restart.
+// The track playing isn't part of it: Lavalink keeps playing it while the bot is away.
+type MusicSessionSnapshot struct {
+	GuildID           string           `json:"guild_id"`
+	LavalinkNode      string           `json:"lavalink_node"`
+	LavalinkSessionID string           `json:"lavalink_session_id"` // The Lavalink session the guild's player lives in.
+	TextChannelID     string           `json:"text_channel_id"`
+	VoiceChannelID    string           `json:"voice_channel_id"`
+	PanelMessageID    string           `json:"panel_message_id,omitempty"`
+	Queue             []lavalink.Track `json:"queue"` // With their request data as user data.
+	Loop              int              `json:"loop"`
+	SleepMode         *int             `json:"sleep_mode,omitempty"` // Nil without a sleep timer.
+	SleepAt           time.Time        `json:"sleep_at"`
+	SavedAt           time.Time        `json:"saved_at"`
+}
+
+// SaveMusicSessionSnapshots stores snapshots, replacing any stored for the same guilds before.
+func (d *Database) SaveMusicSessionSnapshots(snapshots []MusicSessionSnapshot) error {
+	res := make([]MusicSessionSnapshot, 0)
+
+	q := d.client.From(MusicSessionsTable).Insert(snapshots, true, "guild_id", "", "exact")
+	_, err := q.ExecuteTo(&res)
+
+	return err
+}
+
+// GetMusicSessionSnapshots returns the snapshots stored for the sessions on a Lavalink node.
+func (d *Database) GetMusicSessionSnapshots(node string) ([]MusicSessionSnapshot, error) {
+	res := make([]MusicSessionSnapshot, 0)
+
+	f := d.client.From(MusicSessionsTable).Select("*", "exact", false).Eq("lavalink_node", node)
+	_, err := f.ExecuteTo(&res)
+	if err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+// DeleteMusicSessionSnapshots deletes the snapshots stored for the sessions on a Lavalink node,
+// once they were picked up or can't be anymore.
+func (d *Database) DeleteMusicSessionSnapshots(node string) error {
+	res := make([]MusicSessionSnapshot, 0)
+
+	q := d.client.From(MusicSessionsTable).Delete("", "exact").Eq("lavalink_node", node)
+	_, err := q.ExecuteTo(&res)
+
+	return err
+}
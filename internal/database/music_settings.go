@@ -0,0 +1,43 @@
+package database
+
+// MusicSettings holds a guild's music configuration.
+type MusicSettings struct {
+	// SearchSources is the ordered list of search sources non-URL queries are resolved with.
+	// An empty list means the bot's defaults are used.
+	SearchSources []string `json:"search_sources,omitempty"`
+
+	// DJRoleID is the role allowed to control playback for everyone.
+	// Without one, everyone is treated as a DJ, except that skipping takes a vote.
+	DJRoleID string `json:"dj_role_id,omitempty"`
+
+	// SkipVoteThreshold is the percentage of listeners that have to vote to skip a track.
+	// Zero means the bot's default is used.
+	SkipVoteThreshold int `json:"skip_vote_threshold,omitempty"`
+
+	// FairQueue interleaves queued tracks by requester in round-robin order.
+	FairQueue bool `json:"fair_queue,omitempty"`
+
+	// MaxUserQueue is how many tracks a single user may have queued. Zero means unlimited.
+	MaxUserQueue int `json:"max_user_queue,omitempty"`
+
+	// MaxPlaylistSize is how many tracks of a playlist get imported. Zero means unlimited.
+	MaxPlaylistSize int `json:"max_playlist_size,omitempty"`
+
+	// MaxTrackLength is the longest track, in seconds, that can be queued. Zero means unlimited.
+	MaxTrackLength int `json:"max_track_length,omitempty"`
+
+	// MaxQueueLength is how many tracks the queue can hold. Zero means unlimited.
+	MaxQueueLength int `json:"max_queue_length,omitempty"`
+
+	// RejectDuplicates refuses tracks that are already queued or were played recently.
+	RejectDuplicates bool `json:"reject_duplicates,omitempty"`
+
+	// BlockStreams refuses livestreams and other tracks without an end.
+	BlockStreams bool `json:"block_streams,omitempty"`
+
+	// Autoplay keeps playing tracks related to the recently played ones once the queue runs dry.
+	Autoplay bool `json:"autoplay,omitempty"`
+
+	// RequestChannelID is the text channel where plain messages are played as requests, if any.
+	RequestChannelID string `json:"request_channel_id,omitempty"`
+}
@@ -0,0 +1,137 @@
+package database
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/disgoorg/disgolink/v3/lavalink"
+	"github.com/supabase-community/postgrest-go"
+)
+
+const (
+	PlaylistsTable = "playlists"
+)
+
+// Playlist is a saved list of tracks. Personal playlists belong to a user and can be used
+// in any guild, while guild playlists are shared with everyone in their guild.
+// Either way, only their owner can change them.
+type Playlist struct {
+	ID        int64            `json:"id,omitempty"`
+	OwnerID   string           `json:"owner_id"`
+	GuildID   string           `json:"guild_id"` // Empty for personal playlists.
+	Name      string           `json:"name"`
+	Tracks    []lavalink.Track `json:"tracks"` // Encoded along with their info, so loading them doesn't need searching.
+	CreatedAt time.Time        `json:"created_at"`
+	UpdatedAt time.Time        `json:"updated_at"`
+}
+
+// IsPersonal returns whether the playlist belongs to a user rather than a guild.
+func (p *Playlist) IsPersonal() bool {
+	return p.GuildID == ""
+}
+
+// IsOwnedBy returns whether a user saved the playlist. Only they can change it, even if it's a guild's.
+func (p *Playlist) IsOwnedBy(userID string) bool {
+	return p.OwnerID == userID
+}
+
+// playlistScope narrows a query to a user's personal playlists, if guildID is empty, or to a guild's.
+func playlistScope(f *postgrest.FilterBuilder, ownerID string, guildID string) *postgrest.FilterBuilder {
+	if guildID == "" {
+		return f.Eq("guild_id", "").Eq("owner_id", ownerID)
+	}
+
+	return f.Eq("guild_id", guildID)
+}
+
+// GetPlaylist returns the playlist with the given name, either one of ownerID's personal
+// playlists if guildID is empty, or one of the guild's playlists.
+func (d *Database) GetPlaylist(ownerID string, guildID string, name string) (playlist Playlist, exists bool, err error) {
+	res := make([]Playlist, 1)
+
+	f := d.client.From(PlaylistsTable).Select("*", "exact", false)
+	f = playlistScope(f, ownerID, guildID).Eq("name", name).Limit(1, "")
+
+	count, err := f.ExecuteTo(&res)
+	if err != nil {
+		return playlist, false, err
+	}
+
+	if count == 0 {
+		return playlist, false, nil
+	}
+
+	return res[0], true, nil
+}
+
+// GetPlaylists returns ownerID's personal playlists if guildID is empty, or the guild's playlists, by name.
+func (d *Database) GetPlaylists(ownerID string, guildID string) ([]Playlist, error) {
+	res := make([]Playlist, 0)
+
+	f := d.client.From(PlaylistsTable).Select("*", "exact", false)
+	f = playlistScope(f, ownerID, guildID).Order("name", &postgrest.OrderOpts{Ascending: true})
+
+	_, err := f.ExecuteTo(&res)
+	if err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+// CreatePlaylist stores a new playlist.
+func (d *Database) CreatePlaylist(playlist Playlist) (Playlist, error) {
+	res := make([]Playlist, 1)
+
+	playlist.CreatedAt = time.Now()
+	playlist.UpdatedAt = playlist.CreatedAt
+
+	q := d.client.From(PlaylistsTable).Insert(playlist, false, "", "", "exact")
+	count, err := q.ExecuteTo(&res)
+	if err != nil {
+		return playlist, err
+	}
+
+	if count == 0 {
+		return playlist, errors.New("failed to insert playlist")
+	}
+
+	return res[0], nil
+}
+
+// UpdatePlaylist stores the changes made to a playlist.
+func (d *Database) UpdatePlaylist(playlist Playlist) (Playlist, error) {
+	res := make([]Playlist, 1)
+
+	playlist.UpdatedAt = time.Now()
+
+	q := d.client.From(PlaylistsTable).Update(playlist, "", "exact").Eq("id", strconv.FormatInt(playlist.ID, 10))
+	count, err := q.ExecuteTo(&res)
+	if err != nil {
+		return playlist, err
+	}
+
+	if count == 0 {
+		return playlist, errors.New("failed to update playlist")
+	}
+
+	return res[0], nil
+}
+
+// DeletePlaylist deletes a playlist for good.
+func (d *Database) DeletePlaylist(id int64) error {
+	res := make([]Playlist, 0)
+
+	q := d.client.From(PlaylistsTable).Delete("", "exact").Eq("id", strconv.FormatInt(id, 10))
+	count, err := q.ExecuteTo(&res)
+	if err != nil {
+		return err
+	}
+
+	if count == 0 {
+		return errors.New("failed to delete playlist")
+	}
+
+	return nil
+}
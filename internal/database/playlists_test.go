@@ -0,0 +1,28 @@
+package database
+
+import "testing"
+
+func TestPlaylistOwnership(t *testing.T) {
+	tests := []struct {
+		name     string
+		playlist Playlist
+		userID   string
+		owned    bool
+		personal bool
+	}{
+		{"own personal playlist", Playlist{OwnerID: "1"}, "1", true, true},
+		{"own server playlist", Playlist{OwnerID: "1", GuildID: "10"}, "1", true, false},
+		{"someone else's server playlist", Playlist{OwnerID: "1", GuildID: "10"}, "2", false, false},
+		{"someone else's personal playlist", Playlist{OwnerID: "1"}, "2", false, true},
+	}
+
+	for _, test := range tests {
+		if owned := test.playlist.IsOwnedBy(test.userID); owned != test.owned {
+			t.Errorf("%s: IsOwnedBy(%q) = %t, expected %t", test.name, test.userID, owned, test.owned)
+		}
+
+		if personal := test.playlist.IsPersonal(); personal != test.personal {
+			t.Errorf("%s: IsPersonal() = %t, expected %t", test.name, personal, test.personal)
+		}
+	}
+}
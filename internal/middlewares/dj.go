@@ -0,0 +1,53 @@
+package middlewares
+
+import (
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zekrotja/ken"
+
+	"unreal.sh/neo/internal/services/music"
+)
+
+var (
+	_ ken.MiddlewareBefore = (*DJMiddleware)(nil)
+)
+
+// Represents an interface for commands that control playback for everyone,
+// and therefore can only be used by DJs.
+type RequiresDJCommand interface {
+	RequiresDJ() bool
+}
+
+type DJMiddleware struct{}
+
+func (c *DJMiddleware) Before(ctx *ken.Ctx) (next bool, err error) {
+	cmd, ok := ctx.Command.(RequiresDJCommand)
+	if !ok || !cmd.RequiresDJ() {
+		return true, nil
+	}
+
+	musicService, ok := ctx.Get("MusicService").(*music.MusicService)
+	if !ok || musicService == nil {
+		return true, nil
+	}
+
+	guildID := ctx.GetEvent().GuildID
+	if musicService.IsDJ(guildID, ctx.GetEvent().Member) {
+		return true, nil
+	}
+
+	description := "Only DJs can use this command!"
+	if roleID := musicService.MusicSettings(guildID).DJRoleID; roleID != "" {
+		description = fmt.Sprintf("You must have the role <@&%s> to use this command!", roleID)
+	}
+
+	ctx.SetEphemeral(true)
+	err = ctx.RespondEmbed(&discordgo.MessageEmbed{
+		Title:       "🛑 Insufficient Permissions",
+		Color:       0xCC5500,
+		Description: description,
+	})
+
+	return false, err
+}
@@ -39,9 +39,15 @@ func (m *ModerationModule) IsGlobal() bool {
 func (m *ModerationModule) Commands() *[]ken.Command {
 	return &[]ken.Command{
 		new(slash.BanCommand),
+		new(slash.CaseCommand),
+		new(slash.InfractionsCommand),
 		new(slash.KickCommand),
+		new(slash.NoteCommand),
 		new(slash.PurgeCommand),
 		new(slash.SoftbanCommand),
+		new(slash.TimeoutCommand),
+		new(slash.UnbanCommand),
+		new(slash.WarnCommand),
 	}
 }
 
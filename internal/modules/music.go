@@ -35,12 +35,23 @@ func (m *MusicModule) IsGlobal() bool {
 
 func (m *MusicModule) Commands() *[]ken.Command {
 	return &[]ken.Command{
+		new(slash.BackCommand),
+		new(slash.FilterCommand),
+		new(slash.HistoryCommand),
+		new(slash.ImportCommand),
+		new(slash.MusicSettingsCommand),
 		new(slash.NowPlayingCommand),
 		new(slash.PauseCommand),
 		new(slash.PlayCommand),
+		new(slash.PlaylistCommand),
 		new(slash.QueueCommand),
+		new(slash.ReplayCommand),
 		new(slash.ResumeCommand),
+		new(slash.SearchCommand),
+		new(slash.SeekCommand),
 		new(slash.SkipCommand),
+		new(slash.SleepCommand),
+		new(slash.StatsCommand),
 		new(slash.StopCommand),
 		new(slash.VolumeCommand),
 	}
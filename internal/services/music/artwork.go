@@ -0,0 +1,201 @@
+package music
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/disgoorg/disgolink/v3/lavalink"
+
+	"unreal.sh/neo/internal/utils/colorutils"
+	"unreal.sh/neo/internal/utils/static"
+)
+
+const (
+	// ArtworkCacheSize is how many artwork colors are remembered, least recently used ones first to go.
+	ArtworkCacheSize = 1024
+
+	// ArtworkTimeout bounds how long fetching and reading an artwork may take.
+	ArtworkTimeout = 5 * time.Second
+
+	// MaxArtworkSize is how large an artwork may be. Covers are a few hundred KiB at most.
+	MaxArtworkSize = 4 << 20
+
+	// MaxArtworkPixels is how many pixels an artwork may have, so small files can't decode into huge images.
+	MaxArtworkPixels = 4096 * 4096
+
+	// ArtworkConcurrency is how many artworks are fetched at once, across every session.
+	ArtworkConcurrency = 4
+
+	// ArtworkRetryAfter is how long an artwork that couldn't be fetched or read gets the default color,
+	// until it's tried again. Hosts that were down or rate limiting the bot may be back by then.
+	ArtworkRetryAfter = 10 * time.Minute
+)
+
+var ErrArtworkTooLarge = errors.New("artwork is too large")
+
+// ArtworkColors finds the colors of track artworks for player panels.
+// It remembers the colors it found, so tracks played again don't fetch their artwork again,
+// and for a while the artworks it couldn't get a color from.
+type ArtworkColors struct {
+	client   *http.Client
+	slots    chan struct{}       // Held while fetching, to fetch ArtworkConcurrency artworks at most.
+	colors   *lruCache[int]      // Colors by artwork URL.
+	failures *lruCache[struct{}] // Artwork URLs that recently couldn't be fetched or read.
+}
+
+// NewArtworkColors creates an artwork color cache remembering up to size colors.
+func NewArtworkColors(size int) *ArtworkColors {
+	return &ArtworkColors{
+		client:   &http.Client{Timeout: ArtworkTimeout},
+		slots:    make(chan struct{}, ArtworkConcurrency),
+		colors:   newLRUCache[int](size, 0),
+		failures: newLRUCache[struct{}](size, ArtworkRetryAfter),
+	}
+}
+
+// Cached returns the color of an artwork if it's known already, without fetching it.
+// Artworks that recently couldn't be fetched or read have the default embed color.
+func (a *ArtworkColors) Cached(url string) (int, bool) {
+	if color, ok := a.colors.get(url); ok {
+		return color, true
+	}
+
+	if _, failed := a.failures.get(url); failed {
+		return static.ColorEmbedGray, true
+	}
+
+	return 0, false
+}
+
+// Color returns the color of an artwork, fetching it if it isn't known yet.
+// Artworks that can't be fetched or read get the default embed color, and aren't tried again for ArtworkRetryAfter.
+func (a *ArtworkColors) Color(ctx context.Context, url string) (int, error) {
+	if color, ok := a.Cached(url); ok {
+		return color, nil
+	}
+
+	color, err := a.fetch(ctx, url)
+	if err != nil {
+		// Running out of time isn't the artwork's fault, so that is tried again next time.
+		if ctx.Err() == nil {
+			a.failures.put(url, struct{}{})
+		}
+
+		return static.ColorEmbedGray, err
+	}
+
+	a.colors.put(url, color)
+
+	return color, nil
+}
+
+// fetch downloads an artwork and finds its color, waiting for a free slot first.
+func (a *ArtworkColors) fetch(ctx context.Context, url string) (int, error) {
+	select {
+	case a.slots <- struct{}{}:
+		defer func() { <-a.slots }()
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, ArtworkTimeout)
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	response, err := a.client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("artwork responded with %s", response.Status)
+	}
+
+	if response.ContentLength > MaxArtworkSize {
+		return 0, ErrArtworkTooLarge
+	}
+
+	data, err := io.ReadAll(io.LimitReader(response.Body, MaxArtworkSize+1))
+	if err != nil {
+		return 0, err
+	}
+
+	if len(data) > MaxArtworkSize {
+		return 0, ErrArtworkTooLarge
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return 0, err
+	}
+
+	if config.Width*config.Height > MaxArtworkPixels {
+		return 0, ErrArtworkTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return 0, err
+	}
+
+	return colorutils.GetDominantColorFromImage(img)
+}
+
+// artworkURL returns the URL of a track's artwork, or an empty string if it has none.
+func artworkURL(track *lavalink.Track) string {
+	if track.Info.ArtworkURL == nil {
+		return ""
+	}
+
+	return *track.Info.ArtworkURL
+}
+
+// colorPanel sets the player panel's color for a track that just started. A known color is used
+// right away. Otherwise the panel starts out gray and is recolored once the artwork was fetched,
+// which happens off the event loop so it doesn't hold up other events.
+func (s *MusicSession) colorPanel(track *lavalink.Track) {
+	s.panelColor = static.ColorEmbedGray
+
+	url := artworkURL(track)
+	if url == "" || s.artwork == nil {
+		return
+	}
+
+	if color, ok := s.artwork.Cached(url); ok {
+		s.panelColor = color
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(s.ctx, ArtworkTimeout)
+		defer cancel()
+
+		color, err := s.artwork.Color(ctx, url)
+		if err != nil {
+			slog.Warn("Failed to get artwork color.", slog.String("url", url), slog.String("error", err.Error()))
+			return
+		}
+
+		s.post(func(ctx context.Context) {
+			// Another track may have started in the meantime.
+			if s.current == nil || s.current.Encoded != track.Encoded {
+				return
+			}
+
+			s.panelColor = color
+			s.refreshPanel()
+		})
+	}()
+}
@@ -0,0 +1,163 @@
+package music
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"unreal.sh/neo/internal/utils/static"
+)
+
+// newArtworkServer serves a red artwork at /red.png, something that isn't an image at /text,
+// and an artwork too large to fetch at /large.png. It counts the requests it gets.
+func newArtworkServer(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	for x := 0; x < 8; x++ {
+		for y := 0; y < 8; y++ {
+			img.Set(x, y, color.RGBA{R: 255, A: 255})
+		}
+	}
+
+	var artwork bytes.Buffer
+	if err := png.Encode(&artwork, img); err != nil {
+		t.Fatal(err)
+	}
+
+	requests := new(atomic.Int32)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+
+		switch r.URL.Path {
+		case "/red.png":
+			w.Write(artwork.Bytes())
+		case "/text":
+			w.Write([]byte("not an image"))
+		case "/large.png":
+			w.Write(make([]byte, MaxArtworkSize+1))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server, requests
+}
+
+func TestArtworkColorIsCached(t *testing.T) {
+	server, requests := newArtworkServer(t)
+	artwork := NewArtworkColors(ArtworkCacheSize)
+	ctx := context.Background()
+
+	c, err := artwork.Color(ctx, server.URL+"/red.png")
+	if err != nil {
+		t.Fatalf("Color: %v", err)
+	}
+
+	if c != 0xff0000 {
+		t.Fatalf("expected red, got %06x", c)
+	}
+
+	if cached, ok := artwork.Cached(server.URL + "/red.png"); !ok || cached != c {
+		t.Fatalf("expected the color to be cached, got %06x, %v", cached, ok)
+	}
+
+	if _, err := artwork.Color(ctx, server.URL+"/red.png"); err != nil {
+		t.Fatalf("Color: %v", err)
+	}
+
+	if requests.Load() != 1 {
+		t.Fatalf("expected the artwork to be fetched once, got %d requests", requests.Load())
+	}
+}
+
+func TestArtworkColorFallsBack(t *testing.T) {
+	server, requests := newArtworkServer(t)
+	artwork := NewArtworkColors(ArtworkCacheSize)
+	ctx := context.Background()
+
+	for _, path := range []string{"/text", "/missing", "/large.png"} {
+		c, err := artwork.Color(ctx, server.URL+path)
+		if err == nil {
+			t.Errorf("Color(%q): expected an error", path)
+		}
+
+		if c != static.ColorEmbedGray {
+			t.Errorf("Color(%q) = %06x, want the default color", path, c)
+		}
+
+		// Broken artworks aren't fetched again for a while either.
+		if cached, ok := artwork.Cached(server.URL + path); !ok || cached != static.ColorEmbedGray {
+			t.Errorf("expected the default color to be cached for %q", path)
+		}
+	}
+
+	if _, err := artwork.Color(ctx, server.URL+"/large.png"); err != nil {
+		t.Fatalf("Color: %v", err)
+	}
+
+	if requests.Load() != 3 {
+		t.Fatalf("expected every artwork to be fetched once, got %d requests", requests.Load())
+	}
+
+	_, err := artwork.fetch(ctx, server.URL+"/large.png")
+	if !errors.Is(err, ErrArtworkTooLarge) {
+		t.Fatalf("expected ErrArtworkTooLarge, got %v", err)
+	}
+}
+
+func TestArtworkFailuresAreRetried(t *testing.T) {
+	server, requests := newArtworkServer(t)
+	artwork := NewArtworkColors(ArtworkCacheSize)
+	artwork.failures = newLRUCache[struct{}](ArtworkCacheSize, 10*time.Millisecond)
+	ctx := context.Background()
+
+	if _, err := artwork.Color(ctx, server.URL+"/missing"); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	if _, ok := artwork.Cached(server.URL + "/missing"); ok {
+		t.Fatal("expected the failure to be forgotten")
+	}
+
+	if _, err := artwork.Color(ctx, server.URL+"/missing"); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	if requests.Load() != 2 {
+		t.Fatalf("expected the artwork to be fetched again, got %d requests", requests.Load())
+	}
+}
+
+func TestArtworkCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	artwork := NewArtworkColors(2)
+
+	artwork.colors.put("a", 1)
+	artwork.colors.put("b", 2)
+
+	// Using a makes b the least recently used.
+	artwork.Cached("a")
+	artwork.colors.put("c", 3)
+
+	if _, ok := artwork.Cached("b"); ok {
+		t.Fatal("expected b to be evicted")
+	}
+
+	for url, want := range map[string]int{"a": 1, "c": 3} {
+		if c, ok := artwork.Cached(url); !ok || c != want {
+			t.Fatalf("Cached(%q) = %d, %v, want %d", url, c, ok, want)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package music
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/disgoorg/disgolink/v3/lavalink"
+)
+
+// AutoplaySeeds is how many of the most recently played tracks autoplay looks for related tracks to.
+const AutoplaySeeds = 3
+
+// titleNoise matches the parts of a track title that don't say which song it is,
+// like "(Official Video)", "[Remastered]" or "feat. Someone".
+var titleNoise = regexp.MustCompile(`(?i)[(\[][^)\]]*[)\]]|\bf(ea)?t\.?\s.*$`)
+
+// autoplaying returns whether the current track was picked by autoplay.
+func (s *MusicSession) autoplaying() bool {
+	if s.current == nil {
+		return false
+	}
+
+	var data TrackRequestData
+	s.current.UserData.Unmarshal(&data)
+
+	return data.Autoplay
+}
+
+// autoplaySearch is autoplay looking for a related track off the event loop.
+type autoplaySearch struct {
+	replaces *lavalink.Track // The track playing when the search started, which the track found replaces.
+}
+
+// autoplay starts looking for a track related to the recently played ones, for when the queue ran dry.
+// The candidates are loaded off the event loop, one query at a time, and the first that fits is played.
+// It returns whether it started looking, which it never does with autoplay turned off.
+func (s *MusicSession) autoplay() bool {
+	if !s.settings.Autoplay {
+		return false
+	}
+
+	queries := s.autoplayQueries()
+	if len(queries) == 0 {
+		return false
+	}
+
+	search := &autoplaySearch{replaces: s.current}
+	s.pendingAutoplay = search
+	s.searchAutoplay(search, queries)
+
+	return true
+}
+
+// searchAutoplay loads the first of the queries in the background and hands its tracks to the event loop.
+func (s *MusicSession) searchAutoplay(search *autoplaySearch, queries []string) {
+	go func() {
+		ctx, cancel := context.WithTimeout(s.ctx, OperationTimeout)
+		defer cancel()
+
+		var candidates []lavalink.Track
+
+		result, err := s.tracks.LoadTracks(ctx, queries[0])
+		if err != nil {
+			slog.Warn("Failed to load autoplay candidates.",
+				slog.String("guild_id", s.GuildID), slog.String("query", queries[0]), slog.String("error", err.Error()))
+		} else {
+			candidates = loadedTracks(result)
+		}
+
+		s.post(func(ctx context.Context) {
+			if err := s.handleAutoplayCandidates(ctx, search, queries[1:], candidates); err != nil {
+				slog.Error("Failed to autoplay a track.", slog.String("guild_id", s.GuildID), slog.String("error", err.Error()))
+			}
+		})
+	}()
+}
+
+// handleAutoplayCandidates plays the first of the tracks an autoplay search loaded that fits,
+// or moves on to the remaining queries. Once there are none left, playback ends as it would without autoplay.
+func (s *MusicSession) handleAutoplayCandidates(ctx context.Context, search *autoplaySearch, queries []string, candidates []lavalink.Track) error {
+	// The search was cancelled, or something else started playing in the meantime.
+	if s.pendingAutoplay != search || s.current != search.replaces {
+		return nil
+	}
+
+	// Tracks queued in the meantime go first.
+	if len(s.queue) > 0 {
+		s.pendingAutoplay = nil
+
+		next, data := s.dequeue()
+		return s.play(ctx, next, data)
+	}
+
+	for _, track := range candidates {
+		if s.playedRecently(&track) || s.admit(&track) != nil {
+			continue
+		}
+
+		s.pendingAutoplay = nil
+
+		data := TrackRequestData{
+			RequestedAt: time.Now(),
+			Autoplay:    true,
+		}
+
+		return s.play(ctx, &track, data)
+	}
+
+	if len(queries) > 0 {
+		s.searchAutoplay(search, queries)
+		return nil
+	}
+
+	s.pendingAutoplay = nil
+
+	if s.current == nil {
+		s.closePanel()
+		return nil
+	}
+
+	return s.stop(ctx)
+}
+
+// autoplaySeeds returns the tracks autoplay looks for related tracks to, most recent first.
+// Tracks that failed to play are left out, since they may not be what they claim to be.
+func (s *MusicSession) autoplaySeeds() []*lavalink.Track {
+	seeds := make([]*lavalink.Track, 0, AutoplaySeeds)
+	if s.current != nil {
+		seeds = append(seeds, s.current)
+	}
+
+	for i := len(s.history) - 1; i >= 0 && len(seeds) < AutoplaySeeds; i-- {
+		entry := s.history[i]
+		if entry.Outcome == HistoryOutcomeFailed {
+			continue
+		}
+
+		if len(seeds) > 0 && trackKey(seeds[len(seeds)-1]) == trackKey(&entry.Track) {
+			continue
+		}
+
+		seeds = append(seeds, &entry.Track)
+	}
+
+	return seeds
+}
+
+// autoplayQueries returns what autoplay loads to find related tracks, best first:
+// the sources' own recommendations for the seeds, then tracks by the seeds' artists
+// with similar titles, then anything else by them.
+func (s *MusicSession) autoplayQueries() []string {
+	seeds := s.autoplaySeeds()
+
+	prefix := "ytsearch"
+	if source, ok := LookupSearchSource(ResolveSearchSources(s.settings)[0]); ok {
+		prefix = source.Prefix
+	}
+
+	queries := make([]string, 0, len(seeds)*3)
+
+	for _, seed := range seeds {
+		if query, ok := recommendationQuery(seed); ok {
+			queries = append(queries, query)
+		}
+	}
+
+	for _, seed := range seeds {
+		if seed.Info.Author != "" {
+			queries = append(queries, fmt.Sprintf("%s:%s %s", prefix, seed.Info.Author, titleKeywords(seed.Info.Title)))
+		}
+	}
+
+	for _, seed := range seeds {
+		if seed.Info.Author != "" {
+			queries = append(queries, fmt.Sprintf("%s:%s", prefix, seed.Info.Author))
+		}
+	}
+
+	return queries
+}
+
+// recommendationQuery returns the query that loads a source's recommendations for a track, if it has any.
+// Spotify and Deezer recommendations need the LavaSrc plugin.
+func recommendationQuery(track *lavalink.Track) (string, bool) {
+	id := track.Info.Identifier
+
+	switch track.Info.SourceName {
+	case "youtube":
+		return fmt.Sprintf("https://www.youtube.com/watch?v=%s&list=RD%s", id, id), true
+	case "spotify":
+		return fmt.Sprintf("sprec:seed_tracks=%s", id), true
+	case "deezer":
+		return fmt.Sprintf("dzrec:%s", id), true
+	default:
+		return "", false
+	}
+}
+
+// playedRecently returns whether a track, or another upload of the same song,
+// is playing, queued or in the history.
+func (s *MusicSession) playedRecently(track *lavalink.Track) bool {
+	if s.isDuplicate(track) {
+		return true
+	}
+
+	key, title := trackKey(track), normalizeTitle(track.Info.Title)
+	if title == "" {
+		return false
+	}
+
+	if s.current != nil && normalizeTitle(s.current.Info.Title) == title {
+		return true
+	}
+
+	for _, entry := range s.history {
+		if trackKey(&entry.Track) == key || normalizeTitle(entry.Track.Info.Title) == title {
+			return true
+		}
+	}
+
+	return false
+}
+
+// titleKeywords returns the first few words of a track's title that say which song it is.
+func titleKeywords(title string) string {
+	words := strings.Fields(titleNoise.ReplaceAllString(title, ""))
+	if len(words) > 4 {
+		words = words[:4]
+	}
+
+	return strings.Join(words, " ")
+}
+
+func normalizeTitle(title string) string {
+	return strings.ToLower(strings.Join(strings.Fields(titleNoise.ReplaceAllString(title, "")), " "))
+}
+
+// loadedTracks returns every track in a load result.
+func loadedTracks(result *lavalink.LoadResult) []lavalink.Track {
+	switch data := result.Data.(type) {
+	case lavalink.Track:
+		return []lavalink.Track{data}
+	case lavalink.Search:
+		return data
+	case lavalink.Playlist:
+		return data.Tracks
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,39 @@
+package music
+
+// FailureAction is what a session does after a track fails to play.
+type FailureAction int
+
+const (
+	FailureActionRetry FailureAction = iota // Reload the failed track and play it again.
+	FailureActionSkip                       // Give up on the failed track and play the next one.
+	FailureActionStop                       // Give up on playback entirely.
+)
+
+// FailurePolicy decides how a session reacts to track exceptions and stuck tracks.
+type FailurePolicy struct {
+	// Retries is how many times a failed track is reloaded before it is skipped.
+	Retries int
+
+	// MaxConsecutiveFailures is how many tracks failing in a row stop playback,
+	// however often each was retried. A track that finishes normally resets the count.
+	MaxConsecutiveFailures int
+}
+
+var DefaultFailurePolicy = FailurePolicy{
+	Retries:                1,
+	MaxConsecutiveFailures: 3,
+}
+
+// Decide returns the action to take for a track that has failed attempts times,
+// with consecutiveFailures tracks, itself included, having failed in a row.
+func (p FailurePolicy) Decide(attempts int, consecutiveFailures int) FailureAction {
+	if consecutiveFailures >= p.MaxConsecutiveFailures {
+		return FailureActionStop
+	}
+
+	if attempts <= p.Retries {
+		return FailureActionRetry
+	}
+
+	return FailureActionSkip
+}
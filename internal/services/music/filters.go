@@ -0,0 +1,125 @@
+package music
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/disgoorg/disgolink/v3/lavalink"
+)
+
+// FilterPresets maps preset names to the filters they apply.
+// Presets are applied on top of the session's current filters.
+var FilterPresets = map[string]lavalink.Filters{
+	"bassboost": {
+		Equalizer: &lavalink.Equalizer{0.2, 0.15, 0.1, 0.05, 0.0, -0.05},
+	},
+	"nightcore": {
+		Timescale: &lavalink.Timescale{Speed: 1.25, Pitch: 1.25, Rate: 1.0},
+	},
+	"vaporwave": {
+		Timescale: &lavalink.Timescale{Speed: 0.8, Pitch: 0.8, Rate: 1.0},
+		Equalizer: &lavalink.Equalizer{0.3, 0.3},
+		Tremolo:   &lavalink.Tremolo{Frequency: 14, Depth: 0.3},
+	},
+	"8d": {
+		// The usual 8D effect rotates about every five seconds, at 0.2Hz. Lavalink accepts fractional
+		// frequencies, but disgolink only takes whole hertz, so this is the slowest rotation there is:
+		// once per second.
+		Rotation: &lavalink.Rotation{RotationHz: 1},
+	},
+	"karaoke": {
+		Karaoke: &lavalink.Karaoke{Level: 1.0, MonoLevel: 1.0, FilterBand: 220.0, FilterWidth: 100.0},
+	},
+	"soft": {
+		LowPass: &lavalink.LowPass{Smoothing: 20.0},
+	},
+}
+
+// MergeFilters returns base with every filter set in overlay replacing its counterpart.
+func MergeFilters(base lavalink.Filters, overlay lavalink.Filters) lavalink.Filters {
+	if overlay.Volume != nil {
+		base.Volume = overlay.Volume
+	}
+	if overlay.Equalizer != nil {
+		base.Equalizer = overlay.Equalizer
+	}
+	if overlay.Timescale != nil {
+		base.Timescale = overlay.Timescale
+	}
+	if overlay.Tremolo != nil {
+		base.Tremolo = overlay.Tremolo
+	}
+	if overlay.Vibrato != nil {
+		base.Vibrato = overlay.Vibrato
+	}
+	if overlay.Rotation != nil {
+		base.Rotation = overlay.Rotation
+	}
+	if overlay.Karaoke != nil {
+		base.Karaoke = overlay.Karaoke
+	}
+	if overlay.Distortion != nil {
+		base.Distortion = overlay.Distortion
+	}
+	if overlay.ChannelMix != nil {
+		base.ChannelMix = overlay.ChannelMix
+	}
+	if overlay.LowPass != nil {
+		base.LowPass = overlay.LowPass
+	}
+
+	return base
+}
+
+// DescribeFilters returns a short, human readable description of each active filter.
+func DescribeFilters(filters lavalink.Filters) []string {
+	descriptions := make([]string, 0)
+
+	if filters.Equalizer != nil {
+		bands := make([]string, 0)
+		for band, gain := range filters.Equalizer {
+			if gain != 0 {
+				bands = append(bands, fmt.Sprintf("%d: %+.2f", band, gain))
+			}
+		}
+
+		if len(bands) > 0 {
+			descriptions = append(descriptions, fmt.Sprintf("Equalizer (%s)", strings.Join(bands, ", ")))
+		}
+	}
+
+	if filters.Timescale != nil {
+		descriptions = append(descriptions, fmt.Sprintf("Timescale (%.2fx speed, %.2fx pitch)",
+			filters.Timescale.Speed, filters.Timescale.Pitch))
+	}
+
+	if filters.Tremolo != nil {
+		descriptions = append(descriptions, "Tremolo")
+	}
+
+	if filters.Vibrato != nil {
+		descriptions = append(descriptions, "Vibrato")
+	}
+
+	if filters.Rotation != nil {
+		descriptions = append(descriptions, fmt.Sprintf("Rotation (%dHz)", filters.Rotation.RotationHz))
+	}
+
+	if filters.Karaoke != nil {
+		descriptions = append(descriptions, "Karaoke")
+	}
+
+	if filters.Distortion != nil {
+		descriptions = append(descriptions, "Distortion")
+	}
+
+	if filters.ChannelMix != nil {
+		descriptions = append(descriptions, "Channel mix")
+	}
+
+	if filters.LowPass != nil {
+		descriptions = append(descriptions, "Low pass")
+	}
+
+	return descriptions
+}
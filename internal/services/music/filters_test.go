@@ -0,0 +1,55 @@
+package music
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/disgoorg/disgolink/v3/lavalink"
+)
+
+func TestFilterPresets(t *testing.T) {
+	tests := []struct {
+		preset   string
+		expected []string
+	}{
+		{"bassboost", []string{"Equalizer (0: +0.20, 1: +0.15, 2: +0.10, 3: +0.05, 5: -0.05)"}},
+		{"nightcore", []string{"Timescale (1.25x speed, 1.25x pitch)"}},
+		{"vaporwave", []string{"Equalizer (0: +0.30, 1: +0.30)", "Timescale (0.80x speed, 0.80x pitch)", "Tremolo"}},
+		{"8d", []string{"Rotation (1Hz)"}},
+		{"karaoke", []string{"Karaoke"}},
+		{"soft", []string{"Low pass"}},
+	}
+
+	if len(tests) != len(FilterPresets) {
+		t.Fatalf("expected every one of the %d presets to be tested", len(FilterPresets))
+	}
+
+	for _, test := range tests {
+		preset, exists := FilterPresets[test.preset]
+		if !exists {
+			t.Fatalf("preset %q doesn't exist", test.preset)
+		}
+
+		descriptions := DescribeFilters(MergeFilters(lavalink.Filters{}, preset))
+		if !reflect.DeepEqual(descriptions, test.expected) {
+			t.Errorf("preset %q: expected %q, got %q", test.preset, test.expected, descriptions)
+		}
+	}
+}
+
+func TestMergeFilters(t *testing.T) {
+	filters := MergeFilters(FilterPresets["nightcore"], FilterPresets["soft"])
+	if filters.Timescale == nil || filters.LowPass == nil {
+		t.Fatalf("expected presets to add up, got %q", DescribeFilters(filters))
+	}
+
+	// Presets replace the filters they set, and only those.
+	filters = MergeFilters(filters, FilterPresets["vaporwave"])
+	if filters.Timescale.Speed != 0.8 || filters.LowPass == nil || filters.Equalizer == nil {
+		t.Fatalf("expected vaporwave to replace the timescale only, got %q", DescribeFilters(filters))
+	}
+
+	if descriptions := DescribeFilters(lavalink.Filters{}); len(descriptions) != 0 {
+		t.Fatalf("expected no filters to describe, got %q", descriptions)
+	}
+}
@@ -0,0 +1,241 @@
+package music
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/disgoorg/disgolink/v3/lavalink"
+
+	"unreal.sh/neo/internal/database"
+)
+
+// HistorySize is how many played tracks a session remembers.
+const HistorySize = 50
+
+// HistoryQueueSize is how many played tracks can wait to be stored before more are dropped.
+const HistoryQueueSize = 100
+
+var ErrNoHistory = errors.New("no track was played before")
+
+// HistoryOutcome is how a track in the history stopped playing.
+type HistoryOutcome string
+
+const (
+	HistoryOutcomePlayed  HistoryOutcome = "played"  // The track finished.
+	HistoryOutcomeSkipped HistoryOutcome = "skipped" // Another track replaced it.
+	HistoryOutcomeStopped HistoryOutcome = "stopped" // Playback was stopped.
+	HistoryOutcomeFailed  HistoryOutcome = "failed"  // The track failed to load or play.
+)
+
+// HistoryEntry is a track that was played in a session.
+type HistoryEntry struct {
+	Track       lavalink.Track
+	Data        TrackRequestData
+	Outcome     HistoryOutcome
+	ListenedFor time.Duration // How long the track played, not counting pauses.
+	EndedAt     time.Time
+}
+
+// History returns the tracks played in the session, oldest first.
+func (s *MusicSession) History(ctx context.Context) (history []HistoryEntry, err error) {
+	err = s.do(ctx, func() error {
+		history = append([]HistoryEntry(nil), s.history...)
+		return nil
+	})
+
+	return history, err
+}
+
+// Back plays the previously played track. The current track, if any,
+// is put back at the front of the queue so it plays right after.
+func (s *MusicSession) Back(ctx context.Context) (track *lavalink.Track, err error) {
+	err = s.do(ctx, func() error {
+		if len(s.history) == 0 {
+			return ErrNoHistory
+		}
+
+		previous := s.history[len(s.history)-1]
+		s.history = s.history[:len(s.history)-1]
+
+		if s.current != nil {
+			var data TrackRequestData
+			s.current.UserData.Unmarshal(&data)
+
+			s.queue = append([]*lavalink.Track{s.current}, s.queue...)
+			s.data = append([]TrackRequestData{data}, s.data...)
+
+			// Going back isn't skipping, and the history would just bounce between the two tracks otherwise.
+			s.forgetNextEnd = true
+		}
+
+		track = &previous.Track
+
+		return s.play(ctx, track, previous.Data)
+	})
+
+	return track, err
+}
+
+// record adds a track that stopped playing to the history.
+func (s *MusicSession) record(track *lavalink.Track, reason lavalink.TrackEndReason) {
+	if s.forgetNextEnd {
+		s.forgetNextEnd = false
+		return
+	}
+
+	var data TrackRequestData
+	track.UserData.Unmarshal(&data)
+
+	entry := HistoryEntry{
+		Track:       *track,
+		Data:        data,
+		Outcome:     historyOutcome(reason),
+		ListenedFor: s.stopListening(track),
+		EndedAt:     time.Now(),
+	}
+
+	s.history = append(s.history, entry)
+	if len(s.history) > HistorySize {
+		s.history = s.history[len(s.history)-HistorySize:]
+	}
+
+	if s.onHistory != nil {
+		s.onHistory(entry)
+	}
+}
+
+func historyOutcome(reason lavalink.TrackEndReason) HistoryOutcome {
+	switch reason {
+	case lavalink.TrackEndReasonFinished:
+		return HistoryOutcomePlayed
+	case lavalink.TrackEndReasonReplaced:
+		return HistoryOutcomeSkipped
+	case lavalink.TrackEndReasonLoadFailed:
+		return HistoryOutcomeFailed
+	default:
+		return HistoryOutcomeStopped
+	}
+}
+
+// History returns the tracks played in a guild, oldest first.
+// It's taken from the guild's session if it has one, or from storage otherwise.
+func (s *MusicService) History(ctx context.Context, guildID string) ([]HistoryEntry, error) {
+	if session := s.GetMusicSession(guildID); session != nil {
+		return session.History(ctx)
+	}
+
+	return s.loadHistory(guildID)
+}
+
+// loadHistory reads the most recent tracks played in a guild from storage, oldest first.
+func (s *MusicService) loadHistory(guildID string) ([]HistoryEntry, error) {
+	rows, err := s.db.GetMusicHistory(guildID, HistorySize)
+	if err != nil {
+		return nil, err
+	}
+
+	history := make([]HistoryEntry, len(rows))
+	for i, row := range rows {
+		// Tracks without a link were stored with an empty one.
+		var uri *string
+		if link := row.URI; link != "" {
+			uri = &link
+		}
+
+		// Rows come newest first.
+		history[len(rows)-1-i] = HistoryEntry{
+			Track: lavalink.Track{
+				Encoded: row.Track,
+				Info: lavalink.TrackInfo{
+					Title:      row.Title,
+					Author:     row.Author,
+					URI:        uri,
+					Length:     lavalink.Duration(row.Length),
+					Identifier: row.Identifier,
+					SourceName: row.SourceName,
+				},
+			},
+			Data: TrackRequestData{
+				AuthorID: row.RequestedBy,
+				Source:   row.RequestSource,
+				Autoplay: row.RequestedBy == "", // Only autoplay plays tracks nobody requested.
+			},
+			Outcome:     HistoryOutcome(row.Outcome),
+			ListenedFor: time.Duration(row.ListenedFor) * time.Millisecond,
+			EndedAt:     row.EndedAt,
+		}
+	}
+
+	return history, nil
+}
+
+// historyWrite is a played track waiting to be stored.
+type historyWrite struct {
+	guildID string
+	entry   HistoryEntry
+}
+
+// queueHistory has a played track stored in the background. If too many are waiting already,
+// like while the database is slow to respond, it's dropped rather than holding up the session.
+func (s *MusicService) queueHistory(guildID string, entry HistoryEntry) {
+	select {
+	case s.history <- historyWrite{guildID: guildID, entry: entry}:
+	default:
+		slog.Warn("Dropped a played track, too many are waiting to be stored.", slog.String("guild_id", guildID))
+	}
+}
+
+// writeHistory stores the queued played tracks one at a time until the service closes,
+// and then the ones still waiting.
+func (s *MusicService) writeHistory() {
+	defer close(s.historyDone)
+
+	for {
+		select {
+		case write := <-s.history:
+			s.storeHistory(write)
+		case <-s.ctx.Done():
+			for {
+				select {
+				case write := <-s.history:
+					s.storeHistory(write)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (s *MusicService) storeHistory(write historyWrite) {
+	err := s.saveHistory(write.guildID, write.entry)
+	if err != nil {
+		slog.Error("Failed to save music history.", slog.String("guild_id", write.guildID), slog.String("error", err.Error()))
+	}
+}
+
+// saveHistory stores a played track, so the guild's history outlives its session.
+func (s *MusicService) saveHistory(guildID string, entry HistoryEntry) error {
+	uri := ""
+	if entry.Track.Info.URI != nil {
+		uri = *entry.Track.Info.URI
+	}
+
+	return s.db.AddMusicHistoryEntry(database.MusicHistoryEntry{
+		GuildID:       guildID,
+		Track:         entry.Track.Encoded,
+		Title:         entry.Track.Info.Title,
+		Author:        entry.Track.Info.Author,
+		URI:           uri,
+		Identifier:    entry.Track.Info.Identifier,
+		SourceName:    entry.Track.Info.SourceName,
+		Length:        int64(entry.Track.Info.Length),
+		RequestedBy:   entry.Data.AuthorID,
+		RequestSource: entry.Data.Source,
+		Outcome:       string(entry.Outcome),
+		ListenedFor:   entry.ListenedFor.Milliseconds(),
+		EndedAt:       entry.EndedAt,
+	})
+}
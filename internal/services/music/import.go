@@ -0,0 +1,156 @@
+package music
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/disgoorg/disgolink/v3/lavalink"
+)
+
+const (
+	// ImportTimeout bounds how long importing a whole playlist file may take.
+	ImportTimeout = 5 * time.Minute
+
+	// ImportConcurrency is how many entries of a playlist file are loaded at once.
+	ImportConcurrency = 4
+)
+
+// ImportedEntry is the outcome of loading an entry of a playlist file.
+type ImportedEntry struct {
+	Entry  PlaylistEntry
+	Tracks []lavalink.Track // Usually one, but an entry may link to a whole playlist.
+	Source string           // The search source the tracks were found on, if they were searched for.
+	Err    error
+}
+
+// ImportContext returns a context for importing a playlist file,
+// which ends after ImportTimeout or once the service closes.
+func (s *MusicService) ImportContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(s.ctx, ImportTimeout)
+}
+
+// DownloadPlaylistFile downloads a playlist file, like a Discord attachment, and reads its entries.
+func DownloadPlaylistFile(ctx context.Context, name string, url string) ([]PlaylistEntry, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download playlist file: %s", response.Status)
+	}
+
+	// Read one byte past the limit, so too large files are told apart from ones right at it.
+	data, err := io.ReadAll(io.LimitReader(response.Body, MaxPlaylistFileSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	return ParsePlaylistFile(name, data)
+}
+
+// ImportPlaylistEntries loads the entries of a playlist file, a few at a time, and passes
+// each outcome to yield in the order the entries are listed, so they can be enqueued in order.
+// Loading stops early if yield returns false.
+func (s *MusicService) ImportPlaylistEntries(ctx context.Context, entries []PlaylistEntry, sources []string, yield func(ImportedEntry) bool) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	results := make([]ImportedEntry, len(entries))
+	done := make([]chan struct{}, len(entries))
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+
+	// Loads still going when yield stops are cancelled before waiting for them.
+	var wg sync.WaitGroup
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	slots := make(chan struct{}, ImportConcurrency)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		for i := range entries {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				defer func() { <-slots }()
+
+				results[i] = s.importEntry(ctx, entries[i], sources)
+				close(done[i])
+			}(i)
+		}
+	}()
+
+	for i := range entries {
+		select {
+		case <-done[i]:
+		case <-ctx.Done():
+			return
+		}
+
+		if !yield(results[i]) {
+			return
+		}
+	}
+}
+
+// importEntry loads a single entry of a playlist file, giving up on it after OperationTimeout.
+func (s *MusicService) importEntry(ctx context.Context, entry PlaylistEntry, sources []string) ImportedEntry {
+	ctx, cancel := context.WithTimeout(ctx, OperationTimeout)
+	defer cancel()
+
+	imported := ImportedEntry{Entry: entry}
+
+	if entry.Track != nil {
+		imported.Tracks = []lavalink.Track{*entry.Track}
+		return imported
+	}
+
+	result, source, err := s.LoadTracks(ctx, entry.Query(), sources)
+	if err != nil {
+		imported.Err = err
+		return imported
+	}
+
+	imported.Source = source
+
+	switch data := result.Data.(type) {
+	case lavalink.Track:
+		imported.Tracks = []lavalink.Track{data}
+	case lavalink.Search:
+		if len(data) > 0 {
+			imported.Tracks = data[:1]
+		}
+	case lavalink.Playlist:
+		imported.Tracks = data.Tracks
+	case lavalink.Exception:
+		imported.Err = data
+	}
+
+	if imported.Err == nil && len(imported.Tracks) == 0 {
+		imported.Err = ErrNoSearchResults
+	}
+
+	return imported
+}
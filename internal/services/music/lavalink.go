@@ -0,0 +1,68 @@
+package music
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/disgoorg/disgolink/v3/disgolink"
+	"github.com/disgoorg/disgolink/v3/lavalink"
+	"github.com/disgoorg/snowflake/v2"
+
+	"unreal.sh/neo/internal/database"
+)
+
+var ErrNoNode = errors.New("no Lavalink node is available")
+
+// Player is the part of a Lavalink player a session controls.
+// disgolink.Player satisfies it. Sessions keep track of the player's state
+// themselves, from the updates they send and the events they get.
+type Player interface {
+	Update(ctx context.Context, opts ...lavalink.PlayerUpdateOpt) error
+}
+
+// Node is the part of a Lavalink node that loads tracks.
+// disgolink.Node satisfies it.
+type Node interface {
+	LoadTracks(ctx context.Context, identifier string) (*lavalink.LoadResult, error)
+}
+
+// Lavalink is the part of a Lavalink client the music service plays through.
+// It loads tracks from whichever of its nodes is best at the time.
+type Lavalink interface {
+	Node
+
+	Player(guildID snowflake.ID) Player
+	OnVoiceStateUpdate(ctx context.Context, guildID snowflake.ID, channelID *snowflake.ID, sessionID string)
+	OnVoiceServerUpdate(ctx context.Context, guildID snowflake.ID, token string, endpoint string)
+}
+
+// Store is where the music service keeps guild settings, played tracks and what was playing when the bot shut down.
+// database.Database satisfies it.
+type Store interface {
+	GetGuildSettings(guildID string) (database.GuildSettings, bool, error)
+	GetMusicHistory(guildID string, limit int) ([]database.MusicHistoryEntry, error)
+	GetMusicHistorySince(guildID string, userID string, since time.Time, limit int) ([]database.MusicHistoryEntry, error)
+	AddMusicHistoryEntry(entry database.MusicHistoryEntry) error
+	SaveMusicSessionSnapshots(snapshots []database.MusicSessionSnapshot) error
+	GetMusicSessionSnapshots(node string) ([]database.MusicSessionSnapshot, error)
+	DeleteMusicSessionSnapshots(node string) error
+}
+
+// disgolinkClient makes a disgolink.Client a Lavalink.
+type disgolinkClient struct {
+	disgolink.Client
+}
+
+func (c disgolinkClient) Player(guildID snowflake.ID) Player {
+	return c.Client.Player(guildID)
+}
+
+func (c disgolinkClient) LoadTracks(ctx context.Context, identifier string) (*lavalink.LoadResult, error) {
+	node := c.Client.BestNode()
+	if node == nil {
+		return nil, ErrNoNode
+	}
+
+	return node.LoadTracks(ctx, identifier)
+}
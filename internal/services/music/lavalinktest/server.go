@@ -0,0 +1,616 @@
+// Package lavalinktest provides an in-process Lavalink node for tests.
+//
+// The node speaks enough of Lavalink's v4 REST and websocket protocol for disgolink to
+// connect to it, load canned tracks, play them and resume its session after reconnecting. Nothing is actually played: tracks
+// start as soon as they're sent to a player, and only end, fail or get stuck when a test says so.
+package lavalinktest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/disgoorg/disgolink/v3/disgolink"
+	"github.com/disgoorg/disgolink/v3/lavalink"
+	"github.com/disgoorg/snowflake/v2"
+	"github.com/gorilla/websocket"
+)
+
+// Password is what clients have to authorize with.
+const Password = "youshallnotpass"
+
+var (
+	ErrNotConnected = errors.New("no client is connected")
+	ErrNotPlaying   = errors.New("player is not playing anything")
+)
+
+// Server is a fake Lavalink node.
+type Server struct {
+	server    *httptest.Server
+	upgrader  websocket.Upgrader
+	sessionID string
+
+	mu       sync.Mutex
+	conn     *websocket.Conn
+	resuming bool                           // Whether players are kept for the next client resuming the session.
+	results  map[string]lavalink.LoadResult // Load results by identifier.
+	loads    []string                       // Every identifier loaded, in the order they were requested.
+	held     chan struct{}                  // Loads wait until it's closed, if it's set.
+	tracks   map[string]lavalink.Track      // Every track that can be loaded, by its encoded form.
+	players  map[snowflake.ID]*lavalink.Player
+	changed  chan struct{} // Closed and replaced whenever a player starts or stops a track.
+}
+
+// NewServer starts a fake Lavalink node. It should be closed once the test is done with it.
+func NewServer() *Server {
+	s := &Server{
+		sessionID: "lavalinktest",
+
+		results: make(map[string]lavalink.LoadResult),
+		tracks:  make(map[string]lavalink.Track),
+		players: make(map[snowflake.ID]*lavalink.Player),
+		changed: make(chan struct{}),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v4/websocket", s.handleWebSocket)
+	mux.HandleFunc("/v4/loadtracks", s.handleLoadTracks)
+	mux.HandleFunc("/v4/sessions/", s.handleSessions)
+
+	s.server = httptest.NewServer(s.authorize(mux))
+
+	return s
+}
+
+// Close disconnects the client and stops the node.
+func (s *Server) Close() {
+	s.mu.Lock()
+	if s.conn != nil {
+		s.conn.Close()
+		s.conn = nil
+	}
+	s.mu.Unlock()
+
+	s.server.Close()
+}
+
+// NodeConfig returns the config to add the node to a disgolink client with.
+func (s *Server) NodeConfig() disgolink.NodeConfig {
+	return disgolink.NodeConfig{
+		Name:     "lavalinktest",
+		Address:  strings.TrimPrefix(s.server.URL, "http://"),
+		Password: Password,
+	}
+}
+
+// NewTrack returns a track as a node would load it, which can be played once it's added to a load result.
+func NewTrack(identifier string, title string, length time.Duration) lavalink.Track {
+	uri := fmt.Sprintf("https://lavalinktest.invalid/%s", identifier)
+
+	return lavalink.Track{
+		Encoded: "encoded:" + identifier,
+		Info: lavalink.TrackInfo{
+			Identifier: identifier,
+			Title:      title,
+			Author:     "lavalinktest",
+			Length:     lavalink.Duration(length.Milliseconds()),
+			URI:        &uri,
+			SourceName: "http",
+		},
+	}
+}
+
+// AddTrack makes identifier load a single track.
+func (s *Server) AddTrack(identifier string, track lavalink.Track) {
+	s.SetLoadResult(identifier, lavalink.LoadResult{LoadType: lavalink.LoadTypeTrack, Data: track})
+}
+
+// AddSearch makes identifier load search results.
+func (s *Server) AddSearch(identifier string, tracks ...lavalink.Track) {
+	s.SetLoadResult(identifier, lavalink.LoadResult{LoadType: lavalink.LoadTypeSearch, Data: lavalink.Search(tracks)})
+}
+
+// AddPlaylist makes identifier load a playlist.
+func (s *Server) AddPlaylist(identifier string, name string, tracks ...lavalink.Track) {
+	s.SetLoadResult(identifier, lavalink.LoadResult{
+		LoadType: lavalink.LoadTypePlaylist,
+		Data:     lavalink.Playlist{Info: lavalink.PlaylistInfo{Name: name, SelectedTrack: -1}, Tracks: tracks},
+	})
+}
+
+// SetLoadResult makes identifier load result. Identifiers without one load nothing.
+func (s *Server) SetLoadResult(identifier string, result lavalink.LoadResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.results[identifier] = result
+
+	switch data := result.Data.(type) {
+	case lavalink.Track:
+		s.tracks[data.Encoded] = data
+	case lavalink.Search:
+		for _, track := range data {
+			s.tracks[track.Encoded] = track
+		}
+	case lavalink.Playlist:
+		for _, track := range data.Tracks {
+			s.tracks[track.Encoded] = track
+		}
+	}
+}
+
+// HoldLoads makes loading tracks wait until release is called, or the client gives up on it.
+func (s *Server) HoldLoads() (release func()) {
+	held := make(chan struct{})
+
+	s.mu.Lock()
+	s.held = held
+	s.mu.Unlock()
+
+	var once sync.Once
+
+	return func() {
+		once.Do(func() {
+			s.mu.Lock()
+			s.held = nil
+			s.mu.Unlock()
+
+			close(held)
+		})
+	}
+}
+
+// Loads returns every identifier loaded so far, in the order they were requested.
+func (s *Server) Loads() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string(nil), s.loads...)
+}
+
+// PlayingTrack returns the track a guild's player is playing, if any.
+func (s *Server) PlayingTrack(guildID snowflake.ID) *lavalink.Track {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	player, exists := s.players[guildID]
+	if !exists || player.Track == nil {
+		return nil
+	}
+
+	track := *player.Track
+	return &track
+}
+
+// HasPlayer returns whether the node has a player for a guild, playing or not.
+func (s *Server) HasPlayer(guildID snowflake.ID) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, exists := s.players[guildID]
+
+	return exists
+}
+
+// PlayerFilters returns the filters a guild's player applies.
+func (s *Server) PlayerFilters(guildID snowflake.ID) lavalink.Filters {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	player, exists := s.players[guildID]
+	if !exists {
+		return lavalink.Filters{}
+	}
+
+	return player.Filters
+}
+
+// WaitForTrack waits until a guild's player plays the track with identifier,
+// or stops playing if identifier is empty.
+func (s *Server) WaitForTrack(guildID snowflake.ID, identifier string, timeout time.Duration) error {
+	deadline := time.After(timeout)
+
+	for {
+		s.mu.Lock()
+		changed := s.changed
+
+		playing := ""
+		if player, exists := s.players[guildID]; exists && player.Track != nil {
+			playing = player.Track.Info.Identifier
+		}
+		s.mu.Unlock()
+
+		if playing == identifier {
+			return nil
+		}
+
+		select {
+		case <-changed:
+		case <-deadline:
+			return fmt.Errorf("player is playing %q instead of %q", playing, identifier)
+		}
+	}
+}
+
+// FinishTrack ends the track a guild's player is playing, as if it played until the end.
+func (s *Server) FinishTrack(guildID snowflake.ID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	track, err := s.endTrack(guildID)
+	if err != nil {
+		return err
+	}
+
+	return s.send(lavalink.EventTypeTrackEnd, lavalink.TrackEndEvent{Track: track, Reason: lavalink.TrackEndReasonFinished, GuildID_: guildID})
+}
+
+// FailTrack makes the track a guild's player is playing throw an exception, which ends it.
+func (s *Server) FailTrack(guildID snowflake.ID, message string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	track, err := s.endTrack(guildID)
+	if err != nil {
+		return err
+	}
+
+	exception := lavalink.Exception{Message: message, Severity: lavalink.SeverityCommon}
+
+	err = s.send(lavalink.EventTypeTrackException, lavalink.TrackExceptionEvent{Track: track, Exception: exception, GuildID_: guildID})
+	if err != nil {
+		return err
+	}
+
+	return s.send(lavalink.EventTypeTrackEnd, lavalink.TrackEndEvent{Track: track, Reason: lavalink.TrackEndReasonLoadFailed, GuildID_: guildID})
+}
+
+// StickTrack reports the track a guild's player is playing as stuck. It keeps playing.
+func (s *Server) StickTrack(guildID snowflake.ID, threshold time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	player, exists := s.players[guildID]
+	if !exists || player.Track == nil {
+		return ErrNotPlaying
+	}
+
+	return s.send(lavalink.EventTypeTrackStuck, lavalink.TrackStuckEvent{
+		Track:     *player.Track,
+		Threshold: lavalink.Duration(threshold.Milliseconds()),
+		GuildID_:  guildID,
+	})
+}
+
+// endTrack removes the track from a guild's player and returns it.
+func (s *Server) endTrack(guildID snowflake.ID) (lavalink.Track, error) {
+	player, exists := s.players[guildID]
+	if !exists || player.Track == nil {
+		return lavalink.Track{}, ErrNotPlaying
+	}
+
+	track := *player.Track
+	s.setTrack(player, nil)
+
+	return track, nil
+}
+
+// setTrack changes what a player plays and wakes up everyone waiting for it.
+func (s *Server) setTrack(player *lavalink.Player, track *lavalink.Track) {
+	player.Track = track
+	player.State = lavalink.PlayerState{Time: lavalink.Now(), Connected: true}
+
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// send writes an event to the connected client.
+func (s *Server) send(eventType lavalink.EventType, event lavalink.Event) error {
+	if s.conn == nil {
+		return ErrNotConnected
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	// Events are sent with their op and type alongside their own fields.
+	var message map[string]any
+	if err := json.Unmarshal(data, &message); err != nil {
+		return err
+	}
+
+	message["op"] = lavalink.OpEvent
+	message["type"] = eventType
+
+	return s.conn.WriteJSON(message)
+}
+
+func (s *Server) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != Password {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+
+	// A previous connection is left open: clients reconnect when the node closes their connection,
+	// which would take the session back from the client that just resumed it. Events go to the newest one.
+	s.mu.Lock()
+	s.conn = conn
+
+	// Without resuming, the previous session and its players are gone.
+	resumed := s.resuming && r.Header.Get("Session-Id") == s.sessionID
+	if !resumed {
+		s.resuming = false
+		s.players = make(map[snowflake.ID]*lavalink.Player)
+	}
+
+	err = conn.WriteJSON(map[string]any{
+		"op":        lavalink.OpReady,
+		"resumed":   resumed,
+		"sessionId": s.sessionID,
+	})
+	s.mu.Unlock()
+
+	if err != nil {
+		conn.Close()
+		return
+	}
+
+	// Clients never send anything, but reading notices when they disconnect.
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			break
+		}
+	}
+
+	s.mu.Lock()
+	if s.conn == conn {
+		s.conn = nil
+	}
+	s.mu.Unlock()
+}
+
+func (s *Server) handleLoadTracks(w http.ResponseWriter, r *http.Request) {
+	identifier := r.URL.Query().Get("identifier")
+
+	s.mu.Lock()
+	result, exists := s.results[identifier]
+	s.loads = append(s.loads, identifier)
+	held := s.held
+	s.mu.Unlock()
+
+	if held != nil {
+		select {
+		case <-held:
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	if !exists {
+		result = lavalink.LoadResult{LoadType: lavalink.LoadTypeEmpty, Data: lavalink.Empty{}}
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// playerUpdate is the body of a player update, keeping a null track apart from a missing one.
+type playerUpdate struct {
+	Track *struct {
+		Encoded  json.RawMessage `json:"encoded"`
+		UserData json.RawMessage `json:"userData"`
+	} `json:"track"`
+	Position *lavalink.Duration   `json:"position"`
+	Volume   *int                 `json:"volume"`
+	Paused   *bool                `json:"paused"`
+	Filters  *lavalink.Filters    `json:"filters"`
+	Voice    *lavalink.VoiceState `json:"voice"`
+}
+
+// handleSessions serves /v4/sessions/{sessionId}, to configure resuming, /v4/sessions/{sessionId}/players,
+// to list players after resuming, and /v4/sessions/{sessionId}/players/{guildId}, which sessions play through.
+func (s *Server) handleSessions(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/v4/sessions/"), "/")
+	if parts[0] != s.sessionID {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	switch {
+	case len(parts) == 1 && r.Method == http.MethodPatch:
+		s.handleSessionUpdate(w, r)
+		return
+	case len(parts) == 2 && parts[1] == "players" && r.Method == http.MethodGet:
+		s.mu.Lock()
+		players := make([]lavalink.Player, 0, len(s.players))
+		for _, player := range s.players {
+			players = append(players, *player)
+		}
+		s.mu.Unlock()
+
+		writeJSON(w, http.StatusOK, players)
+		return
+	case len(parts) != 3 || parts[1] != "players":
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	guildID, err := snowflake.Parse(parts[2])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid guild ID")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		s.mu.Lock()
+		player := s.player(guildID)
+		writeJSON(w, http.StatusOK, player)
+		s.mu.Unlock()
+
+	case http.MethodPatch:
+		var update playerUpdate
+		if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		player, status, err := s.updatePlayer(guildID, update, r.URL.Query().Get("noReplace") == "true")
+		if err != nil {
+			writeError(w, status, err.Error())
+			return
+		}
+
+		writeJSON(w, http.StatusOK, player)
+
+	case http.MethodDelete:
+		s.mu.Lock()
+		delete(s.players, guildID)
+		s.mu.Unlock()
+
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (s *Server) handleSessionUpdate(w http.ResponseWriter, r *http.Request) {
+	var update lavalink.SessionUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if update.Resuming != nil {
+		s.resuming = *update.Resuming
+	}
+
+	writeJSON(w, http.StatusOK, lavalink.Session{Resuming: s.resuming})
+}
+
+// player returns a guild's player, creating it if needed.
+func (s *Server) player(guildID snowflake.ID) *lavalink.Player {
+	player, exists := s.players[guildID]
+	if !exists {
+		player = &lavalink.Player{GuildID: guildID, Volume: 100}
+		s.players[guildID] = player
+	}
+
+	return player
+}
+
+// updatePlayer applies an update to a guild's player, sending the events Lavalink would for it.
+func (s *Server) updatePlayer(guildID snowflake.ID, update playerUpdate, noReplace bool) (*lavalink.Player, int, error) {
+	player := s.player(guildID)
+
+	if update.Track != nil && update.Track.Encoded != nil {
+		var encoded *string
+		if err := json.Unmarshal(update.Track.Encoded, &encoded); err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+
+		var next *lavalink.Track
+		if encoded != nil {
+			track, exists := s.tracks[*encoded]
+			if !exists {
+				return nil, http.StatusBadRequest, fmt.Errorf("unknown track %q", *encoded)
+			}
+
+			track.UserData = lavalink.RawData(update.Track.UserData)
+			next = &track
+		}
+
+		if !(noReplace && player.Track != nil && next != nil) {
+			if err := s.replaceTrack(player, next); err != nil {
+				return nil, http.StatusInternalServerError, err
+			}
+		}
+	}
+
+	if update.Position != nil && player.Track != nil {
+		player.State.Position = *update.Position
+		player.State.Time = lavalink.Now()
+	}
+
+	if update.Volume != nil {
+		player.Volume = *update.Volume
+	}
+
+	if update.Paused != nil {
+		player.Paused = *update.Paused
+	}
+
+	if update.Filters != nil {
+		player.Filters = *update.Filters
+	}
+
+	if update.Voice != nil {
+		player.Voice = *update.Voice
+	}
+
+	return player, http.StatusOK, nil
+}
+
+// replaceTrack ends the player's track, if any, and starts next, if any.
+func (s *Server) replaceTrack(player *lavalink.Player, next *lavalink.Track) error {
+	previous := player.Track
+	s.setTrack(player, next)
+
+	if previous != nil {
+		reason := lavalink.TrackEndReasonReplaced
+		if next == nil {
+			reason = lavalink.TrackEndReasonStopped
+		}
+
+		err := s.send(lavalink.EventTypeTrackEnd, lavalink.TrackEndEvent{Track: *previous, Reason: reason, GuildID_: player.GuildID})
+		if err != nil {
+			return err
+		}
+	}
+
+	if next != nil {
+		return s.send(lavalink.EventTypeTrackStart, lavalink.TrackStartEvent{Track: *next, GuildID_: player.GuildID})
+	}
+
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]any{
+		"timestamp": time.Now().UnixMilli(),
+		"status":    status,
+		"error":     http.StatusText(status),
+		"message":   message,
+		"path":      "",
+	})
+}
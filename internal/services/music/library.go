@@ -0,0 +1,435 @@
+package music
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/dhowden/tag"
+	"github.com/disgoorg/disgolink/v3/lavalink"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	// LibraryPrefix makes a query look for tracks in the local library, like "library:jingle".
+	LibraryPrefix = "library:"
+
+	// LibrarySource is the source of tracks requested from the local library.
+	LibrarySource = "library"
+
+	// LibraryScanInterval is how often the library looks for files that were added, changed or removed.
+	LibraryScanInterval = time.Minute
+
+	// LibrarySearchLimit is how many tracks a library search returns at most, which is as many choices as autocomplete shows.
+	LibrarySearchLimit = 25
+)
+
+var ErrNoLibrary = errors.New("no local library is configured")
+
+// libraryExtensions are the kinds of audio files Lavalink's local source can play.
+var libraryExtensions = map[string]bool{
+	".mp3":  true,
+	".flac": true,
+	".ogg":  true,
+	".opus": true,
+	".m4a":  true,
+	".mp4":  true,
+	".aac":  true,
+	".wav":  true,
+	".webm": true,
+	".mka":  true,
+	".mkv":  true,
+}
+
+// LibraryEntry is an audio file in the local library.
+type LibraryEntry struct {
+	ID     string // Short and stable, for autocomplete choices, which can't hold long paths.
+	Path   string // Relative to the library's directory, with forward slashes.
+	Title  string // From the file's tags, or its name if it has none.
+	Artist string
+	Album  string
+	Length lavalink.Duration
+	Track  lavalink.Track // The file as loaded by Lavalink, empty if it couldn't be.
+
+	size    int64
+	modTime time.Time
+	search  string // What searches match against.
+}
+
+// String describes the entry for lists and choices.
+func (e LibraryEntry) String() string {
+	if e.Artist == "" {
+		return e.Title
+	}
+
+	return fmt.Sprintf("%s - %s", e.Artist, e.Title)
+}
+
+func (e LibraryEntry) playable() bool {
+	return e.Track.Encoded != ""
+}
+
+// Library is an index of the audio files in a directory, played through Lavalink's local source.
+type Library struct {
+	dir         string // Where the files are for the bot.
+	lavalinkDir string // Where the same files are for Lavalink, which may run on another host or container.
+	node        Node
+
+	mu      sync.RWMutex
+	files   map[string]LibraryEntry // Every indexed file by path, including ones that can't be played.
+	entries []LibraryEntry          // The playable files, sorted by path.
+	ids     map[string]int          // Indexes into entries by ID.
+}
+
+// NewLibrary creates an empty library of the files in dir. Lavalink reads them from lavalinkDir,
+// or from dir if it's empty. The library needs to be scanned before it has any entries.
+func NewLibrary(dir string, lavalinkDir string, node Node) *Library {
+	if lavalinkDir == "" {
+		lavalinkDir = dir
+	}
+
+	return &Library{
+		dir:         dir,
+		lavalinkDir: lavalinkDir,
+		node:        node,
+
+		files: make(map[string]LibraryEntry),
+		ids:   make(map[string]int),
+	}
+}
+
+// Watch scans the library right away, then again every interval until ctx is cancelled,
+// so the index follows the files being added, changed or removed.
+func (l *Library) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := l.Scan(ctx); err != nil && ctx.Err() == nil {
+			slog.Error("Failed to scan music library.", slog.String("dir", l.dir), slog.String("error", err.Error()))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Scan updates the index with the files in the library's directory. Only new and changed files
+// are read and loaded through Lavalink. If scanning fails, the index is left as it was.
+func (l *Library) Scan(ctx context.Context) error {
+	l.mu.RLock()
+	previous := l.files
+	l.mu.RUnlock()
+
+	files := make(map[string]LibraryEntry, len(previous))
+	changed := false
+
+	err := filepath.WalkDir(l.dir, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// A directory we can't read shouldn't take the rest of the library with it.
+			if d != nil && d.IsDir() && file != l.dir {
+				slog.Warn("Failed to read music library directory.", slog.String("dir", file), slog.String("error", err.Error()))
+				return fs.SkipDir
+			}
+
+			return err
+		}
+
+		if d.IsDir() || !libraryExtensions[strings.ToLower(filepath.Ext(file))] {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			// The file was removed while scanning.
+			return nil
+		}
+
+		rel, err := filepath.Rel(l.dir, file)
+		if err != nil {
+			return err
+		}
+
+		rel = filepath.ToSlash(rel)
+
+		if entry, ok := previous[rel]; ok && entry.size == info.Size() && entry.modTime.Equal(info.ModTime()) {
+			files[rel] = entry
+			return nil
+		}
+
+		entry, err := l.index(ctx, file, rel, info)
+		if err != nil {
+			return err
+		}
+
+		files[rel] = entry
+		changed = true
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if !changed && len(files) == len(previous) {
+		return nil
+	}
+
+	entries := make([]LibraryEntry, 0, len(files))
+	for _, entry := range files {
+		if entry.playable() {
+			entries = append(entries, entry)
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Path < entries[j].Path
+	})
+
+	ids := make(map[string]int, len(entries))
+	for i, entry := range entries {
+		ids[entry.ID] = i
+	}
+
+	l.mu.Lock()
+	l.files, l.entries, l.ids = files, entries, ids
+	l.mu.Unlock()
+
+	slog.Info(fmt.Sprintf("Indexed %d tracks in the music library.", len(entries)), slog.String("dir", l.dir))
+
+	return nil
+}
+
+// index reads a file's tags and loads it through Lavalink. Files Lavalink can't play are
+// indexed without a track, so they aren't tried again until they change.
+func (l *Library) index(ctx context.Context, file string, rel string, info fs.FileInfo) (LibraryEntry, error) {
+	entry := LibraryEntry{
+		ID:   libraryID(rel),
+		Path: rel,
+
+		size:    info.Size(),
+		modTime: info.ModTime(),
+	}
+
+	result, err := l.node.LoadTracks(ctx, path.Join(filepath.ToSlash(l.lavalinkDir), rel))
+	if err != nil {
+		return entry, err
+	}
+
+	switch data := result.Data.(type) {
+	case lavalink.Track:
+		entry.Track = data
+		entry.Length = data.Info.Length
+	case lavalink.Exception:
+		slog.Warn("Lavalink can't play a file in the music library.", slog.String("path", rel), slog.String("error", data.Message))
+		return entry, nil
+	default:
+		slog.Warn("Lavalink can't play a file in the music library.", slog.String("path", rel))
+		return entry, nil
+	}
+
+	title, artist, album := readTags(file)
+
+	// Untagged files are named after what's in them, hopefully.
+	if title == "" {
+		title = strings.TrimSuffix(path.Base(rel), path.Ext(rel))
+	}
+
+	entry.Title, entry.Artist, entry.Album = title, artist, album
+	entry.search = normalizeSearch(strings.Join([]string{title, artist, album, rel}, " "))
+
+	return entry, nil
+}
+
+// readTags reads the title, artist and album from an audio file's tags, if it has any.
+func readTags(file string) (title string, artist string, album string) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", "", ""
+	}
+	defer f.Close()
+
+	metadata, err := tag.ReadFrom(f)
+	if err != nil {
+		return "", "", ""
+	}
+
+	return strings.TrimSpace(metadata.Title()), strings.TrimSpace(metadata.Artist()), strings.TrimSpace(metadata.Album())
+}
+
+func libraryID(rel string) string {
+	sum := sha1.Sum([]byte(rel))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Len returns how many playable tracks the library has.
+func (l *Library) Len() int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return len(l.entries)
+}
+
+// Lookup finds a track by its ID.
+func (l *Library) Lookup(id string) (LibraryEntry, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	i, ok := l.ids[id]
+	if !ok {
+		return LibraryEntry{}, false
+	}
+
+	return l.entries[i], true
+}
+
+// Search finds up to limit tracks matching query, best matches first.
+// Every word of the query has to be in a track's title, artist, album or path,
+// or at least have its letters in order in one of their words, so words with letters left out still match.
+// An empty query lists the library from the start.
+func (l *Library) Search(query string, limit int) []LibraryEntry {
+	words := strings.Fields(normalizeSearch(query))
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if len(words) == 0 {
+		return append([]LibraryEntry(nil), l.entries[:min(limit, len(l.entries))]...)
+	}
+
+	type match struct {
+		entry LibraryEntry
+		score int
+	}
+
+	matches := make([]match, 0)
+	for _, entry := range l.entries {
+		if score := fuzzyScore(entry.search, words); score > 0 {
+			matches = append(matches, match{entry, score})
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+
+		// Among equally good matches, the query is more of the shorter ones.
+		if len(matches[i].entry.search) != len(matches[j].entry.search) {
+			return len(matches[i].entry.search) < len(matches[j].entry.search)
+		}
+
+		return matches[i].entry.Path < matches[j].entry.Path
+	})
+
+	results := make([]LibraryEntry, 0, min(limit, len(matches)))
+	for _, m := range matches[:min(limit, len(matches))] {
+		results = append(results, m.entry)
+	}
+
+	return results
+}
+
+// Load resolves a query for the library into a track, the way LoadTracks does for other sources.
+// The query is either an entry's ID, as autocomplete passes it, or what to search for.
+func (l *Library) Load(query string) (*lavalink.LoadResult, error) {
+	entry, ok := l.Lookup(query)
+	if !ok {
+		results := l.Search(query, 1)
+		if len(results) == 0 {
+			return nil, ErrNoSearchResults
+		}
+
+		entry = results[0]
+	}
+
+	return &lavalink.LoadResult{LoadType: lavalink.LoadTypeTrack, Data: entry.Track}, nil
+}
+
+// fuzzyScore rates how well every word of a query matches text, which is 0 if any of them doesn't.
+// Words found at the start of a word in text rate highest, then words found anywhere,
+// then words whose letters are only found in order.
+func fuzzyScore(text string, words []string) int {
+	score := 0
+
+	for _, word := range words {
+		i := strings.Index(text, word)
+
+		switch {
+		case i == 0 || i > 0 && text[i-1] == ' ':
+			score += 3
+		case i > 0:
+			score += 2
+		case isSubsequence(text, word):
+			score += 1
+		default:
+			return 0
+		}
+	}
+
+	return score
+}
+
+// isSubsequence returns whether the letters of word are all in one of the words of text, in order,
+// starting with its first letter. Letters spread over several words match too much to be useful.
+func isSubsequence(text string, word string) bool {
+	for _, candidate := range strings.Fields(text) {
+		letters := []rune(word)
+		if []rune(candidate)[0] != letters[0] {
+			continue
+		}
+
+		for _, r := range candidate {
+			if len(letters) > 0 && r == letters[0] {
+				letters = letters[1:]
+			}
+		}
+
+		if len(letters) == 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// normalizeSearch lowercases text and strips it of accents and punctuation, so searches don't have to be exact.
+func normalizeSearch(text string) string {
+	folded, _, err := transform.String(transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC), text)
+	if err != nil {
+		folded = text
+	}
+
+	return strings.Join(strings.FieldsFunc(strings.ToLower(folded), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}), " ")
+}
+
+// OpenLibrary indexes the audio files in dir and keeps the index up to date until the service closes.
+// Lavalink plays them from lavalinkDir, where the same files are on its host, or from dir if it's empty.
+func (s *MusicService) OpenLibrary(dir string, lavalinkDir string) {
+	s.library = NewLibrary(dir, lavalinkDir, s.lavalink)
+	go s.library.Watch(s.ctx, LibraryScanInterval)
+}
+
+// Library returns the local library, or nil if none was opened.
+func (s *MusicService) Library() *Library {
+	return s.library
+}
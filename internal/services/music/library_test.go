@@ -0,0 +1,168 @@
+package music
+
+import (
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+
+	"github.com/disgoorg/disgolink/v3/lavalink"
+)
+
+// fakeNode loads the tracks it knows by identifier, and nothing else.
+type fakeNode map[string]lavalink.Track
+
+func (n fakeNode) LoadTracks(ctx context.Context, identifier string) (*lavalink.LoadResult, error) {
+	track, ok := n[identifier]
+	if !ok {
+		return &lavalink.LoadResult{LoadType: lavalink.LoadTypeEmpty, Data: lavalink.Empty{}}, nil
+	}
+
+	return &lavalink.LoadResult{LoadType: lavalink.LoadTypeTrack, Data: track}, nil
+}
+
+// newTestLibrary creates a library of files, which Lavalink can play unless they're listed in unplayable.
+func newTestLibrary(t *testing.T, files []string, unplayable ...string) (*Library, string, fakeNode) {
+	t.Helper()
+
+	dir := t.TempDir()
+	node := fakeNode{}
+
+	for _, file := range files {
+		writeLibraryFile(t, dir, node, file)
+	}
+
+	for _, file := range unplayable {
+		delete(node, path.Join("/lavalink", file))
+	}
+
+	return NewLibrary(dir, "/lavalink", node), dir, node
+}
+
+func writeLibraryFile(t *testing.T, dir string, node fakeNode, file string) {
+	t.Helper()
+
+	full := filepath.Join(dir, filepath.FromSlash(file))
+	if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(full, []byte("not really audio"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	node[path.Join("/lavalink", file)] = *testTrack(file)
+}
+
+func TestLibraryScan(t *testing.T) {
+	library, dir, node := newTestLibrary(t, []string{
+		"jingles/Intro Jingle.mp3",
+		"recordings/Live at the Café.flac",
+		"notes.txt",
+		"broken.ogg",
+	}, "broken.ogg")
+
+	if err := library.Scan(context.Background()); err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+
+	// Text files aren't audio, and Lavalink can't play the broken file.
+	if library.Len() != 2 {
+		t.Fatalf("expected 2 tracks, got %d", library.Len())
+	}
+
+	entries := library.Search("", LibrarySearchLimit)
+	if entries[0].Path != "jingles/Intro Jingle.mp3" || entries[0].Title != "Intro Jingle" {
+		t.Fatalf("expected the untagged jingle to be named after its file, got %+v", entries[0])
+	}
+
+	if entries[0].Track.Encoded != "jingles/Intro Jingle.mp3" {
+		t.Fatalf("expected the jingle to be loaded from Lavalink's directory, got %+v", entries[0].Track)
+	}
+
+	writeLibraryFile(t, dir, node, "jingles/Outro Jingle.mp3")
+	if err := os.Remove(filepath.Join(dir, "recordings", "Live at the Café.flac")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := library.Scan(context.Background()); err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+
+	entries = library.Search("", LibrarySearchLimit)
+	if len(entries) != 2 || entries[0].Title != "Intro Jingle" || entries[1].Title != "Outro Jingle" {
+		t.Fatalf("expected the index to follow the files, got %+v", entries)
+	}
+}
+
+func TestLibrarySearch(t *testing.T) {
+	library, _, _ := newTestLibrary(t, []string{
+		"jingles/Intro Jingle.mp3",
+		"jingles/Outro Jingle.mp3",
+		"recordings/Live at the Café.flac",
+		"recordings/Rehearsal.mp3",
+	})
+
+	if err := library.Scan(context.Background()); err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"intro", []string{"Intro Jingle"}},
+		{"jingle", []string{"Intro Jingle", "Outro Jingle"}},
+		{"cafe", []string{"Live at the Café"}},            // Accents don't matter.
+		{"rhrsl", []string{"Rehearsal"}},                  // Nor do left out letters.
+		{"recordings live", []string{"Live at the Café"}}, // Every word has to match.
+		{"nothing like this", nil},
+	}
+
+	for _, test := range tests {
+		entries := library.Search(test.query, LibrarySearchLimit)
+
+		titles := make([]string, len(entries))
+		for i, entry := range entries {
+			titles[i] = entry.Title
+		}
+
+		if len(titles) != len(test.want) {
+			t.Errorf("Search(%q) = %v, want %v", test.query, titles, test.want)
+			continue
+		}
+
+		for i := range titles {
+			if titles[i] != test.want[i] {
+				t.Errorf("Search(%q) = %v, want %v", test.query, titles, test.want)
+				break
+			}
+		}
+	}
+}
+
+func TestLibraryLoad(t *testing.T) {
+	library, _, _ := newTestLibrary(t, []string{"jingles/Intro Jingle.mp3", "jingles/Outro Jingle.mp3"})
+
+	if err := library.Scan(context.Background()); err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+
+	outro := library.Search("outro", 1)[0]
+
+	for _, query := range []string{outro.ID, "outro jingle"} {
+		result, err := library.Load(query)
+		if err != nil {
+			t.Fatalf("Load(%q): %v", query, err)
+		}
+
+		if track, ok := result.Data.(lavalink.Track); !ok || track.Encoded != outro.Track.Encoded {
+			t.Fatalf("Load(%q) = %+v, want %q", query, result.Data, outro.Track.Encoded)
+		}
+	}
+
+	if _, err := library.Load("missing"); err != ErrNoSearchResults {
+		t.Fatalf("expected ErrNoSearchResults, got %v", err)
+	}
+}
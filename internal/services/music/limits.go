@@ -0,0 +1,97 @@
+package music
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/disgoorg/disgolink/v3/lavalink"
+
+	"unreal.sh/neo/internal/database"
+	"unreal.sh/neo/internal/utils/datetime"
+)
+
+// RecentHistorySize is how many recently started tracks are checked for duplicates.
+const RecentHistorySize = 10
+
+// admit checks a track against the guild's music settings,
+// returning why it can't be played or queued, if it can't.
+// Queue length limits are checked by enqueue, since they don't apply to a track played right away.
+func (s *MusicSession) admit(track *lavalink.Track) error {
+	if track.Info.IsStream {
+		if s.settings.BlockStreams {
+			return ErrStreamBlocked
+		}
+	} else if limit := MaxTrackLength(s.settings); limit > 0 && track.Info.Length > limit {
+		return ErrTrackTooLong
+	}
+
+	if s.settings.RejectDuplicates && s.isDuplicate(track) {
+		return ErrDuplicateTrack
+	}
+
+	return nil
+}
+
+// MaxTrackLength returns the longest track that can be queued, or zero if there's no limit.
+func MaxTrackLength(settings database.MusicSettings) lavalink.Duration {
+	return lavalink.Duration((time.Duration(settings.MaxTrackLength) * time.Second).Milliseconds())
+}
+
+// isDuplicate returns whether a track is playing, queued or among the recently started ones.
+func (s *MusicSession) isDuplicate(track *lavalink.Track) bool {
+	key := trackKey(track)
+
+	if s.current != nil && trackKey(s.current) == key {
+		return true
+	}
+
+	for _, queued := range s.queue {
+		if trackKey(queued) == key {
+			return true
+		}
+	}
+
+	for _, recent := range s.recent {
+		if recent == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+// remember records a started track for duplicate detection.
+func (s *MusicSession) remember(track *lavalink.Track) {
+	s.recent = append(s.recent, trackKey(track))
+
+	if len(s.recent) > RecentHistorySize {
+		s.recent = s.recent[len(s.recent)-RecentHistorySize:]
+	}
+}
+
+// trackKey identifies a track regardless of how it was loaded,
+// since the same song found twice may have different encoded data.
+func trackKey(track *lavalink.Track) string {
+	return track.Info.SourceName + ":" + track.Info.Identifier
+}
+
+// RejectionMessage explains why a session refused to enqueue a track,
+// or returns an empty string if err isn't a rejection.
+func RejectionMessage(settings database.MusicSettings, err error) string {
+	switch {
+	case errors.Is(err, ErrUserQueueFull):
+		return fmt.Sprintf("You already have %d tracks queued, which is this server's limit.", settings.MaxUserQueue)
+	case errors.Is(err, ErrQueueFull):
+		return fmt.Sprintf("The queue already has %d tracks, which is this server's limit.", settings.MaxQueueLength)
+	case errors.Is(err, ErrTrackTooLong):
+		return fmt.Sprintf("That track is too long. This server only allows tracks up to %s.",
+			datetime.Pretty(datetime.ToDuration(MaxTrackLength(settings))))
+	case errors.Is(err, ErrDuplicateTrack):
+		return "That track is already in the queue or was played recently."
+	case errors.Is(err, ErrStreamBlocked):
+		return "This server doesn't allow livestreams."
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,110 @@
+package music
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/disgoorg/disgolink/v3/lavalink"
+
+	"unreal.sh/neo/internal/database"
+)
+
+func TestAdmit(t *testing.T) {
+	long := testTrack("long")
+	long.Info.Length = lavalink.Duration((11 * time.Minute).Milliseconds())
+
+	stream := testTrack("stream")
+	stream.Info.IsStream = true
+	stream.Info.Length = 0
+
+	// The same song loaded twice, with different encoded data.
+	queued := testTrack("queued")
+	reloaded := testTrack("queued")
+	reloaded.Encoded = "reloaded"
+
+	otherSource := testTrack("queued")
+	otherSource.Info.SourceName = "other"
+
+	tests := []struct {
+		name     string
+		settings database.MusicSettings
+		track    *lavalink.Track
+		expected error
+	}{
+		{"no limits", database.MusicSettings{}, long, nil},
+		{"too long", database.MusicSettings{MaxTrackLength: 600}, long, ErrTrackTooLong},
+		{"short enough", database.MusicSettings{MaxTrackLength: 600}, testTrack("short"), nil},
+		{"streams aren't too long", database.MusicSettings{MaxTrackLength: 600}, stream, nil},
+		{"blocked stream", database.MusicSettings{BlockStreams: true}, stream, ErrStreamBlocked},
+		{"duplicates allowed", database.MusicSettings{}, reloaded, nil},
+		{"queued duplicate", database.MusicSettings{RejectDuplicates: true}, reloaded, ErrDuplicateTrack},
+		{"playing duplicate", database.MusicSettings{RejectDuplicates: true}, testTrack("current"), ErrDuplicateTrack},
+		{"recent duplicate", database.MusicSettings{RejectDuplicates: true}, testTrack("recent"), ErrDuplicateTrack},
+		{"same identifier elsewhere", database.MusicSettings{RejectDuplicates: true}, otherSource, nil},
+	}
+
+	for _, test := range tests {
+		session := &MusicSession{
+			settings: test.settings,
+			current:  testTrack("current"),
+			queue:    []*lavalink.Track{queued},
+			data:     []TrackRequestData{{}},
+		}
+		session.remember(testTrack("recent"))
+
+		if err := session.admit(test.track); !errors.Is(err, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, err)
+		}
+	}
+}
+
+func TestRememberForgetsOldTracks(t *testing.T) {
+	session := &MusicSession{settings: database.MusicSettings{RejectDuplicates: true}}
+
+	for i := 0; i < RecentHistorySize+2; i++ {
+		session.remember(testTrack(fmt.Sprintf("track %d", i)))
+	}
+
+	if len(session.recent) != RecentHistorySize {
+		t.Fatalf("expected %d recent tracks, got %d", RecentHistorySize, len(session.recent))
+	}
+
+	if err := session.admit(testTrack("track 1")); err != nil {
+		t.Fatalf("expected a track played long ago to be admitted, got %v", err)
+	}
+
+	if err := session.admit(testTrack("track 2")); !errors.Is(err, ErrDuplicateTrack) {
+		t.Fatalf("expected a recent track to be a duplicate, got %v", err)
+	}
+}
+
+func TestRejectionMessage(t *testing.T) {
+	settings := database.MusicSettings{MaxUserQueue: 3, MaxQueueLength: 50, MaxTrackLength: 600}
+
+	tests := []struct {
+		err      error
+		expected string
+	}{
+		{ErrUserQueueFull, "You already have 3 tracks queued"},
+		{ErrQueueFull, "The queue already has 50 tracks"},
+		{ErrTrackTooLong, "up to 10m"},
+		{ErrDuplicateTrack, "already in the queue"},
+		{ErrStreamBlocked, "doesn't allow livestreams"},
+		{fmt.Errorf("enqueue: %w", ErrQueueFull), "The queue already has 50 tracks"},
+	}
+
+	for _, test := range tests {
+		if message := RejectionMessage(settings, test.err); !strings.Contains(message, test.expected) {
+			t.Errorf("RejectionMessage(%v) = %q, expected it to contain %q", test.err, message, test.expected)
+		}
+	}
+
+	for _, err := range []error{nil, ErrNotPlaying, errors.New("node is down")} {
+		if message := RejectionMessage(settings, err); message != "" {
+			t.Errorf("expected %v not to be a rejection, got %q", err, message)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package music
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// lruCache is a cache of up to size values, which drops the least recently used ones first
+// to make room. With a ttl, values also expire that long after they were put in.
+// It's safe to use from several goroutines.
+type lruCache[V any] struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	recent  *list.List               // Cached values, most recently used first.
+	entries map[string]*list.Element // Elements of recent by key.
+}
+
+type lruEntry[V any] struct {
+	key     string
+	value   V
+	expires time.Time // Zero if the value never expires.
+}
+
+func newLRUCache[V any](size int, ttl time.Duration) *lruCache[V] {
+	return &lruCache[V]{
+		size:    size,
+		ttl:     ttl,
+		recent:  list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// get returns the value cached for key, if there is one that hasn't expired.
+func (c *lruCache[V]) get(key string) (value V, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return value, false
+	}
+
+	entry := element.Value.(lruEntry[V])
+	if !entry.expires.IsZero() && time.Now().After(entry.expires) {
+		c.recent.Remove(element)
+		delete(c.entries, key)
+		return value, false
+	}
+
+	c.recent.MoveToFront(element)
+
+	return entry.value, true
+}
+
+// put caches value for key, replacing whatever was cached for it before.
+func (c *lruCache[V]) put(key string, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := lruEntry[V]{key: key, value: value}
+	if c.ttl > 0 {
+		entry.expires = time.Now().Add(c.ttl)
+	}
+
+	if element, ok := c.entries[key]; ok {
+		element.Value = entry
+		c.recent.MoveToFront(element)
+		return
+	}
+
+	c.entries[key] = c.recent.PushFront(entry)
+
+	for c.recent.Len() > c.size {
+		oldest := c.recent.Back()
+		c.recent.Remove(oldest)
+		delete(c.entries, oldest.Value.(lruEntry[V]).key)
+	}
+}
+
+// len returns how many values are cached, including ones that expired but weren't noticed yet.
+func (c *lruCache[V]) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.recent.Len()
+}
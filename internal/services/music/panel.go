@@ -0,0 +1,406 @@
+package music
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/disgoorg/disgolink/v3/lavalink"
+
+	"unreal.sh/neo/internal/utils/datetime"
+	embedutils "unreal.sh/neo/internal/utils/embedutils"
+	sliceutils "unreal.sh/neo/internal/utils/sliceutils"
+	"unreal.sh/neo/internal/utils/static"
+	stringutils "unreal.sh/neo/internal/utils/stringutils"
+)
+
+// PanelUpdateInterval is how often the player panel is edited to keep its progress bar current.
+const PanelUpdateInterval = 15 * time.Second
+
+// PanelRefreshDelay is how long changes to the queue wait before the player panels show them,
+// so a playlist queued track by track edits them once rather than for every track.
+const PanelRefreshDelay = 2 * time.Second
+
+// PanelCustomIDPrefix prefixes the custom IDs of the player panel's buttons.
+const PanelCustomIDPrefix = "music_panel:"
+
+// The actions behind the player panel's buttons.
+const (
+	PanelActionPause   = "pause"
+	PanelActionSkip    = "skip"
+	PanelActionStop    = "stop"
+	PanelActionLoop    = "loop"
+	PanelActionShuffle = "shuffle"
+)
+
+// PostPanel sends a new player panel to a text channel, deleting the previous one.
+// The channel becomes the session's text channel. The panel is sent in the background,
+// so failing to send it is logged rather than returned.
+func (s *MusicSession) PostPanel(ctx context.Context, channelID string) error {
+	return s.do(ctx, func() error {
+		s.textChannelID = channelID
+		s.postPanel()
+
+		return nil
+	})
+}
+
+// postPanel sends a new player panel to the session's text channel, deleting the previous one.
+// The request channel's panel is updated instead, and is all a session playing from there needs.
+func (s *MusicSession) postPanel() {
+	s.updateRequestPanel()
+
+	if s.textChannelID == "" {
+		return
+	}
+
+	if s.inRequestChannel() {
+		s.panels.queue(panelOp{kind: panelDelete})
+		return
+	}
+
+	s.panels.queue(s.renderPanel(panelPost, s.textChannelID))
+	s.panelUpdated = time.Now()
+}
+
+// showPanel updates the player panels like refreshPanel, but posts a player panel if there isn't one yet.
+func (s *MusicSession) showPanel() {
+	s.updateRequestPanel()
+
+	channelID := s.textChannelID
+	if s.inRequestChannel() {
+		channelID = ""
+	}
+
+	s.panels.queue(s.renderPanel(panelShow, channelID))
+	s.panelUpdated = time.Now()
+}
+
+// refreshPanel updates the player panel and the request channel's panel to reflect the session's current state,
+// if they're posted. They're edited in the background, which logs failures.
+func (s *MusicSession) refreshPanel() {
+	s.updateRequestPanel()
+
+	s.panels.queue(s.renderPanel(panelEdit, ""))
+	s.panelUpdated = time.Now()
+}
+
+// renderPanel renders the player panel for a change to it.
+func (s *MusicSession) renderPanel(kind panelOpKind, channelID string) panelOp {
+	return panelOp{
+		kind:       kind,
+		channelID:  channelID,
+		embeds:     []*discordgo.MessageEmbed{s.panelEmbed()},
+		components: s.panelComponents(),
+	}
+}
+
+// schedulePanelUpdate refreshes the player panels after PanelRefreshDelay, unless a refresh is coming already.
+func (s *MusicSession) schedulePanelUpdate() {
+	if s.panelPending {
+		return
+	}
+
+	s.panelPending = true
+
+	time.AfterFunc(PanelRefreshDelay, func() {
+		s.post(func(ctx context.Context) {
+			s.panelPending = false
+			s.refreshPanel()
+		})
+	})
+}
+
+// tickPanel updates the player panels if they haven't been for PanelUpdateInterval.
+func (s *MusicSession) tickPanel() {
+	if (s.panels.message() == nil && s.requestPanel == nil) || time.Since(s.panelUpdated) < PanelUpdateInterval {
+		return
+	}
+
+	s.refreshPanel()
+}
+
+// closePanel edits the player panel one last time, without buttons, and forgets about it.
+// The request channel's panel stays, showing that nothing is playing.
+func (s *MusicSession) closePanel() {
+	s.updateRequestPanel()
+
+	s.panels.queue(panelOp{
+		kind:       panelClose,
+		embeds:     []*discordgo.MessageEmbed{idlePanelEmbed()},
+		components: []discordgo.MessageComponent{},
+	})
+}
+
+// panelEmbed renders the current track, its progress and what's up next.
+func (s *MusicSession) panelEmbed() *discordgo.MessageEmbed {
+	return s.nowPlayingEmbed(s.upNext())
+}
+
+// upNext names the next track in the queue and how many follow it.
+func (s *MusicSession) upNext() string {
+	if len(s.queue) == 0 {
+		return "Nothing"
+	}
+
+	next := TrackLink(s.queue[0])
+	if len(s.queue) > 1 {
+		next += fmt.Sprintf(" and %d more", len(s.queue)-1)
+	}
+
+	return next
+}
+
+// nowPlayingEmbed renders the current track and its progress, with next as what's up next.
+func (s *MusicSession) nowPlayingEmbed(next string) *discordgo.MessageEmbed {
+	track := s.current
+	if track == nil {
+		return idlePanelEmbed()
+	}
+
+	var data TrackRequestData
+	track.UserData.Unmarshal(&data)
+
+	title := "🎶  **Now Playing**"
+	if s.paused() {
+		title = "⏸  **Paused**"
+	}
+
+	description := fmt.Sprintf("**%s**\nby %s\n\n", TrackLink(track), track.Info.Author)
+
+	if track.Info.IsStream {
+		description += "🔴 Live"
+	} else {
+		position := s.position()
+		description += fmt.Sprintf("%s\n`%s / %s`", ProgressBar(position, track.Info.Length),
+			datetime.Pretty(datetime.ToDuration(position)),
+			datetime.Pretty(datetime.ToDuration(track.Info.Length)))
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       title,
+		Color:       s.panelColor,
+		Description: description,
+		Fields: []*discordgo.MessageEmbedField{
+			{
+				Name:   "Requested by",
+				Value:  data.Requester(),
+				Inline: true,
+			},
+			{
+				Name:   "Loop",
+				Value:  s.loop.String(),
+				Inline: true,
+			},
+			{
+				Name:  "Up next",
+				Value: next,
+			},
+		},
+	}
+
+	if track.Info.ArtworkURL != nil {
+		embed.Thumbnail = &discordgo.MessageEmbedThumbnail{URL: *track.Info.ArtworkURL}
+	}
+
+	if s.sleepTimer != nil {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  "Sleep timer",
+			Value: "💤 Stopping " + s.sleepTimer.String(),
+		})
+	}
+
+	if filters := DescribeFilters(s.filters); len(filters) > 0 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  "Filters",
+			Value: strings.Join(filters, "\n"),
+		})
+	}
+
+	return embed
+}
+
+func idlePanelEmbed() *discordgo.MessageEmbed {
+	return &discordgo.MessageEmbed{
+		Title:       "🎶  **Now Playing**",
+		Color:       static.ColorEmbedGray,
+		Description: "Nothing is playing right now.",
+	}
+}
+
+func (s *MusicSession) panelComponents() []discordgo.MessageComponent {
+	pause := discordgo.Button{
+		Label:    "Pause",
+		Emoji:    &discordgo.ComponentEmoji{Name: "⏸"},
+		Style:    discordgo.SecondaryButton,
+		CustomID: PanelCustomIDPrefix + PanelActionPause,
+	}
+
+	if s.paused() {
+		pause.Label = "Resume"
+		pause.Emoji = &discordgo.ComponentEmoji{Name: "▶"}
+		pause.Style = discordgo.PrimaryButton
+	}
+
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				pause,
+				discordgo.Button{
+					Label:    "Skip",
+					Emoji:    &discordgo.ComponentEmoji{Name: "⏭"},
+					Style:    discordgo.SecondaryButton,
+					CustomID: PanelCustomIDPrefix + PanelActionSkip,
+				},
+				discordgo.Button{
+					Label:    "Stop",
+					Emoji:    &discordgo.ComponentEmoji{Name: "⏹"},
+					Style:    discordgo.DangerButton,
+					CustomID: PanelCustomIDPrefix + PanelActionStop,
+				},
+				discordgo.Button{
+					Label:    fmt.Sprintf("Loop: %s", s.loop),
+					Emoji:    &discordgo.ComponentEmoji{Name: "🔁"},
+					Style:    discordgo.SecondaryButton,
+					CustomID: PanelCustomIDPrefix + PanelActionLoop,
+				},
+				discordgo.Button{
+					Label:    "Shuffle",
+					Emoji:    &discordgo.ComponentEmoji{Name: "🔀"},
+					Style:    discordgo.SecondaryButton,
+					CustomID: PanelCustomIDPrefix + PanelActionShuffle,
+				},
+			},
+		},
+	}
+}
+
+// ProgressBar renders how far into a track position is.
+func ProgressBar(position lavalink.Duration, length lavalink.Duration) string {
+	const width = 20
+
+	var progressBar string
+
+	current := -1
+	if length > 0 {
+		// A finished track has its marker at the end, rather than just past it.
+		current = min(max(int(position*width/length), 0), width-1)
+	}
+
+	for i := 0; i < width; i++ {
+		if i == current {
+			progressBar += "🔵"
+		} else {
+			progressBar += "▬"
+		}
+	}
+
+	return progressBar
+}
+
+// TrackLink renders a track's title, shortened, as a link to the track.
+// Tracks that don't have a link only get their title.
+func TrackLink(track *lavalink.Track) string {
+	title := stringutils.Truncate(track.Info.Title, 30)
+	if track.Info.URI == nil {
+		return title
+	}
+
+	return fmt.Sprintf("[%s](%s)", title, *track.Info.URI)
+}
+
+// onInteractionCreate handles presses of the player panel's buttons.
+// It needs to be hooked to the bot's events via MusicService.HookEvents().
+func (s *MusicService) onInteractionCreate(session *discordgo.Session, event *discordgo.InteractionCreate) {
+	if event.Type != discordgo.InteractionMessageComponent || event.Member == nil {
+		return
+	}
+
+	action, ok := strings.CutPrefix(event.MessageComponentData().CustomID, PanelCustomIDPrefix)
+	if !ok {
+		return
+	}
+
+	musicSession := s.GetMusicSession(event.GuildID)
+	if musicSession == nil {
+		s.respondToPanel(event, "I'm not playing anything right now.")
+		return
+	}
+
+	ctx, cancel := s.OperationContext()
+	defer cancel()
+
+	state, err := musicSession.State(ctx)
+	if err != nil || !state.IsPlaying() {
+		s.respondToPanel(event, "I'm not playing anything right now.")
+		return
+	}
+
+	// Panel buttons follow the same rules as their commands.
+	if !sliceutils.Contains(s.Listeners(event.GuildID), event.Member.User.ID) {
+		s.respondToPanel(event, "You have to be in the same voice channel as me to use this.")
+		return
+	}
+
+	isDJ := s.IsDJ(event.GuildID, event.Member)
+
+	if action == PanelActionSkip {
+		var data TrackRequestData
+		state.CurrentTrack.UserData.Unmarshal(&data)
+
+		if !s.SkipsRightAway(event.GuildID, event.Member, data.AuthorID) {
+			s.respondToPanel(event, "Only DJs and whoever requested the track can skip it right away. Use `/skip` to vote instead.")
+			return
+		}
+	} else if !isDJ {
+		description := "Only DJs can use this!"
+		if roleID := s.MusicSettings(event.GuildID).DJRoleID; roleID != "" {
+			description = fmt.Sprintf("You must have the role <@&%s> to use this!", roleID)
+		}
+
+		s.respondToPanel(event, description)
+		return
+	}
+
+	err = session.InteractionRespond(event.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredMessageUpdate,
+	})
+	if err != nil {
+		slog.Error("Failed to respond to player panel interaction.", slog.String("error", err.Error()))
+	}
+
+	switch action {
+	case PanelActionPause:
+		err = musicSession.TogglePause(ctx)
+	case PanelActionSkip:
+		err = musicSession.Skip(ctx)
+	case PanelActionStop:
+		err = musicSession.Stop(ctx)
+	case PanelActionLoop:
+		_, err = musicSession.CycleLoop(ctx)
+	case PanelActionShuffle:
+		err = musicSession.Shuffle(ctx)
+	}
+
+	if err != nil {
+		slog.Error("Failed to handle player panel action.",
+			slog.String("guild_id", event.GuildID), slog.String("action", action), slog.String("error", err.Error()))
+	}
+}
+
+// respondToPanel replies to a player panel interaction with a message only the user sees.
+func (s *MusicService) respondToPanel(event *discordgo.InteractionCreate, description string) {
+	err := s.session.InteractionRespond(event.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{embedutils.CreateErrorEmbed(description)},
+			Flags:  discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		slog.Error("Failed to respond to player panel interaction.", slog.String("error", err.Error()))
+	}
+}
@@ -0,0 +1,54 @@
+package music
+
+import (
+	"testing"
+
+	"github.com/disgoorg/disgolink/v3/lavalink"
+)
+
+func TestTrackLink(t *testing.T) {
+	track := testTrack("title")
+	if link := TrackLink(track); link != "title" {
+		t.Fatalf("expected a track without a link to show its title, got %q", link)
+	}
+
+	uri := "https://example.com/track"
+	track.Info.URI = &uri
+	if link := TrackLink(track); link != "[title](https://example.com/track)" {
+		t.Fatalf("expected a link to the track, got %q", link)
+	}
+}
+
+func TestProgressBar(t *testing.T) {
+	tests := []struct {
+		position lavalink.Duration
+		length   lavalink.Duration
+		marker   int // Where the marker is, -1 for nowhere.
+	}{
+		{0, 1000, 0},
+		{500, 1000, 10},
+		{999, 1000, 19},
+		{1000, 1000, 19},
+		{1500, 1000, 19},
+		{0, 0, -1},
+	}
+
+	for _, test := range tests {
+		bar := []rune(ProgressBar(test.position, test.length))
+		if len(bar) != 20 {
+			t.Errorf("ProgressBar(%d, %d) is %d wide, expected 20", test.position, test.length, len(bar))
+			continue
+		}
+
+		marker := -1
+		for i, r := range bar {
+			if r == '🔵' {
+				marker = i
+			}
+		}
+
+		if marker != test.marker {
+			t.Errorf("ProgressBar(%d, %d) has its marker at %d, expected %d", test.position, test.length, marker, test.marker)
+		}
+	}
+}
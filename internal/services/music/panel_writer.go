@@ -0,0 +1,269 @@
+package music
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// panelOpKind is what a panelOp does to the player panel.
+type panelOpKind int
+
+const (
+	panelEdit   panelOpKind = iota // Edit the panel, if it's posted.
+	panelShow                      // Edit the panel, posting it if it isn't.
+	panelPost                      // Delete the panel and post a new one.
+	panelClose                     // Edit the panel one last time and forget about it.
+	panelDelete                    // Delete the panel.
+)
+
+// panelOp is a change to the player panel waiting to be sent to Discord.
+type panelOp struct {
+	kind       panelOpKind
+	channelID  string // Where the panel is posted, if the change posts one.
+	embeds     []*discordgo.MessageEmbed
+	components []discordgo.MessageComponent
+}
+
+// requestPanelEdit is a change to the request channel's panel waiting to be sent to Discord.
+type requestPanelEdit struct {
+	message    *discordgo.Message
+	embeds     []*discordgo.MessageEmbed
+	components []discordgo.MessageComponent
+}
+
+// maxWaitingMessages is how many of a session's messages can wait to be sent before more are dropped.
+const maxWaitingMessages = 10
+
+// outgoingMessage is a message to the session's text channel waiting to be sent to Discord.
+type outgoingMessage struct {
+	channelID string
+	embed     *discordgo.MessageEmbed
+	lifetime  time.Duration // How long until the message is deleted again, zero to keep it.
+}
+
+// panelWriter sends a session's panels and messages to Discord in the background, so its event loop never waits
+// on Discord's API. Changes are sent in order, but edits made while earlier changes are still being sent are
+// coalesced, so a busy session only sends the latest state of its panels.
+type panelWriter struct {
+	session *discordgo.Session
+	guildID string
+
+	// onRequestPanelGone is called with the request channel's panel when it couldn't be edited.
+	onRequestPanelGone func(message *discordgo.Message)
+
+	mu      sync.Mutex
+	panel   *discordgo.Message // The player panel message, if one is posted.
+	ops     []panelOp          // Changes to the player panel, oldest first.
+	request *requestPanelEdit  // The latest change to the request channel's panel, if any.
+	sends   []outgoingMessage  // Messages to send, oldest first.
+	wake    chan struct{}      // Signals that changes are waiting.
+}
+
+func newPanelWriter(session *discordgo.Session, guildID string) *panelWriter {
+	return &panelWriter{
+		session: session,
+		guildID: guildID,
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+// run sends changes until ctx is cancelled, and the ones still waiting after that.
+func (w *panelWriter) run(ctx context.Context) {
+	for {
+		select {
+		case <-w.wake:
+			w.flush()
+		case <-ctx.Done():
+			// A closing session's last changes, like closing its panel, are still sent.
+			w.flush()
+			return
+		}
+	}
+}
+
+// queue adds a change to the player panel. An edit replaces the content of the change before it,
+// which hasn't been sent yet, and is dropped after changes that leave no panel to edit.
+func (w *panelWriter) queue(op panelOp) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if n := len(w.ops); op.kind == panelEdit && n > 0 {
+		last := &w.ops[n-1]
+		if last.kind == panelClose || last.kind == panelDelete {
+			return
+		}
+
+		last.embeds, last.components = op.embeds, op.components
+	} else {
+		w.ops = append(w.ops, op)
+	}
+
+	w.signal()
+}
+
+// editRequestPanel replaces the request channel's panel with new content, replacing any edit that hasn't been sent yet.
+func (w *panelWriter) editRequestPanel(edit requestPanelEdit) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.request = &edit
+	w.signal()
+}
+
+// send queues a message to a text channel. If too many are waiting already, like while Discord rate limits
+// the bot, it's dropped rather than piling up.
+func (w *panelWriter) send(message outgoingMessage) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.sends) >= maxWaitingMessages {
+		slog.Warn("Dropped a message to the text channel, too many are waiting to be sent.", slog.String("guild_id", w.guildID))
+		return
+	}
+
+	w.sends = append(w.sends, message)
+	w.signal()
+}
+
+// signal wakes the writer up, unless it's about to wake up already. w.mu must be held.
+func (w *panelWriter) signal() {
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+// message returns the player panel message, if one is posted.
+func (w *panelWriter) message() *discordgo.Message {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.panel
+}
+
+// setMessage makes a message the player panel, like one posted before a restart.
+func (w *panelWriter) setMessage(message *discordgo.Message) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.panel = message
+}
+
+// flush sends the waiting changes until there are none left.
+func (w *panelWriter) flush() {
+	for {
+		w.mu.Lock()
+		ops, request, sends := w.ops, w.request, w.sends
+		w.ops, w.request, w.sends = nil, nil, nil
+		w.mu.Unlock()
+
+		if len(ops) == 0 && request == nil && len(sends) == 0 {
+			return
+		}
+
+		for _, message := range sends {
+			w.sendMessage(message)
+		}
+
+		for _, op := range ops {
+			w.apply(op)
+		}
+
+		if request != nil {
+			w.sendRequestPanel(*request)
+		}
+	}
+}
+
+func (w *panelWriter) apply(op panelOp) {
+	switch op.kind {
+	case panelPost:
+		w.delete()
+		w.post(op)
+	case panelDelete:
+		w.delete()
+	default:
+		panel := w.message()
+		if panel == nil {
+			if op.kind == panelShow && op.channelID != "" {
+				w.post(op)
+			}
+
+			return
+		}
+
+		_, err := w.session.ChannelMessageEditComplex(&discordgo.MessageEdit{
+			ID:         panel.ID,
+			Channel:    panel.ChannelID,
+			Embeds:     &op.embeds,
+			Components: &op.components,
+		})
+		if err != nil {
+			slog.Warn("Failed to update player panel.", slog.String("guild_id", w.guildID), slog.String("error", err.Error()))
+		}
+
+		// A panel that can't be edited was most likely deleted by someone, so stop editing it.
+		if err != nil || op.kind == panelClose {
+			w.setMessage(nil)
+		}
+	}
+}
+
+func (w *panelWriter) post(op panelOp) {
+	message, err := w.session.ChannelMessageSendComplex(op.channelID, &discordgo.MessageSend{
+		Embeds:     op.embeds,
+		Components: op.components,
+	})
+	if err != nil {
+		slog.Warn("Failed to post player panel.", slog.String("guild_id", w.guildID), slog.String("error", err.Error()))
+		return
+	}
+
+	w.setMessage(message)
+}
+
+func (w *panelWriter) delete() {
+	panel := w.message()
+	if panel == nil {
+		return
+	}
+
+	err := w.session.ChannelMessageDelete(panel.ChannelID, panel.ID)
+	if err != nil {
+		slog.Warn("Failed to delete player panel.", slog.String("guild_id", w.guildID), slog.String("error", err.Error()))
+	}
+
+	w.setMessage(nil)
+}
+
+func (w *panelWriter) sendRequestPanel(edit requestPanelEdit) {
+	_, err := w.session.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		ID:         edit.message.ID,
+		Channel:    edit.message.ChannelID,
+		Embeds:     &edit.embeds,
+		Components: &edit.components,
+	})
+	if err != nil {
+		slog.Warn("Failed to update request channel panel.", slog.String("guild_id", w.guildID), slog.String("error", err.Error()))
+
+		if w.onRequestPanelGone != nil {
+			w.onRequestPanelGone(edit.message)
+		}
+	}
+}
+
+func (w *panelWriter) sendMessage(outgoing outgoingMessage) {
+	message, err := w.session.ChannelMessageSendEmbed(outgoing.channelID, outgoing.embed)
+	if err != nil {
+		slog.Warn("Failed to send message to text channel.", slog.String("guild_id", w.guildID), slog.String("error", err.Error()))
+		return
+	}
+
+	if outgoing.lifetime > 0 {
+		deleteAfter(w.session, message, outgoing.lifetime)
+	}
+}
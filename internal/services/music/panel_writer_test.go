@@ -0,0 +1,80 @@
+package music
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// editOp returns a panel edit whose content is told apart by its title.
+func editOp(kind panelOpKind, title string) panelOp {
+	return panelOp{kind: kind, embeds: []*discordgo.MessageEmbed{{Title: title}}}
+}
+
+func TestPanelWriterCoalescesEdits(t *testing.T) {
+	tests := []struct {
+		name     string
+		ops      []panelOp
+		expected []string // The kind and title of each waiting change.
+	}{
+		{"edits", []panelOp{editOp(panelEdit, "a"), editOp(panelEdit, "b"), editOp(panelEdit, "c")}, []string{"edit c"}},
+		{"edits after a post", []panelOp{editOp(panelPost, "a"), editOp(panelEdit, "b")}, []string{"post b"}},
+		{"edits after showing", []panelOp{editOp(panelShow, "a"), editOp(panelEdit, "b")}, []string{"show b"}},
+		{"edits after closing", []panelOp{editOp(panelClose, "idle"), editOp(panelEdit, "b")}, []string{"close idle"}},
+		{"edits after deleting", []panelOp{{kind: panelDelete}, editOp(panelEdit, "b")}, []string{"delete "}},
+		{"posts stay", []panelOp{editOp(panelPost, "a"), editOp(panelClose, "idle"), editOp(panelPost, "b")},
+			[]string{"post a", "close idle", "post b"}},
+	}
+
+	kinds := map[panelOpKind]string{panelEdit: "edit", panelShow: "show", panelPost: "post", panelClose: "close", panelDelete: "delete"}
+
+	for _, test := range tests {
+		w := newPanelWriter(nil, "1")
+		for _, op := range test.ops {
+			w.queue(op)
+		}
+
+		if len(w.ops) != len(test.expected) {
+			t.Errorf("%s: expected %d changes, got %d", test.name, len(test.expected), len(w.ops))
+			continue
+		}
+
+		for i, op := range w.ops {
+			title := ""
+			if len(op.embeds) > 0 {
+				title = op.embeds[0].Title
+			}
+
+			if got := kinds[op.kind] + " " + title; got != test.expected[i] {
+				t.Errorf("%s: expected change %d to be %q, got %q", test.name, i, test.expected[i], got)
+			}
+		}
+	}
+}
+
+func TestPanelWriterKeepsLatestRequestPanel(t *testing.T) {
+	w := newPanelWriter(nil, "1")
+	message := &discordgo.Message{ID: "2", ChannelID: "3"}
+
+	w.editRequestPanel(requestPanelEdit{message: message, embeds: []*discordgo.MessageEmbed{{Title: "a"}}})
+	w.editRequestPanel(requestPanelEdit{message: message, embeds: []*discordgo.MessageEmbed{{Title: "b"}}})
+
+	if w.request == nil || w.request.embeds[0].Title != "b" {
+		t.Fatalf("expected only the latest request panel to be waiting, got %+v", w.request)
+	}
+
+	if len(w.wake) != 1 {
+		t.Fatalf("expected the writer to be woken up once, got %d signals", len(w.wake))
+	}
+}
+
+func TestPanelWriterBoundsMessages(t *testing.T) {
+	w := newPanelWriter(nil, "1")
+	for i := 0; i < maxWaitingMessages+5; i++ {
+		w.send(outgoingMessage{channelID: "2", embed: &discordgo.MessageEmbed{}})
+	}
+
+	if len(w.sends) != maxWaitingMessages {
+		t.Fatalf("expected %d messages to be waiting, got %d", maxWaitingMessages, len(w.sends))
+	}
+}
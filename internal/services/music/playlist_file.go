@@ -0,0 +1,278 @@
+package music
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/disgoorg/disgolink/v3/lavalink"
+
+	stringutils "unreal.sh/neo/internal/utils/stringutils"
+)
+
+// MaxPlaylistFileSize is the largest playlist file that gets imported, in bytes.
+const MaxPlaylistFileSize = 512 * 1024
+
+var (
+	ErrPlaylistFileTooLarge = errors.New("playlist file is too large")
+	ErrEmptyPlaylistFile    = errors.New("playlist file has no entries")
+)
+
+// PlaylistEntry is a track listed in a playlist file.
+type PlaylistEntry struct {
+	Location string // A URL, a file path or, in plain text lists, a search query.
+	Title    string // The title the file gives the track, if any.
+	Creator  string // The artist the file gives the track, if any.
+
+	// Track is the exact track to play, for files that carry encoded tracks,
+	// like exported queues. Such entries don't need loading.
+	Track *lavalink.Track
+}
+
+// Query returns what to load the entry with. URLs are loaded directly, while
+// anything else, like a path to a file on someone's computer, is searched for by title.
+func (e PlaylistEntry) Query() string {
+	if stringutils.IsUrl(e.Location) {
+		return e.Location
+	}
+
+	if title := e.fullTitle(); title != "" {
+		return title
+	}
+
+	// Local files are usually named after the song, like "Artist - Title.mp3".
+	name := path.Base(strings.ReplaceAll(e.Location, "\\", "/"))
+	name = strings.TrimSuffix(name, path.Ext(name))
+
+	return strings.TrimSpace(strings.ReplaceAll(name, "_", " "))
+}
+
+// String describes the entry for people, preferring its title over its location.
+func (e PlaylistEntry) String() string {
+	if title := e.fullTitle(); title != "" {
+		return title
+	}
+
+	return e.Location
+}
+
+func (e PlaylistEntry) fullTitle() string {
+	if e.Title != "" && e.Creator != "" {
+		return e.Creator + " - " + e.Title
+	}
+
+	return e.Title
+}
+
+// ParsePlaylistFile reads the entries of an M3U, PLS, XSPF, exported queue or plain text playlist.
+// The format is picked by the file's extension, or by its contents if the extension doesn't say.
+func ParsePlaylistFile(name string, data []byte) ([]PlaylistEntry, error) {
+	if len(data) > MaxPlaylistFileSize {
+		return nil, ErrPlaylistFileTooLarge
+	}
+
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	head := strings.ToLower(strings.TrimSpace(string(data[:min(len(data), 64)])))
+
+	var entries []PlaylistEntry
+	var err error
+
+	switch ext := strings.ToLower(path.Ext(name)); {
+	case ext == ".m3u" || ext == ".m3u8" || strings.HasPrefix(head, "#extm3u"):
+		entries = parseM3U(data)
+	case ext == ".pls" || strings.HasPrefix(head, "[playlist]"):
+		entries = parsePLS(data)
+	case ext == ".xspf" || strings.HasPrefix(head, "<?xml") || strings.HasPrefix(head, "<playlist"):
+		entries, err = parseXSPF(data)
+	case ext == ".json" || strings.HasPrefix(head, "{"):
+		entries, err = parseQueueExport(data)
+	default:
+		entries = parseText(data)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(entries) == 0 {
+		return nil, ErrEmptyPlaylistFile
+	}
+
+	return entries, nil
+}
+
+// parseM3U reads an M3U playlist, taking titles from #EXTINF lines when there are any.
+func parseM3U(data []byte) []PlaylistEntry {
+	var entries []PlaylistEntry
+	var title string
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "#EXTINF:"):
+			// #EXTINF:<seconds>,<artist> - <title>
+			if _, info, ok := strings.Cut(line, ","); ok {
+				title = strings.TrimSpace(info)
+			}
+		case strings.HasPrefix(line, "#"):
+			continue
+		default:
+			entries = append(entries, PlaylistEntry{Location: line, Title: title})
+			title = ""
+		}
+	}
+
+	return entries
+}
+
+// parsePLS reads a PLS playlist, which numbers its entries with FileN and TitleN keys.
+func parsePLS(data []byte) []PlaylistEntry {
+	byNumber := make(map[int]*PlaylistEntry)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if !ok {
+			continue
+		}
+
+		key, value = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)
+
+		var field string
+		switch {
+		case strings.HasPrefix(key, "file"):
+			field = "file"
+		case strings.HasPrefix(key, "title"):
+			field = "title"
+		default:
+			continue
+		}
+
+		n, err := strconv.Atoi(strings.TrimPrefix(key, field))
+		if err != nil {
+			continue
+		}
+
+		entry, exists := byNumber[n]
+		if !exists {
+			entry = &PlaylistEntry{}
+			byNumber[n] = entry
+		}
+
+		if field == "file" {
+			entry.Location = value
+		} else {
+			entry.Title = value
+		}
+	}
+
+	numbers := make([]int, 0, len(byNumber))
+	for n, entry := range byNumber {
+		if entry.Location != "" {
+			numbers = append(numbers, n)
+		}
+	}
+
+	sort.Ints(numbers)
+
+	entries := make([]PlaylistEntry, len(numbers))
+	for i, n := range numbers {
+		entries[i] = *byNumber[n]
+	}
+
+	return entries
+}
+
+type xspfPlaylist struct {
+	Tracks []struct {
+		Locations []string `xml:"location"`
+		Title     string   `xml:"title"`
+		Creator   string   `xml:"creator"`
+	} `xml:"trackList>track"`
+}
+
+// parseXSPF reads an XSPF playlist. Tracks without a location are kept as long as
+// they have a title, since they can still be searched for.
+func parseXSPF(data []byte) ([]PlaylistEntry, error) {
+	var playlist xspfPlaylist
+	if err := xml.Unmarshal(data, &playlist); err != nil {
+		return nil, fmt.Errorf("invalid XSPF playlist: %w", err)
+	}
+
+	entries := make([]PlaylistEntry, 0, len(playlist.Tracks))
+	for _, track := range playlist.Tracks {
+		entry := PlaylistEntry{
+			Title:   strings.TrimSpace(track.Title),
+			Creator: strings.TrimSpace(track.Creator),
+		}
+
+		for _, location := range track.Locations {
+			if location = strings.TrimSpace(location); location != "" {
+				entry.Location = location
+				break
+			}
+		}
+
+		if entry.Location == "" && entry.Title == "" {
+			continue
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// parseQueueExport reads a queue exported as JSON, keeping its encoded tracks.
+func parseQueueExport(data []byte) ([]PlaylistEntry, error) {
+	var export QueueExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, fmt.Errorf("invalid exported queue: %w", err)
+	}
+
+	entries := make([]PlaylistEntry, 0, len(export.Tracks))
+	for _, exported := range export.Tracks {
+		entry := PlaylistEntry{
+			Location: exported.URI,
+			Title:    exported.Title,
+			Creator:  exported.Author,
+		}
+
+		if exported.Track.Encoded != "" {
+			track := exported.Track
+			entry.Track = &track
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// parseText reads a plain list with a URL or search query on each line.
+func parseText(data []byte) []PlaylistEntry {
+	var entries []PlaylistEntry
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		entries = append(entries, PlaylistEntry{Location: line})
+	}
+
+	return entries
+}
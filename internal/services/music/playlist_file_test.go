@@ -0,0 +1,112 @@
+package music
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParsePlaylistFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    []PlaylistEntry
+	}{
+		{
+			name: "m3u",
+			file: "mix.m3u8",
+			content: "#EXTM3U\n" +
+				"#EXTINF:215,Someone - First Song\n" +
+				"C:\\Music\\first.mp3\n" +
+				"\n" +
+				"https://example.com/second\n",
+			want: []PlaylistEntry{
+				{Location: "C:\\Music\\first.mp3", Title: "Someone - First Song"},
+				{Location: "https://example.com/second"},
+			},
+		},
+		{
+			name: "pls",
+			file: "mix.pls",
+			content: "[playlist]\n" +
+				"File2=https://example.com/second\n" +
+				"File1=/music/first.flac\n" +
+				"Title1=First Song\n" +
+				"NumberOfEntries=2\n",
+			want: []PlaylistEntry{
+				{Location: "/music/first.flac", Title: "First Song"},
+				{Location: "https://example.com/second"},
+			},
+		},
+		{
+			name: "xspf",
+			file: "mix.xspf",
+			content: `<?xml version="1.0" encoding="UTF-8"?>
+<playlist version="1" xmlns="http://xspf.org/ns/0/">
+  <trackList>
+    <track><location>file:///music/first.ogg</location><title>First Song</title><creator>Someone</creator></track>
+    <track><title>Only A Title</title></track>
+    <track><annotation>Nothing to load</annotation></track>
+  </trackList>
+</playlist>`,
+			want: []PlaylistEntry{
+				{Location: "file:///music/first.ogg", Title: "First Song", Creator: "Someone"},
+				{Title: "Only A Title"},
+			},
+		},
+		{
+			name:    "text sniffed as m3u",
+			file:    "mix.txt",
+			content: "\xef\xbb\xbf#EXTM3U\n#EXTINF:-1,Radio\nhttps://example.com/radio\n",
+			want:    []PlaylistEntry{{Location: "https://example.com/radio", Title: "Radio"}},
+		},
+		{
+			name:    "plain text",
+			file:    "songs.txt",
+			content: "https://example.com/first\n# a comment\n  someone second song  \n",
+			want: []PlaylistEntry{
+				{Location: "https://example.com/first"},
+				{Location: "someone second song"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			entries, err := ParsePlaylistFile(test.file, []byte(test.content))
+			if err != nil {
+				t.Fatalf("ParsePlaylistFile: %v", err)
+			}
+
+			if !reflect.DeepEqual(entries, test.want) {
+				t.Fatalf("got %+v, want %+v", entries, test.want)
+			}
+		})
+	}
+}
+
+func TestParsePlaylistFileEmpty(t *testing.T) {
+	_, err := ParsePlaylistFile("empty.m3u", []byte("#EXTM3U\n\n"))
+	if !errors.Is(err, ErrEmptyPlaylistFile) {
+		t.Fatalf("expected ErrEmptyPlaylistFile, got %v", err)
+	}
+}
+
+func TestPlaylistEntryQuery(t *testing.T) {
+	tests := []struct {
+		entry PlaylistEntry
+		want  string
+	}{
+		{PlaylistEntry{Location: "https://example.com/a", Title: "A"}, "https://example.com/a"},
+		{PlaylistEntry{Location: "file:///music/a.ogg", Title: "A", Creator: "Someone"}, "Someone - A"},
+		{PlaylistEntry{Location: "C:\\Music\\Someone_-_A.mp3"}, "Someone - A"},
+		{PlaylistEntry{Location: "someone a"}, "someone a"},
+	}
+
+	for _, test := range tests {
+		if got := test.entry.Query(); got != test.want {
+			t.Errorf("%+v.Query() = %q, want %q", test.entry, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package music
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"time"
+
+	"github.com/disgoorg/disgolink/v3/lavalink"
+
+	"unreal.sh/neo/internal/database"
+)
+
+// queuedBy returns how many tracks in the queue were requested by a user.
+func (s *MusicSession) queuedBy(authorID string) int {
+	count := 0
+	for _, data := range s.data {
+		if data.AuthorID == authorID {
+			count++
+		}
+	}
+
+	return count
+}
+
+// fairInsertIndex returns where a track requested by authorID goes in a round-robin queue.
+//
+// Each queued track belongs to a round, which is how many tracks its requester has before it.
+// A new track joins the round after its requester's last one, right after the last track of
+// that round, so requesters take turns instead of waiting for each other's playlists.
+func fairInsertIndex(data []TrackRequestData, authorID string) int {
+	rounds := make(map[string]int)
+	round := 0
+	for _, d := range data {
+		if d.AuthorID == authorID {
+			round++
+		}
+	}
+
+	index := 0
+	for i, d := range data {
+		if rounds[d.AuthorID] <= round {
+			index = i + 1
+		}
+
+		rounds[d.AuthorID]++
+	}
+
+	return index
+}
+
+// EstimatedStarts returns how long after the snapshot each queued track is expected to start.
+// Tracks after a stream can't be estimated, so their estimate is negative.
+func (state SessionState) EstimatedStarts() []time.Duration {
+	starts := make([]time.Duration, len(state.Queue))
+
+	var elapsed lavalink.Duration
+	known := true
+
+	if state.CurrentTrack != nil {
+		if state.CurrentTrack.Info.IsStream {
+			known = false
+		} else {
+			elapsed = state.Remaining()
+		}
+	}
+
+	for i, track := range state.Queue {
+		if !known {
+			starts[i] = -1
+			continue
+		}
+
+		starts[i] = time.Duration(elapsed) * time.Millisecond
+
+		if track.Info.IsStream {
+			known = false
+		}
+
+		elapsed += track.Info.Length
+	}
+
+	return starts
+}
+
+// LoopMode is what a session plays after a track finishes.
+type LoopMode int
+
+const (
+	LoopOff   LoopMode = iota // Play the next queued track.
+	LoopTrack                 // Play the same track again.
+	LoopQueue                 // Put the track back at the end of the queue.
+)
+
+// Next returns the mode after m, cycling through all of them.
+func (m LoopMode) Next() LoopMode {
+	return (m + 1) % 3
+}
+
+func (m LoopMode) String() string {
+	switch m {
+	case LoopTrack:
+		return "Track"
+	case LoopQueue:
+		return "Queue"
+	default:
+		return "Off"
+	}
+}
+
+// CycleLoop switches to the next loop mode, returning it.
+func (s *MusicSession) CycleLoop(ctx context.Context) (mode LoopMode, err error) {
+	err = s.do(ctx, func() error {
+		s.loop = s.loop.Next()
+		mode = s.loop
+
+		s.refreshPanel()
+
+		return nil
+	})
+
+	return mode, err
+}
+
+// Shuffle randomizes the order of the queue.
+func (s *MusicSession) Shuffle(ctx context.Context) error {
+	return s.do(ctx, func() error {
+		rand.Shuffle(len(s.queue), func(i, j int) {
+			s.queue[i], s.queue[j] = s.queue[j], s.queue[i]
+			s.data[i], s.data[j] = s.data[j], s.data[i]
+		})
+
+		s.refreshPanel()
+
+		return nil
+	})
+}
+
+// requeue puts a track back at the end of the queue, bypassing the guild's limits,
+// since it was already let in once.
+func (s *MusicSession) requeue(track *lavalink.Track) {
+	var data TrackRequestData
+	track.UserData.Unmarshal(&data)
+
+	s.queue = append(s.queue, track)
+	s.data = append(s.data, data)
+}
+
+// EnqueueAll plays or enqueues a list of tracks for a user, within the guild's limits.
+// It returns how many were enqueued, and notes for the user on any that weren't.
+func (s *MusicSession) EnqueueAll(ctx context.Context, userID string, settings database.MusicSettings,
+	tracks []lavalink.Track, source string) (loaded int, notes string) {
+	if limit := settings.MaxPlaylistSize; limit > 0 && len(tracks) > limit {
+		notes += fmt.Sprintf("Only the first %d tracks were imported, since that's this server's limit.\n", limit)
+		tracks = tracks[:limit]
+	}
+
+	rejected := 0
+	for i := range tracks {
+		request := NewTrackRequestData(userID, nil, source)
+
+		_, err := s.PlayOrEnqueue(ctx, &tracks[i], request)
+		if errors.Is(err, ErrUserQueueFull) || errors.Is(err, ErrQueueFull) {
+			notes += fmt.Sprintf("The rest were left out. %s\n", RejectionMessage(settings, err))
+			break
+		} else if RejectionMessage(settings, err) != "" {
+			rejected++
+			continue
+		} else if err != nil {
+			slog.Error(fmt.Sprintf("Failed to play or enqueue track: %s", err.Error()))
+			continue
+		}
+
+		loaded++
+	}
+
+	if rejected == 1 {
+		notes += "1 track was skipped, since it's too long, a duplicate or a stream this server doesn't allow.\n"
+	} else if rejected > 1 {
+		notes += fmt.Sprintf("%d tracks were skipped, since they're too long, duplicates or streams this server doesn't allow.\n", rejected)
+	}
+
+	return loaded, notes
+}
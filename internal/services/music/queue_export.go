@@ -0,0 +1,173 @@
+package music
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/disgoorg/disgolink/v3/lavalink"
+)
+
+type ExportFormat string
+
+const (
+	ExportFormatM3U  ExportFormat = "m3u"
+	ExportFormatJSON ExportFormat = "json"
+	ExportFormatText ExportFormat = "text"
+)
+
+// QueueExport is the JSON form of an exported queue. It keeps the encoded
+// tracks, so importing it plays exactly the same tracks without searching again.
+type QueueExport struct {
+	ExportedAt time.Time       `json:"exported_at"`
+	Tracks     []ExportedTrack `json:"tracks"`
+}
+
+type ExportedTrack struct {
+	Title      string         `json:"title"`
+	Author     string         `json:"author"`
+	URI        string         `json:"uri,omitempty"`
+	Length     int64          `json:"length"` // In milliseconds.
+	Requester  string         `json:"requester,omitempty"`
+	Autoplay   bool           `json:"autoplay,omitempty"`
+	NowPlaying bool           `json:"now_playing,omitempty"`
+	Track      lavalink.Track `json:"track"`
+}
+
+// ExportQueue writes the current track and the queue in the given format.
+// names maps requesters' user IDs to the names written in the M3U and text forms.
+// It returns the file's contents and extension.
+func ExportQueue(state SessionState, format ExportFormat, names map[string]string) ([]byte, string, error) {
+	tracks := exportedTracks(state)
+
+	switch format {
+	case ExportFormatJSON:
+		data, err := json.MarshalIndent(QueueExport{ExportedAt: time.Now(), Tracks: tracks}, "", "  ")
+		return data, "json", err
+	case ExportFormatM3U:
+		return exportM3U(tracks, names), "m3u", nil
+	case ExportFormatText:
+		return exportText(tracks, names), "txt", nil
+	default:
+		return nil, "", fmt.Errorf("unknown export format %q", format)
+	}
+}
+
+func exportedTracks(state SessionState) []ExportedTrack {
+	tracks := make([]ExportedTrack, 0, len(state.Queue)+1)
+
+	if state.CurrentTrack != nil {
+		var data TrackRequestData
+		state.CurrentTrack.UserData.Unmarshal(&data)
+
+		exported := exportTrack(state.CurrentTrack, data)
+		exported.NowPlaying = true
+		tracks = append(tracks, exported)
+	}
+
+	for i, track := range state.Queue {
+		tracks = append(tracks, exportTrack(track, state.Data[i]))
+	}
+
+	return tracks
+}
+
+func exportTrack(track *lavalink.Track, data TrackRequestData) ExportedTrack {
+	exported := ExportedTrack{
+		Title:     track.Info.Title,
+		Author:    track.Info.Author,
+		Length:    int64(track.Info.Length),
+		Requester: data.AuthorID,
+		Autoplay:  data.Autoplay,
+		Track:     *track,
+	}
+
+	if track.Info.URI != nil {
+		exported.URI = *track.Info.URI
+	}
+
+	// The request data is the session's own business, and would go stale anyway.
+	exported.Track.UserData = nil
+
+	return exported
+}
+
+// requesterName returns who requested an exported track, by name if it's known.
+func requesterName(track ExportedTrack, names map[string]string) string {
+	if track.Autoplay {
+		return "Autoplay"
+	}
+
+	if name, ok := names[track.Requester]; ok {
+		return name
+	}
+
+	return track.Requester
+}
+
+func exportM3U(tracks []ExportedTrack, names map[string]string) []byte {
+	var b bytes.Buffer
+
+	b.WriteString("#EXTM3U\n")
+
+	for _, track := range tracks {
+		seconds := track.Length / 1000
+		if track.Track.Info.IsStream {
+			seconds = -1
+		}
+
+		// Players show the comment, but ignore it otherwise.
+		fmt.Fprintf(&b, "#EXTINF:%d,%s\n", seconds, exportedName(track))
+		fmt.Fprintf(&b, "# Requested by %s\n", oneLine(requesterName(track, names)))
+		location := track.URI
+		if location == "" {
+			location = exportedName(track)
+		}
+
+		fmt.Fprintf(&b, "%s\n", location)
+	}
+
+	return b.Bytes()
+}
+
+func exportText(tracks []ExportedTrack, names map[string]string) []byte {
+	var b bytes.Buffer
+	n := 0
+
+	for _, track := range tracks {
+		length := lavalink.Duration(track.Length).String()
+		if track.Track.Info.IsStream {
+			length = "live"
+		}
+
+		prefix := "Now playing:"
+		if !track.NowPlaying {
+			n++
+			prefix = fmt.Sprintf("%d.", n)
+		}
+
+		fmt.Fprintf(&b, "%s %s (%s), requested by %s\n", prefix,
+			exportedName(track), length, oneLine(requesterName(track, names)))
+
+		if track.URI != "" {
+			fmt.Fprintf(&b, "    %s\n", track.URI)
+		}
+	}
+
+	return b.Bytes()
+}
+
+// exportedName returns the artist and title of an exported track, as most players show them.
+func exportedName(track ExportedTrack) string {
+	if track.Author == "" {
+		return oneLine(track.Title)
+	}
+
+	return oneLine(track.Author + " - " + track.Title)
+}
+
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,59 @@
+package music
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/disgoorg/disgolink/v3/lavalink"
+)
+
+func TestExportQueueJSONRoundTrip(t *testing.T) {
+	current, queued := testTrack("current"), testTrack("queued")
+
+	withData, err := current.WithUserData(TrackRequestData{AuthorID: "1"})
+	if err != nil {
+		t.Fatalf("WithUserData: %v", err)
+	}
+
+	state := SessionState{
+		CurrentTrack: &withData,
+		Queue:        []*lavalink.Track{queued},
+		Data:         []TrackRequestData{{AuthorID: "2"}},
+	}
+
+	data, extension, err := ExportQueue(state, ExportFormatJSON, nil)
+	if err != nil {
+		t.Fatalf("ExportQueue: %v", err)
+	}
+
+	entries, err := ParsePlaylistFile("queue."+extension, data)
+	if err != nil {
+		t.Fatalf("ParsePlaylistFile: %v", err)
+	}
+
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+
+	for i, want := range []string{"current", "queued"} {
+		if entries[i].Track == nil || entries[i].Track.Encoded != want {
+			t.Errorf("entry %d: expected encoded track %q, got %+v", i, want, entries[i].Track)
+		}
+	}
+}
+
+func TestExportQueueM3U(t *testing.T) {
+	state := SessionState{
+		Queue: []*lavalink.Track{testTrack("queued")},
+		Data:  []TrackRequestData{{AuthorID: "2"}},
+	}
+
+	data, _, err := ExportQueue(state, ExportFormatM3U, map[string]string{"2": "someone"})
+	if err != nil {
+		t.Fatalf("ExportQueue: %v", err)
+	}
+
+	if !bytes.Contains(data, []byte("#EXTINF:180,queued\n# Requested by someone\n")) {
+		t.Fatalf("unexpected M3U export:\n%s", data)
+	}
+}
@@ -0,0 +1,153 @@
+package music
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/disgoorg/disgolink/v3/lavalink"
+
+	"unreal.sh/neo/internal/database"
+)
+
+func TestFairInsertIndex(t *testing.T) {
+	tests := []struct {
+		requests string // Who requested each track, in order.
+		expected string // Who requested each queued track, in queue order.
+	}{
+		{"a", "a"},
+		{"aaab", "abaa"},
+		{"aaabbc", "abcaba"},
+		{"abab", "abab"},
+		{"aaabbbc", "abcabab"},
+		{"aaaab", "abaaa"},
+		{"abc", "abc"},
+	}
+
+	for _, test := range tests {
+		var data []TrackRequestData
+		for _, author := range test.requests {
+			request := TrackRequestData{AuthorID: string(author)}
+
+			i := fairInsertIndex(data, request.AuthorID)
+			data = append(data[:i], append([]TrackRequestData{request}, data[i:]...)...)
+		}
+
+		var order string
+		for _, d := range data {
+			order += d.AuthorID
+		}
+
+		if order != test.expected {
+			t.Errorf("requests %q: expected %q, got %q", test.requests, test.expected, order)
+		}
+	}
+}
+
+// queueRequests plays or enqueues a track for each requester, numbering the tracks in order.
+func queueRequests(t *testing.T, session *MusicSession, requesters string) []error {
+	t.Helper()
+
+	errs := make([]error, 0, len(requesters))
+	for i, author := range requesters {
+		_, err := session.PlayOrEnqueue(context.Background(), testTrack(fmt.Sprintf("%c%d", author, i)),
+			TrackRequestData{AuthorID: string(author)})
+		errs = append(errs, err)
+	}
+
+	return errs
+}
+
+func TestFairQueue(t *testing.T) {
+	session := newTestSession(t)
+	ctx := context.Background()
+
+	if err := session.ApplySettings(ctx, database.MusicSettings{FairQueue: true}); err != nil {
+		t.Fatalf("ApplySettings: %v", err)
+	}
+
+	// The first track plays, so the rest take turns in the queue.
+	for _, err := range queueRequests(t, session, "aaaabbc") {
+		if err != nil {
+			t.Fatalf("PlayOrEnqueue: %v", err)
+		}
+	}
+
+	state, err := session.State(ctx)
+	if err != nil {
+		t.Fatalf("State: %v", err)
+	}
+
+	order := make([]string, len(state.Queue))
+	for i, track := range state.Queue {
+		order[i] = track.Info.Identifier
+	}
+
+	if expected := "a1 b4 c6 a2 b5 a3"; strings.Join(order, " ") != expected {
+		t.Fatalf("expected the queue %q, got %q", expected, strings.Join(order, " "))
+	}
+}
+
+func TestUserQueueLimit(t *testing.T) {
+	session := newTestSession(t)
+	ctx := context.Background()
+
+	if err := session.ApplySettings(ctx, database.MusicSettings{MaxUserQueue: 2, MaxQueueLength: 4}); err != nil {
+		t.Fatalf("ApplySettings: %v", err)
+	}
+
+	// The playing track doesn't count towards the limits.
+	errs := queueRequests(t, session, "aaaabbc")
+
+	expected := []error{nil, nil, nil, ErrUserQueueFull, nil, nil, ErrQueueFull}
+	for i, err := range errs {
+		if !errors.Is(err, expected[i]) {
+			t.Errorf("request %d: expected %v, got %v", i, expected[i], err)
+		}
+	}
+}
+
+func TestEnqueueAllLimits(t *testing.T) {
+	tracks := func(n int) []lavalink.Track {
+		tracks := make([]lavalink.Track, n)
+		for i := range tracks {
+			tracks[i] = *testTrack(fmt.Sprintf("track %d", i))
+		}
+
+		return tracks
+	}
+
+	tests := []struct {
+		settings database.MusicSettings
+		tracks   int
+		loaded   int
+		notes    string
+	}{
+		{database.MusicSettings{}, 5, 5, ""},
+		{database.MusicSettings{MaxPlaylistSize: 3}, 5, 3, "Only the first 3 tracks were imported"},
+		{database.MusicSettings{MaxPlaylistSize: 3}, 2, 2, ""},
+		{database.MusicSettings{MaxUserQueue: 2}, 5, 3, "The rest were left out."},
+		{database.MusicSettings{MaxPlaylistSize: 4, MaxQueueLength: 1}, 5, 2, "Only the first 4 tracks were imported"},
+	}
+
+	for _, test := range tests {
+		session := newTestSession(t)
+		ctx := context.Background()
+
+		if err := session.ApplySettings(ctx, test.settings); err != nil {
+			t.Fatalf("ApplySettings: %v", err)
+		}
+
+		loaded, notes := session.EnqueueAll(ctx, "1", test.settings, tracks(test.tracks), "")
+
+		if loaded != test.loaded {
+			t.Errorf("%+v: expected %d of %d tracks to be loaded, got %d", test.settings, test.loaded, test.tracks, loaded)
+		}
+
+		if (test.notes == "") != (notes == "") || !strings.Contains(notes, test.notes) {
+			t.Errorf("%+v: expected notes with %q, got %q", test.settings, test.notes, notes)
+		}
+	}
+}
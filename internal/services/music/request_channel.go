@@ -0,0 +1,397 @@
+package music
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/disgoorg/disgolink/v3/disgolink"
+	"github.com/disgoorg/disgolink/v3/lavalink"
+
+	embedutils "unreal.sh/neo/internal/utils/embedutils"
+	"unreal.sh/neo/internal/utils/static"
+)
+
+const (
+	// RequestQueueSize is how many queued tracks the request channel's panel lists at most.
+	RequestQueueSize = 10
+
+	// RequestReplyLifetime is how long answers to requests stay in the request channel.
+	RequestReplyLifetime = 10 * time.Second
+)
+
+// requestPanelFooter marks the request channel's panel, so it can be found among the channel's pins again.
+const requestPanelFooter = "Type a song's name or link in this channel to play it."
+
+// maxEmbedFieldLength is how long the value of an embed field may be.
+const maxEmbedFieldLength = 1024
+
+// RequestChannel returns the ID of a guild's request channel, or an empty string if it has none.
+func (s *MusicService) RequestChannel(guildID string) string {
+	s.requestMu.RLock()
+	defer s.requestMu.RUnlock()
+
+	return s.requestChannels[guildID]
+}
+
+// SetRequestChannel makes a channel the guild's request channel, or turns the request channel off
+// if channelID is empty. The previous request channel's panel is deleted and the new one gets a
+// panel of its own. Persisting the setting is up to the caller.
+func (s *MusicService) SetRequestChannel(ctx context.Context, guildID string, channelID string) error {
+	previous := s.RequestChannel(guildID)
+	s.rememberRequestChannel(guildID, channelID)
+
+	if previous != "" && previous != channelID {
+		s.deleteRequestPanel(previous)
+	}
+
+	var panel *discordgo.Message
+	if channelID != "" {
+		var err error
+		if panel, err = s.requestPanel(channelID); err != nil {
+			return err
+		}
+	}
+
+	if session := s.GetMusicSession(guildID); session != nil {
+		return session.do(ctx, func() error {
+			session.requestPanel = panel
+			session.updateRequestPanel()
+
+			return nil
+		})
+	}
+
+	return nil
+}
+
+func (s *MusicService) rememberRequestChannel(guildID string, channelID string) {
+	s.requestMu.Lock()
+	defer s.requestMu.Unlock()
+
+	if channelID == "" {
+		delete(s.requestChannels, guildID)
+	} else {
+		s.requestChannels[guildID] = channelID
+	}
+}
+
+// requestPanel returns the panel of a request channel, posting and pinning a new one if it has none.
+func (s *MusicService) requestPanel(channelID string) (*discordgo.Message, error) {
+	// Sessions and settings may look for the panel at the same time, and it should only be posted once.
+	s.requestPanelMu.Lock()
+	defer s.requestPanelMu.Unlock()
+
+	panel, err := s.findRequestPanel(channelID)
+	if err != nil || panel != nil {
+		return panel, err
+	}
+
+	panel, err = s.session.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+		Embeds: []*discordgo.MessageEmbed{idleRequestPanelEmbed()},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// An unpinned panel still works, it just can't be found again after a restart.
+	if err := s.session.ChannelMessagePin(channelID, panel.ID); err != nil {
+		slog.Warn("Failed to pin request channel panel.", slog.String("channel_id", channelID), slog.String("error", err.Error()))
+	}
+
+	return panel, nil
+}
+
+// findRequestPanel looks for the panel among a request channel's pins, returning nil if it isn't there.
+func (s *MusicService) findRequestPanel(channelID string) (*discordgo.Message, error) {
+	pins, err := s.session.ChannelMessagesPinned(channelID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, message := range pins {
+		if isRequestPanel(message, s.session.State.User.ID) {
+			return message, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// deleteRequestPanel deletes the panel of a channel that's no longer the request channel.
+func (s *MusicService) deleteRequestPanel(channelID string) {
+	panel, err := s.findRequestPanel(channelID)
+	if err == nil && panel != nil {
+		err = s.session.ChannelMessageDelete(channelID, panel.ID)
+	}
+
+	if err != nil {
+		slog.Warn("Failed to delete request channel panel.", slog.String("channel_id", channelID), slog.String("error", err.Error()))
+	}
+}
+
+// isRequestPanel returns whether a message is a request channel panel posted by the bot.
+func isRequestPanel(message *discordgo.Message, botID string) bool {
+	if message.Author == nil || message.Author.ID != botID || len(message.Embeds) == 0 {
+		return false
+	}
+
+	footer := message.Embeds[0].Footer
+	return footer != nil && footer.Text == requestPanelFooter
+}
+
+// updateRequestPanel edits the request channel's panel to reflect the session's current state,
+// if the guild has one. Without a track, it shows that nothing is playing and has no buttons.
+func (s *MusicSession) updateRequestPanel() {
+	if s.requestPanel == nil {
+		return
+	}
+
+	components := []discordgo.MessageComponent{}
+	if s.current != nil {
+		components = s.panelComponents()
+	}
+
+	s.panels.editRequestPanel(requestPanelEdit{
+		message:    s.requestPanel,
+		embeds:     []*discordgo.MessageEmbed{s.requestPanelEmbed()},
+		components: components,
+	})
+	s.panelUpdated = time.Now()
+}
+
+// inRequestChannel returns whether the session's text channel is the guild's request channel.
+func (s *MusicSession) inRequestChannel() bool {
+	return s.requestPanel != nil && s.requestPanel.ChannelID == s.textChannelID
+}
+
+// requestPanelEmbed renders the current track like the player panel does, but lists the queue.
+func (s *MusicSession) requestPanelEmbed() *discordgo.MessageEmbed {
+	if s.current == nil {
+		return idleRequestPanelEmbed()
+	}
+
+	embed := s.nowPlayingEmbed(s.queueList(RequestQueueSize))
+	embed.Footer = &discordgo.MessageEmbedFooter{Text: requestPanelFooter}
+
+	return embed
+}
+
+func idleRequestPanelEmbed() *discordgo.MessageEmbed {
+	embed := idlePanelEmbed()
+	embed.Footer = &discordgo.MessageEmbedFooter{Text: requestPanelFooter}
+
+	return embed
+}
+
+// queueList lists up to limit tracks of the queue, as many as fit into an embed field.
+func (s *MusicSession) queueList(limit int) string {
+	if len(s.queue) == 0 {
+		return "Nothing"
+	}
+
+	var list strings.Builder
+	for i, track := range s.queue {
+		more := fmt.Sprintf("and %d more", len(s.queue)-i)
+		line := fmt.Sprintf("**%d.** %s — %s\n", i+1, TrackLink(track), s.data[i].Requester())
+
+		if i == limit || list.Len()+len(line)+len(more) > maxEmbedFieldLength {
+			list.WriteString(more)
+			break
+		}
+
+		list.WriteString(line)
+	}
+
+	return strings.TrimSuffix(list.String(), "\n")
+}
+
+// Events
+
+// onGuildCreate is called when a guild becomes available, like after the bot connected.
+// It remembers the guild's request channel, so messages don't need a database query each to tell whether
+// they're requests. Unless something is playing, the panel is reset, since it may be left over from before a restart.
+// It needs to be hooked to the bot's events via MusicService.HookEvents().
+func (s *MusicService) onGuildCreate(session *discordgo.Session, event *discordgo.GuildCreate) {
+	channelID := s.MusicSettings(event.ID).RequestChannelID
+	s.rememberRequestChannel(event.ID, channelID)
+
+	if channelID == "" || s.GetMusicSession(event.ID) != nil {
+		return
+	}
+
+	panel, err := s.requestPanel(channelID)
+	if err == nil {
+		embeds := []*discordgo.MessageEmbed{idleRequestPanelEmbed()}
+		components := []discordgo.MessageComponent{}
+
+		_, err = s.session.ChannelMessageEditComplex(&discordgo.MessageEdit{
+			ID:         panel.ID,
+			Channel:    panel.ChannelID,
+			Embeds:     &embeds,
+			Components: &components,
+		})
+	}
+
+	if err != nil {
+		slog.Warn("Failed to reset request channel panel.", slog.String("guild_id", event.ID), slog.String("error", err.Error()))
+	}
+}
+
+// onMessageCreate plays messages posted in a guild's request channel, as if they were passed to /play.
+// The messages are deleted, so the channel only holds its panel and short-lived answers.
+// It needs to be hooked to the bot's events via MusicService.HookEvents().
+func (s *MusicService) onMessageCreate(session *discordgo.Session, event *discordgo.MessageCreate) {
+	if event.GuildID == "" || event.Author == nil || event.Author.Bot || event.ChannelID != s.RequestChannel(event.GuildID) {
+		return
+	}
+
+	if err := session.ChannelMessageDelete(event.ChannelID, event.ID); err != nil {
+		slog.Warn("Failed to delete request.", slog.String("guild_id", event.GuildID), slog.String("error", err.Error()))
+	}
+
+	query := strings.TrimSpace(event.Content)
+	if query == "" {
+		return
+	}
+
+	s.replyBriefly(event.ChannelID, s.request(event.Message, query))
+}
+
+// request joins the author's voice channel and plays or enqueues the tracks found for query,
+// returning the answer to the request.
+func (s *MusicService) request(message *discordgo.Message, query string) *discordgo.MessageEmbed {
+	guildID := message.GuildID
+	userID := message.Author.ID
+
+	state, err := s.session.State.VoiceState(guildID, userID)
+	if err != nil || state.ChannelID == "" {
+		return embedutils.CreateErrorEmbed("You need to be in a voice channel to request songs.")
+	}
+
+	err = s.session.ChannelVoiceJoinManual(guildID, state.ChannelID, false, false)
+	if err != nil {
+		slog.Error("Failed to join voice channel.", slog.String("guild_id", guildID), slog.String("error", err.Error()))
+		return embedutils.CreateErrorEmbed("Failed to join your voice channel.")
+	}
+
+	// Requests can force a search source by prefixing the query, like "soundcloud:lofi".
+	sources := s.SearchSources(guildID)
+	if prefixed, rest, ok := SplitSourcePrefix(query); ok {
+		sources = []string{prefixed.ID}
+		query = rest
+	}
+
+	musicSession := s.MusicSession(guildID, message.ChannelID)
+	if musicSession == nil {
+		return embedutils.CreateErrorEmbed("Failed to play or enqueue track.")
+	}
+
+	settings := s.MusicSettings(guildID)
+
+	ctx, cancel := s.OperationContext()
+	defer cancel()
+
+	result, usedSource, err := s.LoadTracks(ctx, query, sources)
+
+	var answer *discordgo.MessageEmbed
+
+	playOrEnqueue := func(track lavalink.Track) {
+		request := NewTrackRequestData(userID, nil, usedSource)
+
+		enqueued, err := musicSession.PlayOrEnqueue(ctx, &track, request)
+		if rejected := RejectionMessage(settings, err); rejected != "" {
+			answer = embedutils.CreateErrorEmbed(rejected)
+			return
+		} else if err != nil {
+			slog.Error("Failed to play or enqueue track.", slog.String("guild_id", guildID), slog.String("error", err.Error()))
+			answer = embedutils.CreateErrorEmbed("Failed to play or enqueue track.")
+			return
+		}
+
+		answer = requestedEmbed(&track, request, enqueued)
+	}
+
+	HandleLoadResult(result, err, disgolink.NewResultHandler(
+		playOrEnqueue,
+
+		func(playlist lavalink.Playlist) {
+			loaded, notes := musicSession.EnqueueAll(ctx, userID, settings, playlist.Tracks, usedSource)
+
+			description := fmt.Sprintf("Loaded playlist **%s** with %d tracks.\n\n", playlist.Info.Name, loaded)
+			if loaded == 1 {
+				description = fmt.Sprintf("Loaded playlist **%s** with %d track.\n\n", playlist.Info.Name, loaded)
+			}
+
+			answer = embedutils.CreateBasicEmbed(description + notes)
+		},
+
+		func(tracks []lavalink.Track) {
+			if len(tracks) == 0 {
+				answer = embedutils.CreateErrorEmbed(fmt.Sprintf("No results found for \"%s\".", query))
+				return
+			}
+
+			playOrEnqueue(tracks[0])
+		},
+
+		func() {
+			answer = embedutils.CreateErrorEmbed(fmt.Sprintf("No results found for \"%s\".", query))
+		},
+
+		func(err error) {
+			slog.Error("Something went wrong while loading the track.", slog.String("guild_id", guildID), slog.String("error", err.Error()))
+			answer = embedutils.CreateErrorEmbed("Something went wrong while loading the track.")
+		},
+	))
+
+	return answer
+}
+
+// requestedEmbed tells the requester whether their track started playing or was enqueued.
+func requestedEmbed(track *lavalink.Track, request TrackRequestData, enqueued bool) *discordgo.MessageEmbed {
+	action := "🎶 **Playing**"
+	if enqueued {
+		action = "▶ **Enqueued**"
+	}
+
+	description := fmt.Sprintf("%s %s for <@%s>", action, TrackLink(track), request.AuthorID)
+
+	if source, ok := LookupSearchSource(request.Source); ok {
+		description += fmt.Sprintf(" from %s", source.Name)
+	} else if request.Source == LibrarySource {
+		description += " from the library"
+	}
+
+	return &discordgo.MessageEmbed{
+		Color:       static.ColorEmbedGray,
+		Description: description,
+	}
+}
+
+// replyBriefly sends an answer to the request channel, deleting it again after RequestReplyLifetime.
+func (s *MusicService) replyBriefly(channelID string, embed *discordgo.MessageEmbed) {
+	if embed == nil {
+		return
+	}
+
+	message, err := s.session.ChannelMessageSendEmbed(channelID, embed)
+	if err != nil {
+		slog.Warn("Failed to answer request.", slog.String("channel_id", channelID), slog.String("error", err.Error()))
+		return
+	}
+
+	deleteAfter(s.session, message, RequestReplyLifetime)
+}
+
+// deleteAfter deletes a message once d has passed.
+func deleteAfter(session *discordgo.Session, message *discordgo.Message, d time.Duration) {
+	time.AfterFunc(d, func() {
+		if err := session.ChannelMessageDelete(message.ChannelID, message.ID); err != nil {
+			slog.Warn("Failed to delete message.", slog.String("channel_id", message.ChannelID), slog.String("error", err.Error()))
+		}
+	})
+}
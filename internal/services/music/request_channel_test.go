@@ -0,0 +1,68 @@
+package music
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestRequestPanelQueueList(t *testing.T) {
+	session := &MusicSession{}
+
+	if list := session.queueList(RequestQueueSize); list != "Nothing" {
+		t.Fatalf("expected an empty queue to list nothing, got %q", list)
+	}
+
+	for i := 0; i < 15; i++ {
+		track := testTrack(fmt.Sprintf("track %d", i))
+		uri := "https://example.com/" + track.Encoded
+		track.Info.URI = &uri
+
+		session.queue = append(session.queue, track)
+		session.data = append(session.data, NewTrackRequestData("1", nil, ""))
+	}
+
+	list := session.queueList(RequestQueueSize)
+	lines := strings.Split(list, "\n")
+
+	if len(lines) != RequestQueueSize+1 || lines[RequestQueueSize] != "and 5 more" {
+		t.Fatalf("expected %d tracks and the rest counted, got %q", RequestQueueSize, list)
+	}
+
+	// Long links would overflow the embed field, so fewer tracks are listed.
+	for _, track := range session.queue {
+		uri := *track.Info.URI + "?" + strings.Repeat("x", 200)
+		track.Info.URI = &uri
+	}
+
+	list = session.queueList(RequestQueueSize)
+	if len(list) > maxEmbedFieldLength || !strings.HasSuffix(list, "more") {
+		t.Fatalf("expected the list to fit into an embed field, got %d characters: %q", len(list), list)
+	}
+}
+
+func TestIsRequestPanel(t *testing.T) {
+	panel := &discordgo.Message{
+		Author: &discordgo.User{ID: "1"},
+		Embeds: []*discordgo.MessageEmbed{idleRequestPanelEmbed()},
+	}
+
+	if !isRequestPanel(panel, "1") {
+		t.Fatal("expected the bot's panel to be found")
+	}
+
+	if isRequestPanel(panel, "2") {
+		t.Fatal("expected someone else's message not to be taken for the panel")
+	}
+
+	playerPanel := &discordgo.Message{
+		Author: &discordgo.User{ID: "1"},
+		Embeds: []*discordgo.MessageEmbed{idlePanelEmbed()},
+	}
+
+	if isRequestPanel(playerPanel, "1") {
+		t.Fatal("expected a regular player panel not to be taken for the request panel")
+	}
+}
@@ -1,15 +1,18 @@
 package music
 
 import (
+	"fmt"
 	"time"
 )
 
 type TrackRequestData struct {
 	RequestedAt time.Time `json:"requested_at"`
 	AuthorID    string    `json:"author_id"`
+	Source      string    `json:"source,omitempty"`   // ID of the search source the track was found with, if any.
+	Autoplay    bool      `json:"autoplay,omitempty"` // Whether autoplay picked the track, rather than someone requesting it.
 }
 
-func NewTrackRequestData(authorID string, timestamp *time.Time) TrackRequestData {
+func NewTrackRequestData(authorID string, timestamp *time.Time, source string) TrackRequestData {
 	var t time.Time
 	if timestamp == nil {
 		t = time.Now()
@@ -20,5 +23,15 @@ func NewTrackRequestData(authorID string, timestamp *time.Time) TrackRequestData
 	return TrackRequestData{
 		RequestedAt: t,
 		AuthorID:    authorID,
+		Source:      source,
 	}
 }
+
+// Requester describes who requested the track, for messages.
+func (d TrackRequestData) Requester() string {
+	if d.Autoplay {
+		return "📻 Autoplay"
+	}
+
+	return fmt.Sprintf("<@%s>", d.AuthorID)
+}
@@ -0,0 +1,278 @@
+package music
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/disgoorg/disgolink/v3/disgolink"
+	"github.com/disgoorg/disgolink/v3/lavalink"
+	"github.com/disgoorg/snowflake/v2"
+
+	"unreal.sh/neo/internal/database"
+)
+
+// ResumeTimeout is how long Lavalink keeps the bot's players playing after the bot disconnected,
+// for the bot to come back and resume its session, like after restarting for a deploy.
+const ResumeTimeout = 2 * time.Minute
+
+// AddNode connects to a Lavalink node and has it keep the bot's players playing for ResumeTimeout
+// whenever the bot disconnects. If the bot shut down while playing, the node's previous session is
+// resumed and the music sessions that were playing on it are picked up where they are.
+func (s *MusicService) AddNode(ctx context.Context, config disgolink.NodeConfig) (disgolink.Node, error) {
+	snapshots, err := s.db.GetMusicSessionSnapshots(config.Name)
+	if err != nil {
+		slog.Error("Failed to get music session snapshots.", slog.String("node", config.Name), slog.String("error", err.Error()))
+	}
+
+	if config.SessionID == "" {
+		config.SessionID = latestLavalinkSession(snapshots)
+	}
+
+	node, err := s.LavalinkClient.AddNode(ctx, config)
+	if err != nil {
+		return node, err
+	}
+
+	resuming, timeout := true, int(ResumeTimeout.Seconds())
+
+	err = node.Update(ctx, lavalink.SessionUpdate{Resuming: &resuming, Timeout: &timeout})
+	if err != nil {
+		slog.Warn("Failed to enable Lavalink session resuming.", slog.String("node", config.Name), slog.String("error", err.Error()))
+	}
+
+	// Only a resumed Lavalink session has players left to pick up.
+	if config.SessionID != "" && node.SessionID() == config.SessionID {
+		s.resumeSessions(ctx, node, snapshots)
+	}
+
+	if len(snapshots) == 0 {
+		return node, nil
+	}
+
+	// Whatever wasn't picked up now can't be anymore.
+	if err := s.db.DeleteMusicSessionSnapshots(config.Name); err != nil {
+		slog.Error("Failed to delete music session snapshots.", slog.String("node", config.Name), slog.String("error", err.Error()))
+	}
+
+	return node, nil
+}
+
+// latestLavalinkSession returns the Lavalink session of the most recent snapshot, or an empty string if there's none.
+func latestLavalinkSession(snapshots []database.MusicSessionSnapshot) string {
+	var latest database.MusicSessionSnapshot
+	for _, snapshot := range snapshots {
+		if snapshot.SavedAt.After(latest.SavedAt) {
+			latest = snapshot
+		}
+	}
+
+	return latest.LavalinkSessionID
+}
+
+// resumeSessions rebuilds the sessions of the snapshots whose players the node still has.
+// Players are gone if the session couldn't be resumed, like when Lavalink restarted too.
+// Players without a snapshot, like those of guilds where nothing was playing, have no session
+// to go back to, so they're destroyed and the bot leaves their voice channels.
+func (s *MusicService) resumeSessions(ctx context.Context, node disgolink.Node, snapshots []database.MusicSessionSnapshot) {
+	players, err := node.Rest().Players(ctx, node.SessionID())
+	if err != nil {
+		slog.Error("Failed to get Lavalink players.", slog.String("node", node.Config().Name), slog.String("error", err.Error()))
+		return
+	}
+
+	byGuild := make(map[snowflake.ID]lavalink.Player, len(players))
+	for _, player := range players {
+		byGuild[player.GuildID] = player
+	}
+
+	claimed := make(map[snowflake.ID]bool, len(snapshots))
+
+	for _, snapshot := range snapshots {
+		guildID, err := snowflake.Parse(snapshot.GuildID)
+		if err != nil {
+			continue
+		}
+
+		claimed[guildID] = true
+
+		player, ok := byGuild[guildID]
+		if !ok || snapshot.LavalinkSessionID != node.SessionID() {
+			continue
+		}
+
+		musicSession := s.MusicSession(snapshot.GuildID, snapshot.TextChannelID)
+		if musicSession == nil {
+			continue
+		}
+
+		err = musicSession.do(ctx, func() error {
+			return musicSession.resume(ctx, snapshot, player)
+		})
+		if err != nil {
+			slog.Error("Failed to resume music session.", slog.String("guild_id", snapshot.GuildID), slog.String("error", err.Error()))
+			continue
+		}
+
+		slog.Info("Resumed music session.", slog.String("guild_id", snapshot.GuildID))
+	}
+
+	for guildID := range byGuild {
+		if !claimed[guildID] {
+			s.abandonPlayer(ctx, node, guildID)
+		}
+	}
+}
+
+// abandonPlayer destroys a player left on a resumed Lavalink session that no session picks up again,
+// and has the bot leave the voice channel it played in.
+func (s *MusicService) abandonPlayer(ctx context.Context, node disgolink.Node, guildID snowflake.ID) {
+	if err := node.Rest().DestroyPlayer(ctx, node.SessionID(), guildID); err != nil {
+		slog.Warn("Failed to destroy abandoned Lavalink player.", slog.String("guild_id", guildID.String()), slog.String("error", err.Error()))
+	}
+
+	// Discord keeps the bot in the voice channel until the gateway session it joined with times out.
+	if _, err := s.session.State.VoiceState(guildID.String(), s.session.State.User.ID); err != nil {
+		return
+	}
+
+	if err := s.session.ChannelVoiceJoinManual(guildID.String(), "", false, false); err != nil {
+		slog.Warn("Failed to leave voice channel.", slog.String("guild_id", guildID.String()), slog.String("error", err.Error()))
+	}
+}
+
+// saveSessions stores snapshots of the sessions playing on a Lavalink node, for AddNode to resume them
+// once the bot is back. Their players keep playing in the meantime.
+func (s *MusicService) saveSessions() {
+	snapshots := make([]database.MusicSessionSnapshot, 0)
+
+	for _, musicSession := range s.musicSessions() {
+		guildID, err := snowflake.Parse(musicSession.GuildID)
+		if err != nil {
+			continue
+		}
+
+		player := s.LavalinkClient.ExistingPlayer(guildID)
+		if player == nil || player.Node() == nil {
+			continue
+		}
+
+		ctx, cancel := s.OperationContext()
+		snapshot, ok, err := musicSession.Snapshot(ctx)
+		cancel()
+
+		if err != nil {
+			slog.Error("Failed to snapshot music session.", slog.String("guild_id", musicSession.GuildID), slog.String("error", err.Error()))
+			continue
+		}
+
+		if !ok {
+			continue
+		}
+
+		snapshot.LavalinkNode = player.Node().Config().Name
+		snapshot.LavalinkSessionID = player.Node().SessionID()
+
+		snapshots = append(snapshots, snapshot)
+	}
+
+	if len(snapshots) == 0 {
+		return
+	}
+
+	if err := s.db.SaveMusicSessionSnapshots(snapshots); err != nil {
+		slog.Error("Failed to save music session snapshots.", slog.String("error", err.Error()))
+	}
+}
+
+// Snapshot returns what's needed to resume the session after the bot restarted,
+// besides what Lavalink keeps. It returns false if nothing is playing, since there's nothing to resume then.
+func (s *MusicSession) Snapshot(ctx context.Context) (snapshot database.MusicSessionSnapshot, ok bool, err error) {
+	err = s.do(ctx, func() error {
+		if s.current == nil {
+			return nil
+		}
+
+		snapshot = database.MusicSessionSnapshot{
+			GuildID:        s.GuildID,
+			TextChannelID:  s.textChannelID,
+			VoiceChannelID: s.voiceChannelID,
+			Queue:          make([]lavalink.Track, 0, len(s.queue)),
+			Loop:           int(s.loop),
+			SavedAt:        time.Now(),
+		}
+
+		for i, track := range s.queue {
+			withData, err := track.WithUserData(s.data[i])
+			if err != nil {
+				return err
+			}
+
+			snapshot.Queue = append(snapshot.Queue, withData)
+		}
+
+		if panel := s.panels.message(); panel != nil && panel.ChannelID == s.textChannelID {
+			snapshot.PanelMessageID = panel.ID
+		}
+
+		if s.sleepTimer != nil {
+			mode := int(s.sleepTimer.Mode)
+			snapshot.SleepMode = &mode
+			snapshot.SleepAt = s.sleepTimer.At
+		}
+
+		ok = true
+
+		return nil
+	})
+
+	return snapshot, ok, err
+}
+
+// resume picks up the session from a snapshot and the state of its player, which Lavalink kept playing
+// while the bot was away. If the track ended in the meantime, the queue carries on.
+func (s *MusicSession) resume(ctx context.Context, snapshot database.MusicSessionSnapshot, player lavalink.Player) error {
+	s.textChannelID = snapshot.TextChannelID
+	s.voiceChannelID = snapshot.VoiceChannelID
+	s.loop = LoopMode(snapshot.Loop)
+
+	for i := range snapshot.Queue {
+		s.requeue(&snapshot.Queue[i])
+	}
+
+	s.filters = player.Filters
+	s.playerState = player.State
+	s.playerPaused = player.Paused
+
+	if snapshot.PanelMessageID != "" {
+		s.panels.setMessage(&discordgo.Message{ID: snapshot.PanelMessageID, ChannelID: snapshot.TextChannelID})
+	}
+
+	if snapshot.SleepMode != nil {
+		s.setSleepTimer(SleepMode(*snapshot.SleepMode), max(time.Until(snapshot.SleepAt), 0))
+	}
+
+	// The bot's new gateway connection has to take over the voice connection before the old one times out.
+	if err := s.joinVoice(); err != nil {
+		slog.Warn("Failed to rejoin voice channel.", slog.String("guild_id", s.GuildID), slog.String("error", err.Error()))
+	}
+
+	if player.Track == nil {
+		if len(s.queue) == 0 {
+			return nil
+		}
+
+		next, data := s.dequeue()
+
+		return s.play(ctx, next, data)
+	}
+
+	s.current = player.Track
+	s.remember(player.Track)
+	s.startListening(player.Track)
+	s.colorPanel(player.Track)
+	s.refreshPanel()
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package music
+
+import (
+	"github.com/disgoorg/disgolink/v3/lavalink"
+)
+
+const (
+	// MaxSavedPlaylistTracks is how many tracks a saved playlist can hold.
+	MaxSavedPlaylistTracks = 500
+
+	// MaxPlaylistNameLength is how long a saved playlist's name can be.
+	MaxPlaylistNameLength = 50
+)
+
+// Tracks returns the current track, if there is one, followed by the queue.
+func (s SessionState) Tracks() []lavalink.Track {
+	tracks := make([]lavalink.Track, 0, len(s.Queue)+1)
+	if s.CurrentTrack != nil {
+		tracks = append(tracks, *s.CurrentTrack)
+	}
+
+	for _, track := range s.Queue {
+		tracks = append(tracks, *track)
+	}
+
+	return tracks
+}
+
+// SavedTracks returns tracks the way saved playlists keep them, without the
+// request data sessions attach to them, and no more than MaxSavedPlaylistTracks.
+func SavedTracks(tracks []lavalink.Track) []lavalink.Track {
+	if len(tracks) > MaxSavedPlaylistTracks {
+		tracks = tracks[:MaxSavedPlaylistTracks]
+	}
+
+	saved := make([]lavalink.Track, len(tracks))
+	for i, track := range tracks {
+		track.UserData = nil
+		saved[i] = track
+	}
+
+	return saved
+}
+
+// AppendSavedTracks adds tracks to the end of a saved playlist's, as many as it has room for.
+// It returns the playlist's tracks along with how many of the tracks were added.
+func AppendSavedTracks(playlist []lavalink.Track, tracks []lavalink.Track) ([]lavalink.Track, int) {
+	if room := max(MaxSavedPlaylistTracks-len(playlist), 0); len(tracks) > room {
+		tracks = tracks[:room]
+	}
+
+	return append(playlist, SavedTracks(tracks)...), len(tracks)
+}
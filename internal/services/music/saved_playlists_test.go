@@ -0,0 +1,67 @@
+package music
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/disgoorg/disgolink/v3/lavalink"
+)
+
+func testTracks(n int) []lavalink.Track {
+	tracks := make([]lavalink.Track, n)
+	for i := range tracks {
+		tracks[i] = *testTrack(fmt.Sprintf("track %d", i))
+		tracks[i].UserData = []byte(`{"requester":"someone"}`)
+	}
+
+	return tracks
+}
+
+func TestSavedTracks(t *testing.T) {
+	saved := SavedTracks(testTracks(MaxSavedPlaylistTracks + 5))
+
+	if len(saved) != MaxSavedPlaylistTracks {
+		t.Fatalf("expected %d tracks to be saved, got %d", MaxSavedPlaylistTracks, len(saved))
+	}
+
+	if saved[0].Encoded != "track 0" || saved[len(saved)-1].Encoded != fmt.Sprintf("track %d", MaxSavedPlaylistTracks-1) {
+		t.Fatalf("expected the first tracks to be saved, got %q to %q", saved[0].Encoded, saved[len(saved)-1].Encoded)
+	}
+
+	for _, track := range saved {
+		if track.UserData != nil {
+			t.Fatalf("expected saved tracks to lose their request data, got %s", track.UserData)
+		}
+	}
+}
+
+func TestAppendSavedTracks(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing int
+		adding   int
+		added    int
+	}{
+		{"room to spare", 10, 3, 3},
+		{"fills up", MaxSavedPlaylistTracks - 2, 5, 2},
+		{"already full", MaxSavedPlaylistTracks, 1, 0},
+		{"empty playlist", 0, MaxSavedPlaylistTracks + 1, MaxSavedPlaylistTracks},
+	}
+
+	for _, test := range tests {
+		tracks, added := AppendSavedTracks(SavedTracks(testTracks(test.existing)), testTracks(test.adding))
+
+		if added != test.added || len(tracks) != test.existing+test.added {
+			t.Errorf("%s: expected %d added to %d tracks, got %d added and %d tracks",
+				test.name, test.added, test.existing, added, len(tracks))
+			continue
+		}
+
+		for _, track := range tracks[test.existing:] {
+			if track.UserData != nil {
+				t.Errorf("%s: expected added tracks to lose their request data, got %s", test.name, track.UserData)
+				break
+			}
+		}
+	}
+}
@@ -2,31 +2,74 @@ package music
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
+	"sync"
+	"time"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/disgoorg/disgolink/v3/disgolink"
 	"github.com/disgoorg/disgolink/v3/lavalink"
 	"github.com/disgoorg/snowflake/v2"
+
+	"unreal.sh/neo/internal/database"
+	"unreal.sh/neo/internal/utils/datetime"
+	sliceutils "unreal.sh/neo/internal/utils/sliceutils"
 )
 
+// OperationTimeout bounds how long a single music operation, like loading or skipping a track, may take.
+const OperationTimeout = 30 * time.Second
+
+// StartupTimeout bounds how long adding a Lavalink node may take when the bot starts,
+// which includes resuming every session the node kept playing.
+const StartupTimeout = 2 * time.Minute
+
 type MusicService struct {
+	ctx     context.Context // Cancelled when the service closes, which also closes every session.
+	cancel  context.CancelFunc
 	session *discordgo.Session
+	db      Store
 
 	LavalinkClient disgolink.Client
-	MusicSessions  map[string]*MusicSession // GuildID -> MusicSession
+	lavalink       Lavalink // What sessions play through, backed by LavalinkClient.
+
+	library *Library       // The local audio library, if one was opened.
+	artwork *ArtworkColors // The colors of track artworks, shared by every session's panel.
+	tracks  *TrackCache    // What queries and links loaded, shared by every way of loading tracks.
+
+	sessionsMu sync.RWMutex
+	sessions   map[string]*MusicSession // GuildID -> MusicSession
+
+	requestMu       sync.RWMutex
+	requestChannels map[string]string // GuildID -> ID of the guild's request channel
+	requestPanelMu  sync.Mutex        // Held while looking for or posting a request channel's panel.
+
+	history     chan historyWrite // Played tracks waiting to be stored, one at a time.
+	historyDone chan struct{}     // Closed once the played tracks were stored.
 }
 
-func NewMusicService(session *discordgo.Session) (*MusicService, error) {
+// NewMusicService creates the music service. Its sessions live until ctx is cancelled or Close is called.
+func NewMusicService(ctx context.Context, session *discordgo.Session, db Store) (*MusicService, error) {
 	userID, err := snowflake.Parse(session.State.User.ID)
 	if err != nil {
 		return &MusicService{}, err
 	}
 
+	ctx, cancel := context.WithCancel(ctx)
+
 	service := &MusicService{
+		ctx:     ctx,
+		cancel:  cancel,
 		session: session,
+		db:      db,
+
+		artwork:  NewArtworkColors(ArtworkCacheSize),
+		sessions: make(map[string]*MusicSession),
 
-		MusicSessions: make(map[string]*MusicSession),
+		requestChannels: make(map[string]string),
+
+		history:     make(chan historyWrite, HistoryQueueSize),
+		historyDone: make(chan struct{}),
 	}
 
 	client := disgolink.New(userID,
@@ -41,21 +84,54 @@ func NewMusicService(session *discordgo.Session) (*MusicService, error) {
 	)
 
 	service.LavalinkClient = client
+	service.lavalink = disgolinkClient{client}
+	service.tracks = NewTrackCache(service.lavalink, TrackCacheSize, TrackCacheTTL)
+
+	go service.writeHistory()
 
 	return service, nil
 }
 
+// Close stops every session and waits for their event loops to finish.
+// What's playing is saved first, and Lavalink keeps playing it for ResumeTimeout once the bot exits,
+// so the sessions can be resumed if the bot comes back by then.
+func (s *MusicService) Close() {
+	// Sessions of a service that closed already are over, with nothing left to save.
+	if s.ctx.Err() == nil {
+		s.saveSessions()
+	}
+
+	s.cancel()
+
+	// Sessions remove themselves when the bot is disconnected, which they can't do while we hold the lock.
+	for _, session := range s.musicSessions() {
+		<-session.Done()
+	}
+
+	<-s.historyDone
+}
+
+// OperationContext returns a context for a music operation started by a command or event,
+// which ends after OperationTimeout or once the service closes.
+func (s *MusicService) OperationContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(s.ctx, OperationTimeout)
+}
+
 func (s *MusicService) HookEvents() {
 	s.session.AddHandler(s.onVoiceStateUpdate)
 	s.session.AddHandler(s.onVoiceServerUpdate)
-}
-
-func (s *MusicService) AddNode(ctx context.Context, config disgolink.NodeConfig) (disgolink.Node, error) {
-	return s.LavalinkClient.AddNode(ctx, config)
+	s.session.AddHandler(s.onReady)
+	s.session.AddHandler(s.onResumed)
+	s.session.AddHandler(s.onInteractionCreate)
+	s.session.AddHandler(s.onGuildCreate)
+	s.session.AddHandler(s.onMessageCreate)
 }
 
 func (s *MusicService) GetMusicSession(guildID string) *MusicSession {
-	session, exists := s.MusicSessions[guildID]
+	s.sessionsMu.RLock()
+	defer s.sessionsMu.RUnlock()
+
+	session, exists := s.sessions[guildID]
 	if !exists {
 		return nil
 	}
@@ -63,25 +139,208 @@ func (s *MusicService) GetMusicSession(guildID string) *MusicSession {
 	return session
 }
 
+// musicSessions returns every session there is.
+func (s *MusicService) musicSessions() []*MusicSession {
+	s.sessionsMu.RLock()
+	defer s.sessionsMu.RUnlock()
+
+	sessions := make([]*MusicSession, 0, len(s.sessions))
+	for _, session := range s.sessions {
+		sessions = append(sessions, session)
+	}
+
+	return sessions
+}
+
+// SessionCount returns how many guilds have a music session.
+func (s *MusicService) SessionCount() int {
+	s.sessionsMu.RLock()
+	defer s.sessionsMu.RUnlock()
+
+	return len(s.sessions)
+}
+
+// removeMusicSession forgets a session that's over and stops its event loop.
+func (s *MusicService) removeMusicSession(session *MusicSession) {
+	s.sessionsMu.Lock()
+	if s.sessions[session.GuildID] == session {
+		delete(s.sessions, session.GuildID)
+	}
+	s.sessionsMu.Unlock()
+
+	session.cancel()
+}
+
 // MusicSession returns the music session for the passed guild ID.
 // If no session exists, a new one will be created.
 func (s *MusicService) MusicSession(guildID string, textChannelID string) *MusicSession {
-	session, exists := s.MusicSessions[guildID]
+	if session := s.GetMusicSession(guildID); session != nil {
+		return session
+	}
 
 	guildSnowflake, err := snowflake.Parse(guildID)
 	if err != nil {
 		return nil
 	}
 
-	if !exists {
-		player := s.LavalinkClient.Player(guildSnowflake)
-		session = NewMusicSession(s.session, &player, guildID, textChannelID)
-		s.MusicSessions[guildID] = session
+	s.sessionsMu.Lock()
+	defer s.sessionsMu.Unlock()
+
+	// Someone else may have created it while we weren't holding the lock.
+	if session, exists := s.sessions[guildID]; exists {
+		return session
+	}
+
+	player := s.lavalink.Player(guildSnowflake)
+	session := newMusicSession(s.ctx, s.session, player, s.lavalink, guildID, textChannelID)
+	session.artwork = s.artwork
+	session.tracks = s.tracks
+	session.onHistory = func(entry HistoryEntry) {
+		s.queueHistory(guildID, entry)
 	}
+	session.onGone = func(ctx context.Context) {
+		s.lavalink.OnVoiceStateUpdate(ctx, guildSnowflake, nil, "")
+		s.removeMusicSession(session)
+	}
+
+	// The bot joins the voice channel before the session is created, so it never hears about it.
+	if state, err := s.session.State.VoiceState(guildID, s.session.State.User.ID); err == nil {
+		session.voiceChannelID = state.ChannelID
+	}
+
+	// Restoring the session is the first thing its loop does, before anyone else gets to use it.
+	// The loop starts right away rather than waiting for the restore, which would hold up everyone waiting for the lock.
+	session.start(func() { s.restore(session) })
+
+	s.sessions[guildID] = session
 
 	return session
 }
 
+// MusicSettings returns the music settings of a guild,
+// or the zero value if they couldn't be fetched.
+func (s *MusicService) MusicSettings(guildID string) database.MusicSettings {
+	settings, _, err := s.db.GetGuildSettings(guildID)
+	if err != nil {
+		slog.Error("Failed to get guild settings.", slog.String("guild_id", guildID), slog.String("error", err.Error()))
+	}
+
+	return settings.MusicSettings
+}
+
+// IsDJ returns whether a member may control playback for everyone.
+// Server managers always can, as can everyone when the guild has no DJ role.
+// Skipping without a vote has its own rules, see SkipsRightAway.
+func (s *MusicService) IsDJ(guildID string, member *discordgo.Member) bool {
+	if member.Permissions&(discordgo.PermissionAdministrator|discordgo.PermissionManageServer) != 0 {
+		return true
+	}
+
+	settings := s.MusicSettings(guildID)
+	if settings.DJRoleID == "" {
+		return true
+	}
+
+	return sliceutils.Contains(member.Roles, settings.DJRoleID)
+}
+
+// SkipsRightAway returns whether a member may skip a track without a vote: server managers,
+// members with the DJ role and whoever requested the track may. Without a DJ role, everyone else
+// has to vote, even though they may use the commands that need a DJ.
+func (s *MusicService) SkipsRightAway(guildID string, member *discordgo.Member, requesterID string) bool {
+	if member.User != nil && member.User.ID == requesterID {
+		return true
+	}
+
+	if member.Permissions&(discordgo.PermissionAdministrator|discordgo.PermissionManageServer) != 0 {
+		return true
+	}
+
+	roleID := s.MusicSettings(guildID).DJRoleID
+
+	return roleID != "" && sliceutils.Contains(member.Roles, roleID)
+}
+
+// Listeners returns the IDs of the users, not counting bots, in the bot's voice channel of a guild.
+func (s *MusicService) Listeners(guildID string) []string {
+	guild, err := s.session.State.Guild(guildID)
+	if err != nil {
+		return []string{}
+	}
+
+	var channelID string
+	for _, state := range guild.VoiceStates {
+		if state.UserID == s.session.State.User.ID {
+			channelID = state.ChannelID
+		}
+	}
+
+	listeners := make([]string, 0)
+	if channelID == "" {
+		return listeners
+	}
+
+	for _, state := range guild.VoiceStates {
+		if state.ChannelID != channelID || state.UserID == s.session.State.User.ID {
+			continue
+		}
+
+		if member, err := s.session.State.Member(guildID, state.UserID); err == nil && member.User != nil && member.User.Bot {
+			continue
+		}
+
+		listeners = append(listeners, state.UserID)
+	}
+
+	return listeners
+}
+
+// ApplySettings updates the settings of a guild's running session, if there is one.
+func (s *MusicService) ApplySettings(ctx context.Context, guildID string, settings database.MusicSettings) error {
+	if session := s.GetMusicSession(guildID); session != nil {
+		return session.ApplySettings(ctx, settings)
+	}
+
+	return nil
+}
+
+// restore applies the settings, filters and history persisted for the session's guild, if any,
+// and gives the session the guild's request channel panel.
+// It runs on the session's event loop.
+func (s *MusicService) restore(session *MusicSession) {
+	history, err := s.loadHistory(session.GuildID)
+	if err != nil {
+		slog.Error("Failed to load music history.", slog.String("guild_id", session.GuildID), slog.String("error", err.Error()))
+	} else {
+		session.history = history
+	}
+
+	settings, exists, err := s.db.GetGuildSettings(session.GuildID)
+	if err != nil {
+		slog.Error("Failed to get guild settings.", slog.String("guild_id", session.GuildID), slog.String("error", err.Error()))
+		return
+	}
+
+	if !exists {
+		return
+	}
+
+	session.settings = settings.MusicSettings
+
+	if settings.MusicFilters != nil {
+		session.filters = *settings.MusicFilters
+	}
+
+	if channelID := settings.MusicSettings.RequestChannelID; channelID != "" {
+		panel, err := s.requestPanel(channelID)
+		if err != nil {
+			slog.Error("Failed to get request channel panel.", slog.String("guild_id", session.GuildID), slog.String("error", err.Error()))
+		} else {
+			session.requestPanel = panel
+		}
+	}
+}
+
 // Events
 
 // onVoiceStateUpdate is called when a voice state update event is received.
@@ -97,17 +356,52 @@ func (s *MusicService) onVoiceStateUpdate(session *discordgo.Session, event *dis
 		return
 	}
 
+	musicSession := s.GetMusicSession(event.VoiceState.GuildID)
+	sessionID := event.VoiceState.SessionID
+
+	// Without a channel, the bot left or was disconnected, which destroys its player.
+	if event.VoiceState.ChannelID == "" {
+		if musicSession == nil {
+			ctx, cancel := s.OperationContext()
+			defer cancel()
+
+			s.lavalink.OnVoiceStateUpdate(ctx, guildID, nil, sessionID)
+			return
+		}
+
+		musicSession.post(func(ctx context.Context) {
+			if !musicSession.handleDisconnect() {
+				return
+			}
+
+			s.lavalink.OnVoiceStateUpdate(ctx, guildID, nil, sessionID)
+			s.removeMusicSession(musicSession)
+		})
+
+		return
+	}
+
 	channelID, err := snowflake.Parse(event.VoiceState.ChannelID)
 	if err != nil {
 		return
 	}
 
-	s.LavalinkClient.OnVoiceStateUpdate(
-		context.TODO(),
+	ctx, cancel := s.OperationContext()
+	defer cancel()
+
+	// Lavalink needs the voice session before the voice server update that follows, so this can't wait for the event loop.
+	s.lavalink.OnVoiceStateUpdate(
+		ctx,
 		guildID,
 		&channelID,
-		event.VoiceState.SessionID,
+		sessionID,
 	)
+
+	if musicSession != nil {
+		musicSession.post(func(ctx context.Context) {
+			musicSession.handleVoiceChannel(event.VoiceState.ChannelID)
+		})
+	}
 }
 
 // onVoiceServerUpdate is called when a voice server update event is received.
@@ -118,12 +412,48 @@ func (s *MusicService) onVoiceServerUpdate(session *discordgo.Session, event *di
 		return
 	}
 
-	s.LavalinkClient.OnVoiceServerUpdate(context.TODO(), guildID, event.Token, event.Endpoint)
+	ctx, cancel := s.OperationContext()
+	defer cancel()
+
+	s.lavalink.OnVoiceServerUpdate(ctx, guildID, event.Token, event.Endpoint)
+}
+
+// onReady is called when the bot connected to the gateway, which is when it reconnects
+// if resuming the previous connection failed. Voice connections don't survive that.
+// It needs to be hooked to the bot's events via MusicService.HookEvents().
+func (s *MusicService) onReady(session *discordgo.Session, event *discordgo.Ready) {
+	// discordgo starts its state over on Ready, so it can't tell which voice channels the bot is still in.
+	s.rejoin((*MusicSession).joinVoice)
+}
+
+// onResumed is called when the bot resumed its connection to the gateway.
+// It needs to be hooked to the bot's events via MusicService.HookEvents().
+func (s *MusicService) onResumed(session *discordgo.Session, event *discordgo.Resumed) {
+	s.rejoin((*MusicSession).rejoin)
+}
+
+// rejoin has every session join its voice channel again with join, since the bot may have lost it while reconnecting.
+func (s *MusicService) rejoin(join func(*MusicSession) error) {
+	for _, musicSession := range s.musicSessions() {
+		musicSession := musicSession
+		musicSession.post(func(ctx context.Context) {
+			err := join(musicSession)
+			if err != nil {
+				slog.Error("Failed to rejoin voice channel.", slog.String("guild_id", musicSession.GuildID), slog.String("error", err.Error()))
+			}
+		})
+	}
 }
 
 func (s *MusicService) onPlayerUpdate(player disgolink.Player, event lavalink.PlayerUpdateMessage) {
-	// do something with the event
+	musicSession := s.GetMusicSession(event.GuildID.String())
+	if musicSession == nil {
+		return
+	}
 
+	musicSession.post(func(ctx context.Context) {
+		musicSession.handlePlayerUpdate(event.State)
+	})
 }
 
 func (s *MusicService) onPlayerPause(player disgolink.Player, event lavalink.PlayerPauseEvent) {
@@ -135,29 +465,61 @@ func (s *MusicService) onPlayerResume(player disgolink.Player, event lavalink.Pl
 }
 
 func (s *MusicService) onTrackStart(player disgolink.Player, event lavalink.TrackStartEvent) {
-	musicSession := s.MusicSession(event.GuildID().String(), "")
-
-	err := musicSession.HandleTrackStart(&event.Track)
-	if err != nil {
-		slog.Error(err.Error())
+	musicSession := s.GetMusicSession(event.GuildID().String())
+	if musicSession == nil {
+		return
 	}
+
+	musicSession.post(func(ctx context.Context) {
+		err := musicSession.handleTrackStart(&event.Track)
+		if err != nil {
+			slog.Error(err.Error())
+		}
+	})
 }
 
 func (s *MusicService) onTrackEnd(player disgolink.Player, event lavalink.TrackEndEvent) {
-	musicSession := s.MusicSession(event.GuildID().String(), "")
-
-	err := musicSession.HandleTrackEnd(&event.Track)
-	if err != nil {
-		slog.Error(err.Error())
+	musicSession := s.GetMusicSession(event.GuildID().String())
+	if musicSession == nil {
+		return
 	}
+
+	musicSession.post(func(ctx context.Context) {
+		err := musicSession.handleTrackEnd(ctx, &event.Track, event.Reason)
+		if err != nil {
+			slog.Error(err.Error())
+		}
+	})
 }
 
 func (s *MusicService) onTrackException(player disgolink.Player, event lavalink.TrackExceptionEvent) {
-	// do something with the event
+	musicSession := s.GetMusicSession(event.GuildID().String())
+	if musicSession == nil {
+		return
+	}
+
+	musicSession.post(func(ctx context.Context) {
+		err := musicSession.handleTrackFailure(ctx, &event.Track, event.Exception.Message)
+		if err != nil {
+			slog.Error(err.Error())
+		}
+	})
 }
 
 func (s *MusicService) onTrackStuck(player disgolink.Player, event lavalink.TrackStuckEvent) {
-	// do something with the event
+	musicSession := s.GetMusicSession(event.GuildID().String())
+	if musicSession == nil {
+		return
+	}
+
+	cause := fmt.Sprintf("stuck for %s", datetime.Pretty(datetime.ToDuration(event.Threshold)))
+
+	musicSession.post(func(ctx context.Context) {
+		err := musicSession.handleTrackFailure(ctx, &event.Track, cause)
+		if err != nil {
+			slog.Error(err.Error())
+		}
+	})
 }
 
 func (s *MusicService) onWebSocketClosed(player disgolink.Player, event lavalink.WebSocketClosedEvent) {
@@ -0,0 +1,677 @@
+package music_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/disgoorg/disgolink/v3/lavalink"
+	"github.com/disgoorg/snowflake/v2"
+
+	"unreal.sh/neo/internal/database"
+	"unreal.sh/neo/internal/services/music"
+	"unreal.sh/neo/internal/services/music/lavalinktest"
+)
+
+const (
+	testGuildID = snowflake.ID(1234)
+	waitTimeout = 5 * time.Second
+)
+
+// memoryStore keeps guild settings, history and session snapshots in memory.
+type memoryStore struct {
+	mu        sync.Mutex
+	settings  map[string]database.GuildSettings
+	history   []database.MusicHistoryEntry
+	snapshots []database.MusicSessionSnapshot
+}
+
+func (s *memoryStore) GetGuildSettings(guildID string) (database.GuildSettings, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	settings, exists := s.settings[guildID]
+	return settings, exists, nil
+}
+
+func (s *memoryStore) GetMusicHistory(guildID string, limit int) ([]database.MusicHistoryEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var history []database.MusicHistoryEntry
+	for i := len(s.history) - 1; i >= 0 && len(history) < limit; i-- {
+		if s.history[i].GuildID == guildID {
+			history = append(history, s.history[i])
+		}
+	}
+
+	return history, nil
+}
+
+func (s *memoryStore) GetMusicHistorySince(guildID string, userID string, since time.Time, limit int) ([]database.MusicHistoryEntry, error) {
+	return nil, nil
+}
+
+func (s *memoryStore) AddMusicHistoryEntry(entry database.MusicHistoryEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.history = append(s.history, entry)
+	return nil
+}
+
+func (s *memoryStore) SaveMusicSessionSnapshots(snapshots []database.MusicSessionSnapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.snapshots = append(s.snapshots, snapshots...)
+	return nil
+}
+
+func (s *memoryStore) GetMusicSessionSnapshots(node string) ([]database.MusicSessionSnapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var snapshots []database.MusicSessionSnapshot
+	for _, snapshot := range s.snapshots {
+		if snapshot.LavalinkNode == node {
+			snapshots = append(snapshots, snapshot)
+		}
+	}
+
+	return snapshots, nil
+}
+
+func (s *memoryStore) DeleteMusicSessionSnapshots(node string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	kept := s.snapshots[:0]
+	for _, snapshot := range s.snapshots {
+		if snapshot.LavalinkNode != node {
+			kept = append(kept, snapshot)
+		}
+	}
+
+	s.snapshots = kept
+	return nil
+}
+
+// newTestService starts a music service connected to a fake Lavalink node.
+func newTestService(t *testing.T) (*music.MusicService, *lavalinktest.Server) {
+	t.Helper()
+
+	return newTestServiceWithStore(t, &memoryStore{})
+}
+
+// newTestServiceWithStore is newTestService with the guild settings and history in store.
+func newTestServiceWithStore(t *testing.T, store *memoryStore) (*music.MusicService, *lavalinktest.Server) {
+	t.Helper()
+
+	server := lavalinktest.NewServer()
+	t.Cleanup(server.Close)
+
+	return startTestService(t, store, server), server
+}
+
+// startTestService starts a music service connected to server, like the bot does when it starts.
+func startTestService(t *testing.T, store *memoryStore, server *lavalinktest.Server) *music.MusicService {
+	t.Helper()
+
+	session, err := discordgo.New("Bot test")
+	if err != nil {
+		t.Fatalf("discordgo.New: %v", err)
+	}
+
+	session.State.User = &discordgo.User{ID: "1"}
+
+	service, err := music.NewMusicService(context.Background(), session, store)
+	if err != nil {
+		t.Fatalf("NewMusicService: %v", err)
+	}
+
+	t.Cleanup(service.Close)
+
+	ctx, cancel := service.OperationContext()
+	defer cancel()
+
+	if _, err := service.AddNode(ctx, server.NodeConfig()); err != nil {
+		t.Fatalf("AddNode: %v", err)
+	}
+
+	return service
+}
+
+// addTrack makes a track loadable by its URI, the way it's reloaded after failing.
+func addTrack(server *lavalinktest.Server, identifier string) lavalink.Track {
+	track := lavalinktest.NewTrack(identifier, identifier, 3*time.Minute)
+	server.AddTrack(*track.Info.URI, track)
+
+	return track
+}
+
+// play loads a query and plays or enqueues the first track found, like /play does.
+func play(t *testing.T, service *music.MusicService, query string) bool {
+	t.Helper()
+
+	ctx, cancel := service.OperationContext()
+	defer cancel()
+
+	result, _, err := service.LoadTracks(ctx, query, music.DefaultSearchSources)
+	if err != nil {
+		t.Fatalf("LoadTracks(%q): %v", query, err)
+	}
+
+	var track lavalink.Track
+	switch data := result.Data.(type) {
+	case lavalink.Track:
+		track = data
+	case lavalink.Search:
+		track = data[0]
+	default:
+		t.Fatalf("LoadTracks(%q) loaded %T", query, result.Data)
+	}
+
+	session := service.MusicSession(testGuildID.String(), "")
+
+	enqueued, err := session.PlayOrEnqueue(ctx, &track, music.NewTrackRequestData("42", nil, ""))
+	if err != nil {
+		t.Fatalf("PlayOrEnqueue(%q): %v", query, err)
+	}
+
+	return enqueued
+}
+
+func waitForTrack(t *testing.T, server *lavalinktest.Server, identifier string) {
+	t.Helper()
+
+	if err := server.WaitForTrack(testGuildID, identifier, waitTimeout); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// waitForState waits until the session's state satisfies ok, since the node's events are handled asynchronously.
+func waitForState(t *testing.T, service *music.MusicService, ok func(music.SessionState) bool) music.SessionState {
+	t.Helper()
+
+	deadline := time.Now().Add(waitTimeout)
+	session := service.MusicSession(testGuildID.String(), "")
+
+	for {
+		ctx, cancel := service.OperationContext()
+		state, err := session.State(ctx)
+		cancel()
+
+		if err != nil {
+			t.Fatalf("State: %v", err)
+		}
+
+		if ok(state) {
+			return state
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("session never reached the expected state, last was %+v", state)
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestPlayQueueAndAdvance(t *testing.T) {
+	service, server := newTestService(t)
+
+	first := addTrack(server, "first")
+	second := addTrack(server, "second")
+
+	server.AddSearch("ytsearch:second song", second)
+
+	if enqueued := play(t, service, *first.Info.URI); enqueued {
+		t.Fatal("first track was enqueued instead of played")
+	}
+
+	waitForTrack(t, server, "first")
+
+	if enqueued := play(t, service, "second song"); !enqueued {
+		t.Fatal("second track was played instead of enqueued")
+	}
+
+	state := waitForState(t, service, func(state music.SessionState) bool { return len(state.Queue) == 1 })
+	if state.CurrentTrack.Info.Identifier != "first" {
+		t.Fatalf("expected first to be playing, got %s", state.CurrentTrack.Info.Identifier)
+	}
+
+	if err := server.FinishTrack(testGuildID); err != nil {
+		t.Fatalf("FinishTrack: %v", err)
+	}
+
+	waitForTrack(t, server, "second")
+	waitForState(t, service, func(state music.SessionState) bool {
+		return state.IsPlaying() && state.CurrentTrack.Info.Identifier == "second" && len(state.Queue) == 0
+	})
+
+	if err := server.FinishTrack(testGuildID); err != nil {
+		t.Fatalf("FinishTrack: %v", err)
+	}
+
+	waitForState(t, service, func(state music.SessionState) bool { return !state.IsPlaying() })
+}
+
+func TestSkipAndStop(t *testing.T) {
+	service, server := newTestService(t)
+
+	for _, identifier := range []string{"one", "two", "three"} {
+		play(t, service, *addTrack(server, identifier).Info.URI)
+	}
+
+	waitForTrack(t, server, "one")
+
+	session := service.MusicSession(testGuildID.String(), "")
+
+	ctx, cancel := service.OperationContext()
+	defer cancel()
+
+	if err := session.Skip(ctx); err != nil {
+		t.Fatalf("Skip: %v", err)
+	}
+
+	waitForTrack(t, server, "two")
+
+	if err := session.Stop(ctx); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+
+	// Stopping keeps the queue.
+	waitForTrack(t, server, "")
+	waitForState(t, service, func(state music.SessionState) bool { return !state.IsPlaying() && len(state.Queue) == 1 })
+}
+
+func TestTrackFailureRetriesThenSkips(t *testing.T) {
+	service, server := newTestService(t)
+
+	broken := addTrack(server, "broken")
+	next := addTrack(server, "next")
+
+	play(t, service, *broken.Info.URI)
+	play(t, service, *next.Info.URI)
+
+	waitForTrack(t, server, "broken")
+
+	// The first failure reloads the track and plays it again.
+	if err := server.FailTrack(testGuildID, "decoding failed"); err != nil {
+		t.Fatalf("FailTrack: %v", err)
+	}
+
+	waitForTrack(t, server, "broken")
+
+	// The failed track's end, which follows the retry, leaves the retry as the current track.
+	state := waitForState(t, service, func(state music.SessionState) bool { return len(state.Queue) == 1 })
+	if state.CurrentTrack == nil || state.CurrentTrack.Info.Identifier != "broken" {
+		t.Fatalf("expected the retry to be the current track, got %+v", state.CurrentTrack)
+	}
+
+	// Failing again gives up on it.
+	if err := server.FailTrack(testGuildID, "decoding failed"); err != nil {
+		t.Fatalf("FailTrack: %v", err)
+	}
+
+	waitForTrack(t, server, "next")
+	waitForState(t, service, func(state music.SessionState) bool {
+		return state.IsPlaying() && state.CurrentTrack.Info.Identifier == "next"
+	})
+}
+
+func TestTrackFailureRetriesEveryTrack(t *testing.T) {
+	service, server := newTestService(t)
+
+	for _, identifier := range []string{"first", "second", "third"} {
+		play(t, service, *addTrack(server, identifier).Info.URI)
+	}
+
+	waitForTrack(t, server, "first")
+
+	// The first track fails twice and is skipped, then the second fails once.
+	// Retries don't count towards stopping, so the second is still retried rather than playback stopped.
+	for _, identifier := range []string{"first", "second"} {
+		if err := server.FailTrack(testGuildID, "decoding failed"); err != nil {
+			t.Fatalf("FailTrack: %v", err)
+		}
+
+		waitForTrack(t, server, identifier)
+	}
+
+	if err := server.FailTrack(testGuildID, "decoding failed"); err != nil {
+		t.Fatalf("FailTrack: %v", err)
+	}
+
+	waitForTrack(t, server, "second")
+	waitForState(t, service, func(state music.SessionState) bool {
+		return state.CurrentTrack != nil && state.CurrentTrack.Info.Identifier == "second" && len(state.Queue) == 1
+	})
+}
+
+func TestFiltersPersist(t *testing.T) {
+	nightcore := music.FilterPresets["nightcore"]
+	store := &memoryStore{settings: map[string]database.GuildSettings{
+		testGuildID.String(): {GuildID: testGuildID.String(), MusicFilters: &nightcore},
+	}}
+
+	service, server := newTestServiceWithStore(t, store)
+
+	first := addTrack(server, "first")
+	second := addTrack(server, "second")
+
+	// A new session picks up the guild's stored filters.
+	play(t, service, *first.Info.URI)
+	waitForTrack(t, server, "first")
+
+	if filters := server.PlayerFilters(testGuildID); filters.Timescale == nil || filters.Timescale.Speed != 1.25 {
+		t.Fatalf("expected the stored filters to be applied, got %+v", filters)
+	}
+
+	ctx, cancel := service.OperationContext()
+	defer cancel()
+
+	session := service.MusicSession(testGuildID.String(), "")
+
+	_, err := session.UpdateFilters(ctx, func(filters lavalink.Filters) lavalink.Filters {
+		return music.MergeFilters(filters, music.FilterPresets["soft"])
+	})
+	if err != nil {
+		t.Fatalf("UpdateFilters: %v", err)
+	}
+
+	// The changed filters stay on for the following tracks.
+	play(t, service, *second.Info.URI)
+
+	if err := server.FinishTrack(testGuildID); err != nil {
+		t.Fatalf("FinishTrack: %v", err)
+	}
+
+	waitForTrack(t, server, "second")
+
+	if filters := server.PlayerFilters(testGuildID); filters.Timescale == nil || filters.LowPass == nil {
+		t.Fatalf("expected the filters to carry over to the next track, got %+v", filters)
+	}
+}
+
+func TestAutoplayWhenQueueRunsDry(t *testing.T) {
+	store := &memoryStore{settings: map[string]database.GuildSettings{
+		testGuildID.String(): {GuildID: testGuildID.String(), MusicSettings: database.MusicSettings{Autoplay: true}},
+	}}
+
+	service, server := newTestServiceWithStore(t, store)
+
+	first := addTrack(server, "first")
+	related := addTrack(server, "related")
+	requested := addTrack(server, "requested")
+
+	// Searching for the seed finds the seed itself too, which autoplay passes over.
+	server.AddSearch("dzsearch:lavalinktest first", first, related)
+
+	play(t, service, *first.Info.URI)
+	waitForTrack(t, server, "first")
+
+	if err := server.FinishTrack(testGuildID); err != nil {
+		t.Fatalf("FinishTrack: %v", err)
+	}
+
+	waitForTrack(t, server, "related")
+
+	var data music.TrackRequestData
+	state := waitForState(t, service, func(state music.SessionState) bool {
+		return state.IsPlaying() && state.CurrentTrack.Info.Identifier == "related"
+	})
+
+	if err := state.CurrentTrack.UserData.Unmarshal(&data); err != nil || !data.Autoplay {
+		t.Fatalf("expected the related track to be tagged as autoplay, got %+v (%v)", data, err)
+	}
+
+	// Someone queueing something takes over from autoplay right away.
+	if enqueued := play(t, service, *requested.Info.URI); enqueued {
+		t.Fatal("requested track was queued behind an autoplay track")
+	}
+
+	waitForTrack(t, server, "requested")
+}
+
+func TestImportPlaylistEntriesKeepsOrder(t *testing.T) {
+	service, server := newTestService(t)
+
+	first := addTrack(server, "first")
+	second := lavalinktest.NewTrack("second", "Second Song", 3*time.Minute)
+	third := addTrack(server, "third")
+
+	server.AddSearch("ytsearch:Someone - Second Song", second)
+
+	entries := []music.PlaylistEntry{
+		{Location: *first.Info.URI},
+		{Location: "/music/second.mp3", Title: "Second Song", Creator: "Someone"},
+		{Location: "https://lavalinktest.invalid/missing"},
+		{Location: *third.Info.URI},
+	}
+
+	ctx, cancel := service.ImportContext()
+	defer cancel()
+
+	var imported []string
+	service.ImportPlaylistEntries(ctx, entries, music.DefaultSearchSources, func(entry music.ImportedEntry) bool {
+		if entry.Err != nil {
+			imported = append(imported, "failed")
+		} else {
+			imported = append(imported, entry.Tracks[0].Info.Identifier)
+		}
+
+		return true
+	})
+
+	want := []string{"first", "second", "failed", "third"}
+	if fmt.Sprint(imported) != fmt.Sprint(want) {
+		t.Fatalf("imported %v, want %v", imported, want)
+	}
+}
+
+func TestImportPlaylistEntriesStopsEarly(t *testing.T) {
+	service, server := newTestService(t)
+
+	release := server.HoldLoads()
+	t.Cleanup(release)
+
+	first := lavalinktest.NewTrack("first", "First Song", 3*time.Minute)
+	entries := []music.PlaylistEntry{{Track: &first}}
+
+	for i := 0; i < 3*music.ImportConcurrency; i++ {
+		entries = append(entries, music.PlaylistEntry{Location: fmt.Sprintf("https://lavalinktest.invalid/%d", i)})
+	}
+
+	ctx, cancel := service.ImportContext()
+	defer cancel()
+
+	imported := make(chan struct{})
+	go func() {
+		defer close(imported)
+
+		// The first entry needs no loading, and stopping there cancels the loads of the others.
+		service.ImportPlaylistEntries(ctx, entries, music.DefaultSearchSources, func(entry music.ImportedEntry) bool {
+			return false
+		})
+	}()
+
+	select {
+	case <-imported:
+	case <-time.After(5 * time.Second):
+		t.Fatal("import kept waiting for loads after being stopped")
+	}
+
+	if loads := server.Loads(); len(loads) > music.ImportConcurrency {
+		t.Fatalf("expected at most %d loads, got %d: %v", music.ImportConcurrency, len(loads), loads)
+	}
+}
+
+func TestResumeAfterRestart(t *testing.T) {
+	store := &memoryStore{}
+	service, server := newTestServiceWithStore(t, store)
+
+	first := addTrack(server, "first")
+	second := addTrack(server, "second")
+
+	play(t, service, *first.Info.URI)
+	waitForTrack(t, server, "first")
+	play(t, service, *second.Info.URI)
+	waitForState(t, service, func(state music.SessionState) bool { return len(state.Queue) == 1 })
+
+	service.Close()
+
+	// The node keeps playing while the bot restarts.
+	restarted := startTestService(t, store, server)
+
+	state := waitForState(t, restarted, func(state music.SessionState) bool { return state.CurrentTrack != nil })
+	if state.CurrentTrack.Info.Identifier != "first" || len(state.Queue) != 1 {
+		t.Fatalf("expected first to still be playing with second queued, got %+v", state)
+	}
+
+	if snapshots, _ := store.GetMusicSessionSnapshots(server.NodeConfig().Name); len(snapshots) != 0 {
+		t.Fatalf("expected snapshots to be deleted once resumed, got %d", len(snapshots))
+	}
+
+	if err := server.FinishTrack(testGuildID); err != nil {
+		t.Fatalf("FinishTrack: %v", err)
+	}
+
+	waitForTrack(t, server, "second")
+	waitForState(t, restarted, func(state music.SessionState) bool {
+		return state.CurrentTrack != nil && state.CurrentTrack.Info.Identifier == "second" && len(state.Queue) == 0
+	})
+}
+
+func TestResumeAbandonsIdlePlayers(t *testing.T) {
+	store := &memoryStore{}
+	service, server := newTestServiceWithStore(t, store)
+
+	play(t, service, *addTrack(server, "first").Info.URI)
+	waitForTrack(t, server, "first")
+
+	// Another guild's bot is connected, but has nothing to resume.
+	idleGuildID := snowflake.ID(5678)
+	idle := service.MusicSession(idleGuildID.String(), "")
+
+	ctx, cancel := service.OperationContext()
+	defer cancel()
+
+	second := addTrack(server, "second")
+	if _, err := idle.PlayOrEnqueue(ctx, &second, music.NewTrackRequestData("42", nil, "")); err != nil {
+		t.Fatalf("PlayOrEnqueue: %v", err)
+	}
+
+	if err := idle.Stop(ctx); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+
+	if !server.HasPlayer(idleGuildID) {
+		t.Fatal("expected the idle guild to have a player")
+	}
+
+	service.Close()
+
+	restarted := startTestService(t, store, server)
+
+	waitForState(t, restarted, func(state music.SessionState) bool { return state.CurrentTrack != nil })
+
+	if server.HasPlayer(idleGuildID) {
+		t.Fatal("expected the idle guild's player to be destroyed")
+	}
+
+	if restarted.GetMusicSession(idleGuildID.String()) != nil {
+		t.Fatal("expected no session for the idle guild")
+	}
+}
+
+func TestHistoryOutlivesSession(t *testing.T) {
+	store := &memoryStore{}
+	service, server := newTestServiceWithStore(t, store)
+
+	play(t, service, *addTrack(server, "first").Info.URI)
+	waitForTrack(t, server, "first")
+
+	if err := server.FinishTrack(testGuildID); err != nil {
+		t.Fatalf("FinishTrack: %v", err)
+	}
+
+	waitForState(t, service, func(state music.SessionState) bool { return !state.IsPlaying() })
+
+	// Closing waits for the played tracks to be stored.
+	service.Close()
+
+	restarted := startTestService(t, store, server)
+
+	ctx, cancel := restarted.OperationContext()
+	defer cancel()
+
+	history, err := restarted.History(ctx, testGuildID.String())
+	if err != nil {
+		t.Fatalf("History: %v", err)
+	}
+
+	// Duplicate checks and autoplay tell tracks apart by their source and identifier.
+	if len(history) != 1 || history[0].Track.Info.Identifier != "first" || history[0].Track.Info.SourceName != "http" {
+		t.Fatalf("expected first to be in the history with its source, got %+v", history)
+	}
+}
+
+func TestSearchFallsThroughGuildSources(t *testing.T) {
+	store := &memoryStore{settings: map[string]database.GuildSettings{
+		testGuildID.String(): {GuildID: testGuildID.String(), MusicSettings: database.MusicSettings{SearchSources: []string{"deezer", "soundcloud"}}},
+	}}
+	service, server := newTestServiceWithStore(t, store)
+
+	server.AddSearch("scsearch:lofi", lavalinktest.NewTrack("lofi", "lofi", 3*time.Minute))
+
+	sources := service.SearchSources(testGuildID.String())
+	if len(sources) != 2 || sources[0] != "deezer" {
+		t.Fatalf("expected the guild's search sources, got %q", sources)
+	}
+
+	ctx, cancel := service.OperationContext()
+	defer cancel()
+
+	// Deezer finds nothing, so SoundCloud is asked next.
+	_, source, err := service.LoadTracks(ctx, "lofi", sources)
+	if err != nil {
+		t.Fatalf("LoadTracks: %v", err)
+	}
+
+	if source != "soundcloud" {
+		t.Fatalf("expected soundcloud to find the track, got %q", source)
+	}
+
+	if _, _, err := service.LoadTracks(ctx, "nothing", sources); !errors.Is(err, music.ErrNoSearchResults) {
+		t.Fatalf("expected no results, got %v", err)
+	}
+}
+
+func TestStoredHistoryWithoutLinks(t *testing.T) {
+	store := &memoryStore{history: []database.MusicHistoryEntry{
+		{GuildID: testGuildID.String(), Track: "encoded", Title: "local file", Outcome: "played"},
+	}}
+	service, _ := newTestServiceWithStore(t, store)
+
+	ctx, cancel := service.OperationContext()
+	defer cancel()
+
+	history, err := service.History(ctx, testGuildID.String())
+	if err != nil {
+		t.Fatalf("History: %v", err)
+	}
+
+	if len(history) != 1 || history[0].Track.Info.URI != nil {
+		t.Fatalf("expected a track stored without a link to have none, got %+v", history)
+	}
+
+	if link := music.TrackLink(&history[0].Track); link != "local file" {
+		t.Fatalf("expected the track to be shown by its title, got %q", link)
+	}
+}
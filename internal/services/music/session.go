@@ -2,209 +2,783 @@ package music
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
-	"sync"
+	"time"
 
 	"github.com/bwmarrin/discordgo"
-	"github.com/disgoorg/disgolink/v3/disgolink"
 	"github.com/disgoorg/disgolink/v3/lavalink"
-	"unreal.sh/neo/internal/utils/colorutils"
-	"unreal.sh/neo/internal/utils/static"
+	"unreal.sh/neo/internal/database"
+	embedutils "unreal.sh/neo/internal/utils/embedutils"
 	stringutils "unreal.sh/neo/internal/utils/stringutils"
 )
 
+var (
+	ErrNotPlaying     = errors.New("nothing is playing")
+	ErrNotSeekable    = errors.New("track is not seekable")
+	ErrSeekOutOfRange = errors.New("position is out of the track's range")
+	ErrUserQueueFull  = errors.New("user has too many tracks in the queue")
+	ErrQueueFull      = errors.New("queue is full")
+	ErrTrackTooLong   = errors.New("track is too long")
+	ErrDuplicateTrack = errors.New("track is already queued or was played recently")
+	ErrStreamBlocked  = errors.New("streams are not allowed")
+	ErrSessionClosed  = errors.New("music session is closed")
+)
+
+// MusicSession is the music playback of a guild.
+//
+// A session's state is owned by its event loop: every exported method runs on it,
+// one at a time, so commands and Lavalink events never race each other.
+// Code already running on the loop uses the unexported variants instead,
+// since waiting on the loop from inside it would never return.
 type MusicSession struct {
-	sync.Mutex
 	session *discordgo.Session
-	player  *disgolink.Player
+	player  Player
+	node    Node // Where failed tracks are reloaded from.
+	tracks  Node // Where autoplay looks for tracks, which may be cached.
 
-	TextChannelID string
-	GuildID       string
+	GuildID string
 
-	Queue        []*lavalink.Track  // A queue of tracks to play next.
-	Data         []TrackRequestData // A list of data for each track in the queue.
-	CurrentTrack *lavalink.Track
+	ctx    context.Context    // Cancelled when the session closes.
+	cancel context.CancelFunc // Closes the session.
+	ops    chan func()        // Operations waiting to run on the event loop.
+	events chan func()        // Lavalink events waiting to run on the event loop, in the order they came in.
+	done   chan struct{}      // Closed once the event loop stopped.
+
+	textChannelID string
+
+	voiceChannelID string      // The voice channel the bot is in, as far as the session knows.
+	rejoining      *time.Timer // Gives up on joining voiceChannelID again after reconnecting to the gateway, while the bot is.
+	leaving        bool        // Whether the bot is leaving on its own, rather than being disconnected.
+
+	queue   []*lavalink.Track  // A queue of tracks to play next.
+	data    []TrackRequestData // A list of data for each track in the queue.
+	current *lavalink.Track    // The track playing, or about to start playing.
+	filters lavalink.Filters   // The audio filters applied to every track played.
+	loop    LoopMode           // What to play after a track finishes.
+
+	pendingAutoplay *autoplaySearch // The search for a track to autoplay, while one is going on.
+
+	// The player's state as last reported by Lavalink. disgolink updates its players from
+	// the node's connection, so reading them from the event loop would race with it.
+	playerState  lavalink.PlayerState
+	playerPaused bool
+
+	settings database.MusicSettings // The guild's music settings.
+
+	FailurePolicy FailurePolicy
+
+	skipVote *SkipVote // The ongoing vote to skip the current track, if any.
+
+	sleepTimer *SleepTimer // When the session stops and leaves on its own, if ever.
+
+	recent []string // Keys of the most recently started tracks, oldest first.
+
+	history       []HistoryEntry           // Tracks that stopped playing, oldest first.
+	forgetNextEnd bool                     // Whether the next track to end is left out of the history.
+	onHistory     func(entry HistoryEntry) // Called with every track added to the history.
+
+	onGone func(ctx context.Context) // Called when the session ends on its own, without the bot being disconnected.
+
+	listening      string        // Encoded track whose listening time is being counted.
+	listenedFor    time.Duration // How long it was listened to before listeningSince.
+	listeningSince time.Time     // When it last started or resumed playing, zero while paused.
+
+	panels       *panelWriter       // Sends the player panel and the request channel's panel to Discord.
+	panelColor   int                // The embed color of the player panel, taken from the track's artwork.
+	artwork      *ArtworkColors     // Where the panel's colors come from, if anywhere.
+	panelUpdated time.Time          // When the player panel was last edited.
+	panelPending bool               // Whether the player panels are about to be updated for changes to the queue.
+	requestPanel *discordgo.Message // The panel of the guild's request channel, if it has one.
+
+	consecutiveFailures int         // How many tracks failed in a row, not counting retries.
+	failedIdentifier    string      // Identifier of the last track that failed, until another track starts.
+	failedAttempts      int         // How many times the last failed track has failed.
+	retrying            *trackRetry // The failed track being reloaded to play again, if any.
 }
 
-func NewMusicSession(session *discordgo.Session, player *disgolink.Player, guildID string, textChannelID string) *MusicSession {
-	return &MusicSession{
+// eventBufferSize is how many Lavalink events can wait for a session before the node's connection is held up.
+const eventBufferSize = 64
+
+// NewMusicSession creates a session and starts its event loop, which runs until ctx is cancelled
+// or the bot is disconnected from its voice channel.
+func NewMusicSession(ctx context.Context, session *discordgo.Session, player Player, node Node, guildID string, textChannelID string) *MusicSession {
+	s := newMusicSession(ctx, session, player, node, guildID, textChannelID)
+	s.start(nil)
+
+	return s
+}
+
+// newMusicSession creates a session without starting its event loop.
+func newMusicSession(ctx context.Context, session *discordgo.Session, player Player, node Node, guildID string, textChannelID string) *MusicSession {
+	ctx, cancel := context.WithCancel(ctx)
+
+	s := &MusicSession{
 		session: session,
 		player:  player,
+		node:    node,
+		tracks:  node,
 
 		GuildID:       guildID,
-		TextChannelID: textChannelID,
+		textChannelID: textChannelID,
+
+		ctx:    ctx,
+		cancel: cancel,
+		ops:    make(chan func()),
+		events: make(chan func(), eventBufferSize),
+		done:   make(chan struct{}),
+
+		queue:   make([]*lavalink.Track, 0),
+		current: nil,
+
+		FailurePolicy: DefaultFailurePolicy,
+	}
+
+	s.panels = newPanelWriter(session, guildID)
+	s.panels.onRequestPanelGone = func(message *discordgo.Message) {
+		s.post(func(ctx context.Context) {
+			// Someone deleted the panel. The next session posts a new one.
+			if s.requestPanel == message {
+				s.requestPanel = nil
+			}
+		})
+	}
+
+	return s
+}
+
+// start starts the session's event loop, which runs first before any operation or event, if it isn't nil.
+func (s *MusicSession) start(first func()) {
+	go s.run(first)
+	go s.panels.run(s.ctx)
+}
+
+// run executes first and then the session's operations in order until its context is cancelled.
+func (s *MusicSession) run(first func()) {
+	defer close(s.done)
+
+	if first != nil {
+		first()
+	}
+
+	for {
+		select {
+		case op := <-s.ops:
+			op()
+		case event := <-s.events:
+			event()
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
 
-		Queue:        make([]*lavalink.Track, 0),
-		CurrentTrack: nil,
+// Done returns a channel that's closed once the session's event loop stopped.
+func (s *MusicSession) Done() <-chan struct{} {
+	return s.done
+}
+
+// do runs f on the event loop and waits for its result.
+// If ctx ends first, do stops waiting, but f still runs if it was already picked up.
+func (s *MusicSession) do(ctx context.Context, f func() error) error {
+	result := make(chan error, 1)
+
+	select {
+	case s.ops <- func() { result <- f() }:
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-s.ctx.Done():
+		return ErrSessionClosed
+	}
+
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
 	}
 }
 
-// Play plays a track.
-func (s *MusicSession) Play(track *lavalink.Track, data TrackRequestData) error {
-	err := (*s.player).Update(context.TODO(), lavalink.WithTrack(*track), lavalink.WithTrackUserData(data))
+// post queues a Lavalink event to be handled on the event loop without waiting for it,
+// so events keep their order without holding up the node's connection.
+func (s *MusicSession) post(f func(ctx context.Context)) {
+	event := func() {
+		ctx, cancel := context.WithTimeout(s.ctx, OperationTimeout)
+		defer cancel()
+
+		f(ctx)
+	}
+
+	select {
+	case s.events <- event:
+	case <-s.ctx.Done():
+	}
+}
+
+// SessionState is a snapshot of a session, safe to use outside of its event loop.
+type SessionState struct {
+	CurrentTrack *lavalink.Track
+	Queue        []*lavalink.Track
+	Data         []TrackRequestData
+	Filters      lavalink.Filters
+	Loop         LoopMode
+	Settings     database.MusicSettings
+	Paused       bool
+	Position     lavalink.Duration
+	SleepTimer   *SleepTimer
+}
+
+// State returns a snapshot of the session.
+func (s *MusicSession) State(ctx context.Context) (state SessionState, err error) {
+	err = s.do(ctx, func() error {
+		state = SessionState{
+			CurrentTrack: s.current,
+			Queue:        append([]*lavalink.Track(nil), s.queue...),
+			Data:         append([]TrackRequestData(nil), s.data...),
+			Filters:      s.filters,
+			Loop:         s.loop,
+			Settings:     s.settings,
+			Paused:       s.paused(),
+			Position:     s.position(),
+		}
+
+		if s.sleepTimer != nil {
+			timer := *s.sleepTimer
+			state.SleepTimer = &timer
+		}
+
+		return nil
+	})
+
+	return state, err
+}
+
+// IsPlaying returns whether a track was playing when the snapshot was taken.
+// Note that this does not check if the player is paused.
+func (state SessionState) IsPlaying() bool {
+	return state.CurrentTrack != nil
+}
+
+// Remaining returns how much of the current track was left when the snapshot was taken.
+func (state SessionState) Remaining() lavalink.Duration {
+	if state.CurrentTrack == nil {
+		return 0
+	}
+
+	return state.CurrentTrack.Info.Length - state.Position
+}
+
+// play plays a track, claiming it as the current one right away,
+// so tracks requested before Lavalink says it started don't replace it.
+func (s *MusicSession) play(ctx context.Context, track *lavalink.Track, data TrackRequestData) error {
+	// Keep the request data on the current track too, not only on the one Lavalink echoes back.
+	withData, err := track.WithUserData(data)
 	if err != nil {
 		return err
 	}
 
+	err = s.player.Update(ctx, lavalink.WithTrack(withData), lavalink.WithFilters(s.filters))
+	if err != nil {
+		return err
+	}
+
+	s.current = &withData
+	s.playerState = lavalink.PlayerState{Time: lavalink.Now()}
+
 	return nil
 }
 
-// Enqueue adds a track to the queue.
-func (s *MusicSession) Enqueue(track *lavalink.Track, data TrackRequestData) {
-	s.Queue = append(s.Queue, track)
-	s.Data = append(s.Data, data)
-}
+// enqueue adds a track to the queue.
+// With a fair queue, the track is placed so requesters take turns rather than at the end.
+func (s *MusicSession) enqueue(track *lavalink.Track, data TrackRequestData) error {
+	if err := s.admit(track); err != nil {
+		return err
+	}
+
+	if limit := s.settings.MaxQueueLength; limit > 0 && len(s.queue) >= limit {
+		return ErrQueueFull
+	}
 
-func (s *MusicSession) PlayOrEnqueue(track *lavalink.Track, data TrackRequestData) (enqueued bool, err error) {
-	if s.IsPlaying() {
-		s.Enqueue(track, data)
-		return true, nil
+	if limit := s.settings.MaxUserQueue; limit > 0 && s.queuedBy(data.AuthorID) >= limit {
+		return ErrUserQueueFull
+	}
+
+	if !s.settings.FairQueue {
+		s.queue = append(s.queue, track)
+		s.data = append(s.data, data)
+		return nil
 	}
 
-	return false, s.Play(track, data)
+	i := fairInsertIndex(s.data, data.AuthorID)
+
+	s.queue = append(s.queue[:i], append([]*lavalink.Track{track}, s.queue[i:]...)...)
+	s.data = append(s.data[:i], append([]TrackRequestData{data}, s.data[i:]...)...)
+
+	return nil
 }
 
-func (s *MusicSession) Dequeue() (*lavalink.Track, TrackRequestData) {
-	if len(s.Queue) == 0 {
+// PlayOrEnqueue plays a track right away if nothing is playing, or enqueues it otherwise.
+// A track picked by autoplay counts as nothing playing, so requests replace it.
+// Tracks that break the guild's music settings are rejected either way.
+func (s *MusicSession) PlayOrEnqueue(ctx context.Context, track *lavalink.Track, data TrackRequestData) (enqueued bool, err error) {
+	err = s.do(ctx, func() error {
+		if s.current != nil && !s.autoplaying() {
+			enqueued = true
+			if err := s.enqueue(track, data); err != nil {
+				return err
+			}
+
+			s.schedulePanelUpdate()
+			return nil
+		}
+
+		if err := s.admit(track); err != nil {
+			return err
+		}
+
+		return s.play(ctx, track, data)
+	})
+
+	return enqueued, err
+}
+
+func (s *MusicSession) dequeue() (*lavalink.Track, TrackRequestData) {
+	if len(s.queue) == 0 {
 		return nil, TrackRequestData{}
 	}
 
-	track := s.Queue[0]
-	s.Queue = s.Queue[1:]
+	track := s.queue[0]
+	s.queue = s.queue[1:]
 
-	data := s.Data[0]
-	s.Data = s.Data[1:]
+	data := s.data[0]
+	s.data = s.data[1:]
 
 	return track, data
 }
 
-// IsPlaying returns whether the session is currently playing a track.
-// Note that this does not check if the player is paused.
-func (s *MusicSession) IsPlaying() bool {
-	return s.CurrentTrack != nil
+// Pause pauses the current track.
+func (s *MusicSession) Pause(ctx context.Context) error {
+	return s.do(ctx, func() error {
+		return s.setPaused(ctx, true)
+	})
 }
 
-// Pauses the current track.
-func (s *MusicSession) Pause() error {
-	player := (*s.player)
+// Resume resumes the current track.
+func (s *MusicSession) Resume(ctx context.Context) error {
+	return s.do(ctx, func() error {
+		return s.setPaused(ctx, false)
+	})
+}
 
-	return player.Update(context.TODO(), lavalink.WithPaused(true))
+// TogglePause pauses the current track if it's playing, or resumes it if it's paused.
+func (s *MusicSession) TogglePause(ctx context.Context) error {
+	return s.do(ctx, func() error {
+		return s.setPaused(ctx, !s.paused())
+	})
 }
 
-// Returns whether the current track is paused.
-func (s *MusicSession) Paused() bool {
-	player := (*s.player)
+func (s *MusicSession) setPaused(ctx context.Context, paused bool) error {
+	err := s.player.Update(ctx, lavalink.WithPaused(paused))
+	if err != nil {
+		return err
+	}
 
-	return player.Paused()
+	// The position stops or starts moving from here.
+	s.playerState.Position = s.position()
+	s.playerState.Time = lavalink.Now()
+	s.playerPaused = paused
+	s.pauseListening(paused)
+
+	// Lavalink doesn't send pause events, so the panel has to be updated here.
+	s.refreshPanel()
+
+	return nil
 }
 
-// Resumes the current track.
-func (s *MusicSession) Resume() error {
-	player := (*s.player)
+func (s *MusicSession) paused() bool {
+	return s.playerPaused
+}
 
-	return player.Update(context.TODO(), lavalink.WithPaused(false))
+// Skip plays the next track in the queue, or stops if there is none.
+func (s *MusicSession) Skip(ctx context.Context) error {
+	return s.do(ctx, func() error {
+		return s.skip(ctx)
+	})
 }
 
-func (s *MusicSession) Skip() error {
-	player := (*s.player)
+func (s *MusicSession) skip(ctx context.Context) error {
+	if s.loop == LoopQueue && s.current != nil {
+		s.requeue(s.current)
+	}
+
+	next, data := s.dequeue()
+	if next == nil && s.sleepsAfter(SleepAfterQueue) {
+		return s.sleep(ctx)
+	}
+
+	// The current track keeps playing until autoplay found one to replace it.
+	if next == nil && s.current != nil && s.autoplay() {
+		return nil
+	}
 
-	next, data := s.Dequeue()
 	if next == nil {
-		return s.Stop()
-	} else {
-		player.Update(context.TODO(), lavalink.WithTrack(*next), lavalink.WithTrackUserData(data))
+		return s.stop(ctx)
 	}
 
-	return nil
+	return s.play(ctx, next, data)
 }
 
-func (s *MusicSession) Stop() error {
-	player := (*s.player)
+// Stop stops the current track. The queue is kept.
+func (s *MusicSession) Stop(ctx context.Context) error {
+	return s.do(ctx, func() error {
+		return s.stop(ctx)
+	})
+}
 
-	player.Update(context.TODO(), lavalink.WithNullTrack())
+func (s *MusicSession) stop(ctx context.Context) error {
+	s.current = nil
+	s.pendingAutoplay = nil
 
-	return nil
+	s.cancelSkipVote()
+	s.closePanel()
+
+	return s.player.Update(ctx, lavalink.WithNullTrack())
 }
 
-func (s *MusicSession) Position() lavalink.Duration {
-	player := (*s.player)
+// position returns the current track's position, accounting for the time since the last player update.
+func (s *MusicSession) position() lavalink.Duration {
+	if s.current == nil {
+		return 0
+	}
 
-	return player.State().Position
-}
+	position := s.playerState.Position
+	if !s.playerPaused {
+		position += lavalink.Duration(time.Since(s.playerState.Time.Time).Milliseconds())
+	}
 
-func (s *MusicSession) Remaining() lavalink.Duration {
-	player := (*s.player)
+	if position > s.current.Info.Length {
+		return s.current.Info.Length
+	} else if position < 0 {
+		return 0
+	}
 
-	return player.Track().Info.Length - player.State().Position
+	return position
 }
 
-func (s *MusicSession) Volume(volume int) error {
-	player := (*s.player)
+// handlePlayerUpdate is called when Lavalink reports the player's state, every few seconds while playing.
+func (s *MusicSession) handlePlayerUpdate(state lavalink.PlayerState) {
+	s.playerState = state
+	s.tickPanel()
+}
 
-	return player.Update(context.TODO(), lavalink.WithVolume(volume))
+// Seek jumps to a position within the current track.
+func (s *MusicSession) Seek(ctx context.Context, position lavalink.Duration) error {
+	return s.do(ctx, func() error {
+		return s.seek(ctx, position)
+	})
 }
 
-func (s *MusicSession) HandleTrackStart(track *lavalink.Track) error {
-	s.CurrentTrack = track
+func (s *MusicSession) seek(ctx context.Context, position lavalink.Duration) error {
+	if s.current == nil {
+		return ErrNotPlaying
+	}
 
-	textChannel, err := s.session.Channel(s.TextChannelID)
+	if s.current.Info.IsStream {
+		return ErrNotSeekable
+	}
+
+	if position < 0 || position > s.current.Info.Length {
+		return ErrSeekOutOfRange
+	}
+
+	err := s.player.Update(ctx, lavalink.WithPosition(position))
 	if err != nil {
 		return err
 	}
 
-	// Get the prominent color of the track's artwork.
-	color := static.ColorEmbedGray
-	c, err := colorutils.GetDominantColorFromImageURL(*track.Info.ArtworkURL)
-	if err == nil {
-		color = c
+	// Lavalink only reports the new position with its next player update,
+	// so reflect it locally right away.
+	s.playerState.Position = position
+	s.playerState.Time = lavalink.Now()
+
+	return nil
+}
+
+// Replay restarts the current track from the beginning.
+func (s *MusicSession) Replay(ctx context.Context) error {
+	return s.do(ctx, func() error {
+		return s.seek(ctx, 0)
+	})
+}
+
+// Volume sets the player's volume.
+func (s *MusicSession) Volume(ctx context.Context, volume int) error {
+	return s.do(ctx, func() error {
+		return s.player.Update(ctx, lavalink.WithVolume(volume))
+	})
+}
+
+// UpdateFilters replaces the session's audio filters with what f makes of them, and applies them to the player.
+func (s *MusicSession) UpdateFilters(ctx context.Context, f func(lavalink.Filters) lavalink.Filters) (filters lavalink.Filters, err error) {
+	err = s.do(ctx, func() error {
+		filters = f(s.filters)
+
+		err := s.player.Update(ctx, lavalink.WithFilters(filters))
+		if err != nil {
+			return err
+		}
+
+		s.filters = filters
+		s.refreshPanel()
+
+		return nil
+	})
+
+	return filters, err
+}
+
+// ApplySettings replaces the guild's music settings used by the session.
+func (s *MusicSession) ApplySettings(ctx context.Context, settings database.MusicSettings) error {
+	return s.do(ctx, func() error {
+		s.settings = settings
+		return nil
+	})
+}
+
+func (s *MusicSession) handleTrackStart(track *lavalink.Track) error {
+	s.current = track
+
+	// A retried track starts again too, so only another track makes the failed one's attempts start over.
+	if track.Info.Identifier != s.failedIdentifier {
+		s.failedIdentifier = ""
+		s.failedAttempts = 0
+	}
+	s.remember(track)
+	s.startListening(track)
+
+	// Votes are only ever about the track that was playing when they started.
+	if s.skipVote != nil && s.skipVote.Track != track.Encoded {
+		s.cancelSkipVote()
+	}
+
+	s.colorPanel(track)
+	s.showPanel()
+
+	return nil
+}
+
+func (s *MusicSession) handleTrackEnd(ctx context.Context, track *lavalink.Track, reason lavalink.TrackEndReason) error {
+	// Whatever replaced the track has been claimed as the current one already. The same goes for failed tracks,
+	// which handleTrackFailure replaced by a retry, often with the same encoded track, or by the next one.
+	replaced := reason == lavalink.TrackEndReasonReplaced || reason == lavalink.TrackEndReasonLoadFailed
+	if !replaced && s.current != nil && s.current.Encoded == track.Encoded {
+		s.current = nil
+	}
+
+	s.record(track, reason)
+
+	// Replaced and stopped tracks were ended by us, and failed tracks are
+	// taken care of by handleTrackFailure, so only finished tracks advance the queue.
+	if reason != lavalink.TrackEndReasonFinished {
+		return nil
 	}
 
+	s.consecutiveFailures = 0
+	s.failedIdentifier = ""
+	s.failedAttempts = 0
+
+	if s.sleepsAfter(SleepAfterTrack) {
+		return s.sleep(ctx)
+	}
+
+	switch s.loop {
+	case LoopTrack:
+		var data TrackRequestData
+		track.UserData.Unmarshal(&data)
+
+		return s.play(ctx, track, data)
+	case LoopQueue:
+		s.requeue(track)
+	}
+
+	// Something else may have started playing in the meantime.
+	if s.current != nil {
+		return nil
+	}
+
+	if len(s.queue) == 0 {
+		if s.sleepsAfter(SleepAfterQueue) {
+			return s.sleep(ctx)
+		}
+
+		if s.autoplay() {
+			return nil
+		}
+
+		s.closePanel()
+		return nil
+	}
+
+	next, data := s.dequeue()
+
+	return s.play(ctx, next, data)
+}
+
+// handleTrackFailure is called when a track throws an exception or gets stuck.
+// Depending on the session's FailurePolicy, the track is reloaded and retried,
+// skipped in favor of the next queued track, or playback is stopped altogether.
+func (s *MusicSession) handleTrackFailure(ctx context.Context, track *lavalink.Track, cause string) error {
+	// Retries of a track count as one failure towards stopping, so every failed track gets its retries.
+	if track.Info.Identifier == s.failedIdentifier {
+		s.failedAttempts++
+	} else {
+		s.failedIdentifier = track.Info.Identifier
+		s.failedAttempts = 1
+		s.consecutiveFailures++
+	}
+
+	slog.Warn("Track failed to play.",
+		slog.String("guild_id", s.GuildID),
+		slog.String("identifier", track.Info.Identifier),
+		slog.String("source", track.Info.SourceName),
+		slog.String("cause", cause),
+		slog.Int("attempt", s.failedAttempts),
+		slog.Int("consecutive_failures", s.consecutiveFailures))
+
 	var data TrackRequestData
-	slog.Debug(fmt.Sprintf("Unmarshalling user data: %s", track.UserData.String()))
 	track.UserData.Unmarshal(&data)
 
-	author, err := s.session.User(data.AuthorID)
-	if err != nil {
-		return err
+	title := stringutils.Truncate(track.Info.Title, 30)
+
+	switch s.FailurePolicy.Decide(s.failedAttempts, s.consecutiveFailures) {
+	case FailureActionRetry:
+		s.retry(&trackRetry{track: track, data: data, title: title, cause: cause})
+		return nil
+
+	case FailureActionSkip:
+		return s.skipFailed(ctx, title, cause)
+
+	default:
+		s.notify(fmt.Sprintf("Failed to play **%s** (`%s`).\n%d tracks failed in a row, so I stopped playing.",
+			title, cause, s.consecutiveFailures))
+
+		s.consecutiveFailures = 0
+		return s.stop(ctx)
 	}
+}
+
+// trackRetry is a failed track being reloaded off the event loop to be played again.
+type trackRetry struct {
+	track *lavalink.Track
+	data  TrackRequestData
+	title string // The failed track's title, shortened for messages.
+	cause string // Why the track failed.
+}
 
-	embed := &discordgo.MessageEmbed{
-		Title: "🎶  **Now Playing**",
-		Color: color,
-		Description: fmt.Sprintf("**[%s](%s)**\nby %s\n",
-			stringutils.Truncate(track.Info.Title, 30), *track.Info.URI, track.Info.Author),
-		Thumbnail: &discordgo.MessageEmbedThumbnail{
-			URL: *track.Info.ArtworkURL,
-		},
-		Footer: &discordgo.MessageEmbedFooter{
-			IconURL: author.AvatarURL("512"),
-			Text:    fmt.Sprintf("Requested by %s", author.Username),
-		},
+// retry reloads a failed track in the background and hands it to the event loop to play again.
+func (s *MusicSession) retry(retry *trackRetry) {
+	s.retrying = retry
+
+	go func() {
+		ctx, cancel := context.WithTimeout(s.ctx, OperationTimeout)
+		defer cancel()
+
+		track, err := s.reload(ctx, retry.track)
+
+		s.post(func(ctx context.Context) {
+			if err := s.handleReload(ctx, retry, track, err); err != nil {
+				slog.Error("Failed to retry a track.", slog.String("guild_id", s.GuildID), slog.String("error", err.Error()))
+			}
+		})
+	}()
+}
+
+// handleReload plays a reloaded track again, or skips it if it couldn't be reloaded.
+func (s *MusicSession) handleReload(ctx context.Context, retry *trackRetry, track *lavalink.Track, err error) error {
+	// Another failure took over.
+	if s.retrying != retry {
+		return nil
+	}
+
+	s.retrying = nil
+
+	// Someone skipped or stopped the track in the meantime.
+	if s.current == nil || s.current.Encoded != retry.track.Encoded {
+		return nil
 	}
 
-	_, err = s.session.ChannelMessageSendEmbed(textChannel.ID, embed)
 	if err != nil {
-		return err
+		slog.Warn("Failed to reload track.",
+			slog.String("identifier", retry.track.Info.Identifier), slog.String("error", err.Error()))
+
+		return s.skipFailed(ctx, retry.title, retry.cause)
 	}
 
-	return nil
+	s.notify(fmt.Sprintf("Failed to play **%s** (`%s`). Trying again...", retry.title, retry.cause))
+	return s.play(ctx, track, retry.data)
 }
 
-func (s *MusicSession) HandleTrackPause(track *lavalink.Track) error {
-	return nil
+// skipFailed plays the next track in place of one that failed, or stops if there is none.
+func (s *MusicSession) skipFailed(ctx context.Context, title string, cause string) error {
+	next, data := s.dequeue()
+	if next == nil {
+		s.notify(fmt.Sprintf("Failed to play **%s** (`%s`). There's nothing left in the queue.", title, cause))
+		return s.stop(ctx)
+	}
+
+	s.notify(fmt.Sprintf("Failed to play **%s** (`%s`). Skipping to the next track.", title, cause))
+	return s.play(ctx, next, data)
 }
 
-func (s *MusicSession) HandleTrackEnd(track *lavalink.Track) error {
-	s.CurrentTrack = nil
+// reload loads a fresh copy of a track from Lavalink,
+// since the encoded track of a failed one may point to a stale stream.
+func (s *MusicSession) reload(ctx context.Context, track *lavalink.Track) (*lavalink.Track, error) {
+	identifier := track.Info.Identifier
+	if track.Info.URI != nil {
+		identifier = *track.Info.URI
+	}
 
-	if len(s.Queue) == 0 {
-		return nil
+	result, err := s.node.LoadTracks(ctx, identifier)
+	if err != nil {
+		return nil, err
+	}
+
+	switch data := result.Data.(type) {
+	case lavalink.Track:
+		return &data, nil
+	case lavalink.Search:
+		if len(data) > 0 {
+			return &data[0], nil
+		}
+	case lavalink.Exception:
+		return nil, data
 	}
 
-	next, data := s.Dequeue()
+	return nil, fmt.Errorf("no track found for %s", identifier)
+}
 
-	s.Play(next, data)
+// notify sends a message to the session's text channel, if it has one.
+func (s *MusicSession) notify(description string) {
+	s.send(embedutils.CreateErrorEmbed(description))
+}
 
-	return nil
+// announce is notify for news that isn't an error.
+func (s *MusicSession) announce(description string) {
+	s.send(embedutils.CreateBasicEmbed(description))
+}
+
+// send sends an embed to the session's text channel in the background, if it has one.
+// In the request channel, it's deleted again after RequestReplyLifetime.
+func (s *MusicSession) send(embed *discordgo.MessageEmbed) {
+	if s.textChannelID == "" || s.session == nil {
+		return
+	}
+
+	message := outgoingMessage{channelID: s.textChannelID, embed: embed}
+	if s.inRequestChannel() {
+		message.lifetime = RequestReplyLifetime
+	}
+
+	s.panels.send(message)
 }
@@ -0,0 +1,343 @@
+package music
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/disgoorg/disgolink/v3/lavalink"
+
+	"unreal.sh/neo/internal/database"
+)
+
+// fakePlayer stands in for a Lavalink player, accepting every update without a node.
+type fakePlayer struct {
+	mu      sync.Mutex
+	updates int
+}
+
+func (p *fakePlayer) Update(ctx context.Context, opts ...lavalink.PlayerUpdateOpt) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.updates++
+
+	return nil
+}
+
+func newTestSession(t *testing.T) *MusicSession {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	session := NewMusicSession(ctx, nil, &fakePlayer{}, nil, "1", "")
+
+	t.Cleanup(func() {
+		cancel()
+		<-session.Done()
+	})
+
+	return session
+}
+
+func testTrack(id string) *lavalink.Track {
+	return &lavalink.Track{
+		Encoded: id,
+		Info: lavalink.TrackInfo{
+			Identifier: id,
+			Title:      id,
+			Length:     lavalink.Duration(3 * time.Minute / time.Millisecond),
+			SourceName: "test",
+		},
+	}
+}
+
+func TestSessionConcurrentOperations(t *testing.T) {
+	session := newTestSession(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	const workers = 8
+	const rounds = 50
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		w := w
+
+		wg.Add(4)
+
+		go func() {
+			defer wg.Done()
+
+			for i := 0; i < rounds; i++ {
+				track := testTrack(fmt.Sprintf("%d-%d", w, i))
+				if _, err := session.PlayOrEnqueue(ctx, track, TrackRequestData{AuthorID: fmt.Sprint(w)}); err != nil {
+					t.Errorf("PlayOrEnqueue: %v", err)
+					return
+				}
+			}
+		}()
+
+		go func() {
+			defer wg.Done()
+
+			for i := 0; i < rounds; i++ {
+				if err := session.Skip(ctx); err != nil {
+					t.Errorf("Skip: %v", err)
+					return
+				}
+			}
+		}()
+
+		go func() {
+			defer wg.Done()
+
+			for i := 0; i < rounds; i++ {
+				state, err := session.State(ctx)
+				if err != nil {
+					t.Errorf("State: %v", err)
+					return
+				}
+
+				if !state.IsPlaying() {
+					continue
+				}
+
+				// Lavalink reports the end of whatever was playing, possibly after it was replaced already.
+				ended := state.CurrentTrack
+				session.post(func(ctx context.Context) {
+					if err := session.handleTrackEnd(ctx, ended, lavalink.TrackEndReasonFinished); err != nil {
+						t.Errorf("handleTrackEnd: %v", err)
+					}
+				})
+			}
+		}()
+
+		go func() {
+			defer wg.Done()
+
+			for i := 0; i < rounds; i++ {
+				if err := session.TogglePause(ctx); err != nil {
+					t.Errorf("TogglePause: %v", err)
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	state, err := session.State(ctx)
+	if err != nil {
+		t.Fatalf("State: %v", err)
+	}
+
+	if len(state.Queue) != len(state.Data) {
+		t.Fatalf("queue has %d tracks but %d request data", len(state.Queue), len(state.Data))
+	}
+}
+
+func TestSessionStaleTrackEnd(t *testing.T) {
+	session := newTestSession(t)
+	ctx := context.Background()
+
+	first, second := testTrack("first"), testTrack("second")
+
+	if _, err := session.PlayOrEnqueue(ctx, first, TrackRequestData{}); err != nil {
+		t.Fatalf("PlayOrEnqueue: %v", err)
+	}
+
+	if _, err := session.PlayOrEnqueue(ctx, second, TrackRequestData{}); err != nil {
+		t.Fatalf("PlayOrEnqueue: %v", err)
+	}
+
+	if err := session.Skip(ctx); err != nil {
+		t.Fatalf("Skip: %v", err)
+	}
+
+	// The end of the skipped track arrives after the next one was claimed.
+	err := session.do(ctx, func() error {
+		return session.handleTrackEnd(ctx, first, lavalink.TrackEndReasonReplaced)
+	})
+	if err != nil {
+		t.Fatalf("handleTrackEnd: %v", err)
+	}
+
+	state, err := session.State(ctx)
+	if err != nil {
+		t.Fatalf("State: %v", err)
+	}
+
+	if !state.IsPlaying() || state.CurrentTrack.Encoded != second.Encoded {
+		t.Fatalf("expected %q to be playing, got %+v", second.Encoded, state.CurrentTrack)
+	}
+}
+
+func TestSessionClosed(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	session := NewMusicSession(ctx, nil, &fakePlayer{}, nil, "1", "")
+
+	cancel()
+
+	select {
+	case <-session.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("event loop didn't stop")
+	}
+
+	err := session.Skip(context.Background())
+	if !errors.Is(err, ErrSessionClosed) {
+		t.Fatalf("expected ErrSessionClosed, got %v", err)
+	}
+}
+
+// blockingNode loads every query as a search for its tracks, once it's released.
+type blockingNode struct {
+	release chan struct{}
+	tracks  []lavalink.Track
+}
+
+func (n *blockingNode) LoadTracks(ctx context.Context, identifier string) (*lavalink.LoadResult, error) {
+	select {
+	case <-n.release:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	return &lavalink.LoadResult{LoadType: lavalink.LoadTypeSearch, Data: lavalink.Search(n.tracks)}, nil
+}
+
+func TestSessionAutoplayOffLoop(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	node := &blockingNode{release: make(chan struct{}), tracks: []lavalink.Track{*testTrack("related")}}
+	session := NewMusicSession(ctx, nil, &fakePlayer{}, node, "1", "")
+
+	t.Cleanup(func() {
+		cancel()
+		<-session.Done()
+	})
+
+	if err := session.ApplySettings(ctx, database.MusicSettings{Autoplay: true}); err != nil {
+		t.Fatalf("ApplySettings: %v", err)
+	}
+
+	first := testTrack("first")
+	first.Info.Author = "artist"
+
+	if _, err := session.PlayOrEnqueue(ctx, first, TrackRequestData{}); err != nil {
+		t.Fatalf("PlayOrEnqueue: %v", err)
+	}
+
+	if err := session.Skip(ctx); err != nil {
+		t.Fatalf("Skip: %v", err)
+	}
+
+	// The session keeps going while autoplay is still loading, with the skipped track playing until it's replaced.
+	stateCtx, stateCancel := context.WithTimeout(ctx, time.Second)
+	defer stateCancel()
+
+	state, err := session.State(stateCtx)
+	if err != nil {
+		t.Fatalf("State while autoplay is loading: %v", err)
+	}
+
+	if state.CurrentTrack == nil || state.CurrentTrack.Encoded != first.Encoded {
+		t.Fatalf("expected %q to keep playing, got %+v", first.Encoded, state.CurrentTrack)
+	}
+
+	close(node.release)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		state, err := session.State(ctx)
+		if err != nil {
+			t.Fatalf("State: %v", err)
+		}
+
+		if state.CurrentTrack != nil && state.CurrentTrack.Encoded == "related" {
+			return
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("expected autoplay to play the related track, got %+v", state.CurrentTrack)
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSessionRetryOffLoop(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	broken := testTrack("broken")
+	node := &blockingNode{release: make(chan struct{}), tracks: []lavalink.Track{*broken}}
+	session := NewMusicSession(ctx, nil, &fakePlayer{}, node, "1", "")
+
+	t.Cleanup(func() {
+		cancel()
+		<-session.Done()
+	})
+
+	if _, err := session.PlayOrEnqueue(ctx, broken, TrackRequestData{}); err != nil {
+		t.Fatalf("PlayOrEnqueue: %v", err)
+	}
+
+	if _, err := session.PlayOrEnqueue(ctx, testTrack("next"), TrackRequestData{}); err != nil {
+		t.Fatalf("PlayOrEnqueue: %v", err)
+	}
+
+	err := session.do(ctx, func() error {
+		return session.handleTrackFailure(ctx, broken, "decoding failed")
+	})
+	if err != nil {
+		t.Fatalf("handleTrackFailure: %v", err)
+	}
+
+	// Skipping while the track is being reloaded takes precedence over the retry.
+	skipCtx, skipCancel := context.WithTimeout(ctx, time.Second)
+	defer skipCancel()
+
+	if err := session.Skip(skipCtx); err != nil {
+		t.Fatalf("Skip while the track is being reloaded: %v", err)
+	}
+
+	close(node.release)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var retrying bool
+		session.do(ctx, func() error {
+			retrying = session.retrying != nil
+			return nil
+		})
+
+		if !retrying {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatal("the reloaded track was never handed back")
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	state, err := session.State(ctx)
+	if err != nil {
+		t.Fatalf("State: %v", err)
+	}
+
+	if state.CurrentTrack == nil || state.CurrentTrack.Encoded != "next" {
+		t.Fatalf("expected the skip to stick, got %+v", state.CurrentTrack)
+	}
+}
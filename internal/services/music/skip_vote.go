@@ -0,0 +1,98 @@
+package music
+
+import (
+	"context"
+	"errors"
+)
+
+// DefaultSkipVoteThreshold is the percentage of listeners that have to vote to skip a track,
+// for guilds that haven't configured their own.
+const DefaultSkipVoteThreshold = 50
+
+var ErrNoSkipVote = errors.New("no vote to skip the current track")
+
+// SkipVote is an ongoing vote to skip the current track.
+type SkipVote struct {
+	Track  string          // The encoded track being voted on.
+	Voters map[string]bool // IDs of the users that voted.
+
+	// OnEnd is called once the vote either passed or was cancelled because the track changed.
+	OnEnd func(passed bool)
+}
+
+// RequiredSkipVotes returns how many votes out of listeners are needed to skip with a threshold percentage.
+func RequiredSkipVotes(listeners int, threshold int) int {
+	required := (listeners*threshold + 99) / 100
+	if required < 1 {
+		return 1
+	}
+
+	return required
+}
+
+// StartSkipVote starts a vote to skip the current track, replacing any ongoing vote.
+func (s *MusicSession) StartSkipVote(ctx context.Context, onEnd func(passed bool)) error {
+	return s.do(ctx, func() error {
+		if s.current == nil {
+			return ErrNotPlaying
+		}
+
+		s.cancelSkipVote()
+
+		s.skipVote = &SkipVote{
+			Track:  s.current.Encoded,
+			Voters: make(map[string]bool),
+			OnEnd:  onEnd,
+		}
+
+		return nil
+	})
+}
+
+// AddSkipVote counts a user's vote to skip. Once required votes are reached,
+// the vote ends and the current track is skipped.
+// If there's no vote about the current track, ErrNoSkipVote is returned.
+func (s *MusicSession) AddSkipVote(ctx context.Context, userID string, required int) (votes int, passed bool, err error) {
+	err = s.do(ctx, func() error {
+		if s.current == nil {
+			return ErrNotPlaying
+		}
+
+		vote := s.skipVote
+		if vote == nil || vote.Track != s.current.Encoded {
+			return ErrNoSkipVote
+		}
+
+		vote.Voters[userID] = true
+		votes = len(vote.Voters)
+
+		if votes < required {
+			return nil
+		}
+
+		s.skipVote = nil
+		passed = true
+
+		if vote.OnEnd != nil {
+			go vote.OnEnd(true)
+		}
+
+		return s.skip(ctx)
+	})
+
+	return votes, passed, err
+}
+
+// cancelSkipVote cancels the ongoing skip vote, if there is one.
+func (s *MusicSession) cancelSkipVote() {
+	vote := s.skipVote
+	if vote == nil {
+		return
+	}
+
+	s.skipVote = nil
+
+	if vote.OnEnd != nil {
+		go vote.OnEnd(false)
+	}
+}
@@ -0,0 +1,130 @@
+package music_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+
+	"unreal.sh/neo/internal/database"
+	"unreal.sh/neo/internal/services/music"
+)
+
+func TestRequiredSkipVotes(t *testing.T) {
+	tests := []struct {
+		listeners int
+		threshold int
+		expected  int
+	}{
+		{0, 50, 1},
+		{1, 50, 1},
+		{2, 50, 1},
+		{3, 50, 2},
+		{4, 50, 2},
+		{5, 100, 5},
+		{10, 1, 1},
+		{10, 34, 4},
+	}
+
+	for _, test := range tests {
+		if required := music.RequiredSkipVotes(test.listeners, test.threshold); required != test.expected {
+			t.Errorf("RequiredSkipVotes(%d, %d) = %d, expected %d", test.listeners, test.threshold, required, test.expected)
+		}
+	}
+}
+
+func TestSkipsRightAway(t *testing.T) {
+	member := func(id string, permissions int64, roles ...string) *discordgo.Member {
+		return &discordgo.Member{User: &discordgo.User{ID: id}, Permissions: permissions, Roles: roles}
+	}
+
+	service, _ := newTestService(t)
+	guildID := testGuildID.String()
+
+	// Without a DJ role, only managers and the requester skip right away.
+	if !service.SkipsRightAway(guildID, member("1", 0), "1") {
+		t.Error("expected the requester to skip right away")
+	}
+
+	if !service.SkipsRightAway(guildID, member("2", discordgo.PermissionManageServer), "1") {
+		t.Error("expected a server manager to skip right away")
+	}
+
+	if service.SkipsRightAway(guildID, member("2", 0), "1") {
+		t.Error("expected everyone else to vote without a DJ role")
+	}
+
+	store := &memoryStore{settings: map[string]database.GuildSettings{
+		guildID: {GuildID: guildID, MusicSettings: database.MusicSettings{DJRoleID: "dj"}},
+	}}
+	service, _ = newTestServiceWithStore(t, store)
+
+	if !service.SkipsRightAway(guildID, member("2", 0, "dj"), "1") {
+		t.Error("expected a DJ to skip right away")
+	}
+
+	if service.SkipsRightAway(guildID, member("2", 0, "listener"), "1") {
+		t.Error("expected someone without the DJ role to vote")
+	}
+}
+
+func TestSkipVote(t *testing.T) {
+	service, server := newTestService(t)
+
+	for _, identifier := range []string{"first", "second", "third"} {
+		play(t, service, *addTrack(server, identifier).Info.URI)
+	}
+
+	waitForTrack(t, server, "first")
+
+	session := service.MusicSession(testGuildID.String(), "")
+
+	ctx, cancel := service.OperationContext()
+	defer cancel()
+
+	if _, _, err := session.AddSkipVote(ctx, "1", 2); !errors.Is(err, music.ErrNoSkipVote) {
+		t.Fatalf("expected no vote to be ongoing, got %v", err)
+	}
+
+	ended := make(chan bool, 1)
+	if err := session.StartSkipVote(ctx, func(passed bool) { ended <- passed }); err != nil {
+		t.Fatalf("StartSkipVote: %v", err)
+	}
+
+	// Voting twice counts once.
+	for i := 0; i < 2; i++ {
+		votes, passed, err := session.AddSkipVote(ctx, "1", 2)
+		if err != nil || passed || votes != 1 {
+			t.Fatalf("expected one vote that didn't pass yet, got %d votes, passed %t, error %v", votes, passed, err)
+		}
+	}
+
+	if votes, passed, err := session.AddSkipVote(ctx, "2", 2); err != nil || !passed || votes != 2 {
+		t.Fatalf("expected the second vote to pass, got %d votes, passed %t, error %v", votes, passed, err)
+	}
+
+	if passed := <-ended; !passed {
+		t.Fatal("expected the vote to end as passed")
+	}
+
+	waitForTrack(t, server, "second")
+
+	// A vote about a track that stopped playing ends without passing.
+	if err := session.StartSkipVote(ctx, func(passed bool) { ended <- passed }); err != nil {
+		t.Fatalf("StartSkipVote: %v", err)
+	}
+
+	if err := session.Skip(ctx); err != nil {
+		t.Fatalf("Skip: %v", err)
+	}
+
+	waitForTrack(t, server, "third")
+
+	if passed := <-ended; passed {
+		t.Fatal("expected the vote to be cancelled when the track changed")
+	}
+
+	if _, _, err := session.AddSkipVote(ctx, "1", 2); !errors.Is(err, music.ErrNoSkipVote) {
+		t.Fatalf("expected the cancelled vote to be over, got %v", err)
+	}
+}
@@ -0,0 +1,143 @@
+package music
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/disgoorg/disgolink/v3/lavalink"
+)
+
+var ErrNoSleepTimer = errors.New("no sleep timer is set")
+
+// SleepMode is when a sleep timer stops playback.
+type SleepMode int
+
+const (
+	SleepAfterDuration SleepMode = iota // Once a set time is reached.
+	SleepAfterTrack                     // Once the next track finishes.
+	SleepAfterQueue                     // Once the queue runs dry, instead of autoplaying.
+)
+
+// SleepTimer stops playback and leaves the voice channel at some point,
+// for people falling asleep to music.
+type SleepTimer struct {
+	Mode SleepMode
+	At   time.Time // When the timer runs out, with SleepAfterDuration.
+
+	timer *time.Timer
+}
+
+// String describes when the timer runs out, for messages.
+func (t SleepTimer) String() string {
+	switch t.Mode {
+	case SleepAfterTrack:
+		return "after this track"
+	case SleepAfterQueue:
+		return "after the queue ends"
+	default:
+		return fmt.Sprintf("<t:%d:R>", t.At.Unix())
+	}
+}
+
+// SetSleepTimer schedules the session to stop and leave, replacing any timer set before.
+// With SleepAfterDuration, the timer runs out after d.
+func (s *MusicSession) SetSleepTimer(ctx context.Context, mode SleepMode, d time.Duration) (timer SleepTimer, err error) {
+	err = s.do(ctx, func() error {
+		timer = *s.setSleepTimer(mode, d)
+		s.refreshPanel()
+
+		return nil
+	})
+
+	return timer, err
+}
+
+// setSleepTimer is SetSleepTimer for code already running on the event loop.
+func (s *MusicSession) setSleepTimer(mode SleepMode, d time.Duration) *SleepTimer {
+	s.cancelSleepTimer()
+
+	sleepTimer := &SleepTimer{Mode: mode}
+	if mode == SleepAfterDuration {
+		sleepTimer.At = time.Now().Add(d)
+		sleepTimer.timer = time.AfterFunc(d, func() {
+			s.post(func(ctx context.Context) {
+				// The timer may have been cancelled or replaced while this was waiting.
+				if s.sleepTimer != sleepTimer {
+					return
+				}
+
+				if err := s.sleep(ctx); err != nil {
+					slog.Error("Failed to stop for the sleep timer.",
+						slog.String("guild_id", s.GuildID), slog.String("error", err.Error()))
+				}
+			})
+		})
+	}
+
+	s.sleepTimer = sleepTimer
+
+	return sleepTimer
+}
+
+// CancelSleepTimer clears the session's sleep timer.
+// If none is set, ErrNoSleepTimer is returned.
+func (s *MusicSession) CancelSleepTimer(ctx context.Context) error {
+	return s.do(ctx, func() error {
+		if s.sleepTimer == nil {
+			return ErrNoSleepTimer
+		}
+
+		s.cancelSleepTimer()
+		s.refreshPanel()
+
+		return nil
+	})
+}
+
+func (s *MusicSession) cancelSleepTimer() {
+	if s.sleepTimer == nil {
+		return
+	}
+
+	if s.sleepTimer.timer != nil {
+		s.sleepTimer.timer.Stop()
+	}
+
+	s.sleepTimer = nil
+}
+
+// sleepsAfter returns whether the sleep timer runs out when mode says so.
+func (s *MusicSession) sleepsAfter(mode SleepMode) bool {
+	return s.sleepTimer != nil && s.sleepTimer.Mode == mode
+}
+
+// sleep stops playback, clears the queue and leaves the voice channel, once the sleep timer ran out.
+func (s *MusicSession) sleep(ctx context.Context) error {
+	s.cancelSleepTimer()
+
+	s.queue = make([]*lavalink.Track, 0)
+	s.data = make([]TrackRequestData, 0)
+
+	if err := s.stop(ctx); err != nil {
+		return err
+	}
+
+	if s.session == nil {
+		return nil
+	}
+
+	// Saying good night is enough, without also hearing about being disconnected.
+	s.leaving = true
+
+	if err := s.session.ChannelVoiceJoinManual(s.GuildID, "", false, false); err != nil {
+		s.leaving = false
+		return err
+	}
+
+	s.announce("💤 **Good night!** The sleep timer ran out, so I stopped playing.")
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package music
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/disgoorg/disgolink/v3/lavalink"
+)
+
+func TestSleepAfterTrack(t *testing.T) {
+	session := newTestSession(t)
+	ctx := context.Background()
+
+	first, second := testTrack("first"), testTrack("second")
+	for _, track := range []*lavalink.Track{first, second} {
+		if _, err := session.PlayOrEnqueue(ctx, track, TrackRequestData{}); err != nil {
+			t.Fatalf("PlayOrEnqueue: %v", err)
+		}
+	}
+
+	if _, err := session.SetSleepTimer(ctx, SleepAfterTrack, 0); err != nil {
+		t.Fatalf("SetSleepTimer: %v", err)
+	}
+
+	state, err := session.State(ctx)
+	if err != nil {
+		t.Fatalf("State: %v", err)
+	}
+
+	if state.SleepTimer == nil || state.SleepTimer.Mode != SleepAfterTrack {
+		t.Fatalf("expected a sleep timer after the track, got %+v", state.SleepTimer)
+	}
+
+	err = session.do(ctx, func() error {
+		return session.handleTrackEnd(ctx, first, lavalink.TrackEndReasonFinished)
+	})
+	if err != nil {
+		t.Fatalf("handleTrackEnd: %v", err)
+	}
+
+	state, err = session.State(ctx)
+	if err != nil {
+		t.Fatalf("State: %v", err)
+	}
+
+	if state.IsPlaying() || len(state.Queue) != 0 || state.SleepTimer != nil {
+		t.Fatalf("expected the session to have stopped for good, got %+v", state)
+	}
+}
+
+func TestSleepAfterDuration(t *testing.T) {
+	session := newTestSession(t)
+	ctx := context.Background()
+
+	if _, err := session.PlayOrEnqueue(ctx, testTrack("track"), TrackRequestData{}); err != nil {
+		t.Fatalf("PlayOrEnqueue: %v", err)
+	}
+
+	if _, err := session.SetSleepTimer(ctx, SleepAfterDuration, 10*time.Millisecond); err != nil {
+		t.Fatalf("SetSleepTimer: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		state, err := session.State(ctx)
+		if err != nil {
+			t.Fatalf("State: %v", err)
+		}
+
+		if !state.IsPlaying() && state.SleepTimer == nil {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatal("the sleep timer never ran out")
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := session.CancelSleepTimer(ctx); err != ErrNoSleepTimer {
+		t.Fatalf("expected ErrNoSleepTimer, got %v", err)
+	}
+}
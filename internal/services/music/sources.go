@@ -0,0 +1,190 @@
+package music
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/disgoorg/disgolink/v3/disgolink"
+	"github.com/disgoorg/disgolink/v3/lavalink"
+
+	"unreal.sh/neo/internal/database"
+	stringutils "unreal.sh/neo/internal/utils/stringutils"
+)
+
+// SearchSource is a Lavalink search provider that non-URL queries can be resolved with.
+type SearchSource struct {
+	ID     string // Identifier used in settings and options, e.g. "deezer".
+	Name   string // Display name.
+	Prefix string // Lavalink search prefix, e.g. "dzsearch".
+}
+
+var SearchSources = []SearchSource{
+	{ID: "deezer", Name: "Deezer", Prefix: "dzsearch"},
+	{ID: "youtube", Name: "YouTube", Prefix: "ytsearch"},
+	{ID: "youtubemusic", Name: "YouTube Music", Prefix: "ytmsearch"},
+	{ID: "soundcloud", Name: "SoundCloud", Prefix: "scsearch"},
+	{ID: "spotify", Name: "Spotify", Prefix: "spsearch"},
+	{ID: "applemusic", Name: "Apple Music", Prefix: "amsearch"},
+}
+
+// DefaultSearchSources are used for guilds that haven't configured their own.
+// They can be overridden globally with a comma-separated MUSIC_SEARCH_SOURCES environment variable.
+var DefaultSearchSources = []string{"deezer", "youtube", "soundcloud"}
+
+var ErrNoSearchResults = errors.New("no search results")
+
+// LookupSearchSource finds a search source by its ID or Lavalink prefix.
+func LookupSearchSource(name string) (SearchSource, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+
+	for _, source := range SearchSources {
+		if source.ID == name || source.Prefix == name {
+			return source, true
+		}
+	}
+
+	return SearchSource{}, false
+}
+
+// SplitSourcePrefix splits a query like "soundcloud:lofi" or "scsearch:lofi"
+// into its search source and the remaining query.
+func SplitSourcePrefix(query string) (source SearchSource, rest string, ok bool) {
+	name, rest, found := strings.Cut(query, ":")
+	if !found {
+		return SearchSource{}, query, false
+	}
+
+	source, ok = LookupSearchSource(name)
+	if !ok {
+		return SearchSource{}, query, false
+	}
+
+	return source, strings.TrimSpace(rest), true
+}
+
+// ParseSearchSources parses a comma-separated list of search source IDs or prefixes.
+func ParseSearchSources(list string) ([]string, error) {
+	ids := make([]string, 0)
+
+	for _, name := range strings.Split(list, ",") {
+		if strings.TrimSpace(name) == "" {
+			continue
+		}
+
+		source, ok := LookupSearchSource(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown search source %q", strings.TrimSpace(name))
+		}
+
+		ids = append(ids, source.ID)
+	}
+
+	return ids, nil
+}
+
+// SearchSources returns the ordered search sources of a guild.
+func (s *MusicService) SearchSources(guildID string) []string {
+	return ResolveSearchSources(s.MusicSettings(guildID))
+}
+
+// ResolveSearchSources returns the ordered search sources for a guild's music settings,
+// falling back to the bot's defaults if it hasn't configured any.
+func ResolveSearchSources(settings database.MusicSettings) []string {
+	if len(settings.SearchSources) > 0 {
+		return settings.SearchSources
+	}
+
+	if env, ok := os.LookupEnv("MUSIC_SEARCH_SOURCES"); ok {
+		if sources, err := ParseSearchSources(env); err == nil && len(sources) > 0 {
+			return sources
+		}
+	}
+
+	return DefaultSearchSources
+}
+
+// Search resolves a non-URL query by trying each source in order,
+// falling through to the next one when a source errors or finds nothing.
+// It returns the ID of the source that found the results.
+func (s *MusicService) Search(ctx context.Context, query string, sources []string) (*lavalink.LoadResult, string, error) {
+	var lastErr error = ErrNoSearchResults
+
+	for _, id := range sources {
+		source, ok := LookupSearchSource(id)
+		if !ok {
+			continue
+		}
+
+		result, err := s.tracks.LoadTracks(ctx, fmt.Sprintf("%s:%s", source.Prefix, query))
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		switch data := result.Data.(type) {
+		case lavalink.Exception:
+			lastErr = data
+			continue
+		case lavalink.Empty:
+			continue
+		case lavalink.Search:
+			if len(data) == 0 {
+				continue
+			}
+		}
+
+		return result, source.ID, nil
+	}
+
+	return nil, "", lastErr
+}
+
+// LoadTracks resolves a query into tracks. URLs are loaded directly, queries starting with
+// LibraryPrefix are looked up in the local library, and anything else is searched for with
+// the given sources in order. It returns the ID of the search source that found the tracks,
+// LibrarySource for the library, or an empty string for URLs.
+func (s *MusicService) LoadTracks(ctx context.Context, query string, sources []string) (*lavalink.LoadResult, string, error) {
+	if rest, ok := strings.CutPrefix(query, LibraryPrefix); ok {
+		if s.library == nil {
+			return nil, "", ErrNoLibrary
+		}
+
+		result, err := s.library.Load(strings.TrimSpace(rest))
+		return result, LibrarySource, err
+	}
+
+	if stringutils.IsUrl(query) {
+		result, err := s.tracks.LoadTracks(ctx, query)
+		return result, "", err
+	}
+
+	return s.Search(ctx, query, sources)
+}
+
+// HandleLoadResult passes the outcome of LoadTracks to handler,
+// the same way disgolink's Node.LoadTracksHandler does.
+func HandleLoadResult(result *lavalink.LoadResult, err error, handler disgolink.AudioLoadResultHandler) {
+	if errors.Is(err, ErrNoSearchResults) {
+		handler.NoMatches()
+		return
+	} else if err != nil {
+		handler.LoadFailed(err)
+		return
+	}
+
+	switch data := result.Data.(type) {
+	case lavalink.Track:
+		handler.TrackLoaded(data)
+	case lavalink.Playlist:
+		handler.PlaylistLoaded(data)
+	case lavalink.Search:
+		handler.SearchResultLoaded(data)
+	case lavalink.Empty:
+		handler.NoMatches()
+	case lavalink.Exception:
+		handler.LoadFailed(data)
+	}
+}
@@ -0,0 +1,67 @@
+package music
+
+import (
+	"reflect"
+	"testing"
+
+	"unreal.sh/neo/internal/database"
+)
+
+func TestSplitSourcePrefix(t *testing.T) {
+	tests := []struct {
+		query  string
+		source string // The ID of the source found, if any.
+		rest   string
+	}{
+		{"soundcloud:lofi beats", "soundcloud", "lofi beats"},
+		{"scsearch:lofi beats", "soundcloud", "lofi beats"},
+		{"YouTube: never gonna", "youtube", "never gonna"},
+		{"lofi beats", "", "lofi beats"},
+		{"bandcamp:lofi", "", "bandcamp:lofi"},
+		{"artist: song: remix", "", "artist: song: remix"},
+	}
+
+	for _, test := range tests {
+		source, rest, ok := SplitSourcePrefix(test.query)
+
+		if ok != (test.source != "") || source.ID != test.source || rest != test.rest {
+			t.Errorf("SplitSourcePrefix(%q) = %q, %q, %t, expected %q, %q", test.query, source.ID, rest, ok, test.source, test.rest)
+		}
+	}
+}
+
+func TestParseSearchSources(t *testing.T) {
+	sources, err := ParseSearchSources("youtube, scsearch,,Deezer")
+	if err != nil {
+		t.Fatalf("ParseSearchSources: %v", err)
+	}
+
+	if expected := []string{"youtube", "soundcloud", "deezer"}; !reflect.DeepEqual(sources, expected) {
+		t.Fatalf("expected %q, got %q", expected, sources)
+	}
+
+	if _, err := ParseSearchSources("youtube,bandcamp"); err == nil {
+		t.Fatal("expected an unknown source to be rejected")
+	}
+}
+
+func TestResolveSearchSources(t *testing.T) {
+	tests := []struct {
+		settings database.MusicSettings
+		env      string
+		expected []string
+	}{
+		{database.MusicSettings{}, "", DefaultSearchSources},
+		{database.MusicSettings{}, "spotify,youtube", []string{"spotify", "youtube"}},
+		{database.MusicSettings{}, "bandcamp", DefaultSearchSources},
+		{database.MusicSettings{SearchSources: []string{"soundcloud"}}, "spotify", []string{"soundcloud"}},
+	}
+
+	for _, test := range tests {
+		t.Setenv("MUSIC_SEARCH_SOURCES", test.env)
+
+		if sources := ResolveSearchSources(test.settings); !reflect.DeepEqual(sources, test.expected) {
+			t.Errorf("%+v with %q: expected %q, got %q", test.settings.SearchSources, test.env, test.expected, sources)
+		}
+	}
+}
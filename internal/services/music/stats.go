@@ -0,0 +1,190 @@
+package music
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/disgoorg/disgolink/v3/lavalink"
+
+	"unreal.sh/neo/internal/database"
+)
+
+const (
+	// StatsHistoryLimit is how many played tracks statistics are taken from at most.
+	StatsHistoryLimit = 10000
+
+	// StatsTopSize is how many tracks, artists and requesters statistics rank.
+	StatsTopSize = 30
+)
+
+// MusicStats sums up what was played in a guild, or by someone in it.
+type MusicStats struct {
+	Since time.Time
+
+	Plays         int           // Tracks that played until the end.
+	Skips         int           // Tracks skipped or stopped before the end.
+	Failures      int           // Tracks that failed to play.
+	ListeningTime time.Duration // How long tracks played in total, not counting pauses.
+
+	TopTracks     []StatsCount
+	TopArtists    []StatsCount
+	TopRequesters []StatsCount // Keyed by user ID.
+}
+
+// StatsCount is how much something was listened to.
+type StatsCount struct {
+	Name          string // The track's title, the artist's name, or the requester's ID.
+	Author        string // The track's artist, for tracks.
+	URI           string // A link to the track, for tracks.
+	Plays         int    // How many times it started playing, however that ended.
+	ListeningTime time.Duration
+}
+
+// TrackLink renders a ranked track like TrackLink does, linking it if it has a link.
+func (c StatsCount) TrackLink() string {
+	track := lavalink.Track{Info: lavalink.TrackInfo{Title: c.Name}}
+	if c.URI != "" {
+		track.Info.URI = &c.URI
+	}
+
+	return TrackLink(&track)
+}
+
+// Stats sums up the tracks played in a guild since a point in time.
+// If userID isn't empty, only the tracks that user requested are counted.
+func (s *MusicService) Stats(guildID string, userID string, since time.Time) (MusicStats, error) {
+	rows, err := s.db.GetMusicHistorySince(guildID, userID, since, StatsHistoryLimit)
+	if err != nil {
+		return MusicStats{Since: since}, err
+	}
+
+	return ComputeStats(rows, since), nil
+}
+
+// ComputeStats sums up played tracks. Failed tracks are counted as failures,
+// but left out of the rankings, since they were never really listened to.
+func ComputeStats(rows []database.MusicHistoryEntry, since time.Time) MusicStats {
+	stats := MusicStats{Since: since}
+
+	tracks := make(map[string]*StatsCount)
+	artists := make(map[string]*StatsCount)
+	requesters := make(map[string]*StatsCount)
+
+	count := func(counts map[string]*StatsCount, key string, init StatsCount, listened time.Duration) {
+		c, ok := counts[key]
+		if !ok {
+			c = &init
+			counts[key] = c
+		}
+
+		c.Plays++
+		c.ListeningTime += listened
+	}
+
+	for _, row := range rows {
+		switch HistoryOutcome(row.Outcome) {
+		case HistoryOutcomePlayed:
+			stats.Plays++
+		case HistoryOutcomeFailed:
+			stats.Failures++
+			continue
+		default:
+			stats.Skips++
+		}
+
+		listened := time.Duration(row.ListenedFor) * time.Millisecond
+		stats.ListeningTime += listened
+
+		// The same track may have been found on different sources, but it usually has the same link.
+		key := row.URI
+		if key == "" {
+			key = strings.ToLower(row.Author + "\x00" + row.Title)
+		}
+
+		count(tracks, key, StatsCount{Name: row.Title, Author: row.Author, URI: row.URI}, listened)
+
+		if row.Author != "" {
+			count(artists, strings.ToLower(row.Author), StatsCount{Name: row.Author}, listened)
+		}
+
+		// Tracks nobody requested were picked by autoplay.
+		if row.RequestedBy != "" {
+			count(requesters, row.RequestedBy, StatsCount{Name: row.RequestedBy}, listened)
+		}
+	}
+
+	stats.TopTracks = topCounts(tracks)
+	stats.TopArtists = topCounts(artists)
+	stats.TopRequesters = topCounts(requesters)
+
+	return stats
+}
+
+// topCounts ranks counts by plays, then by listening time, keeping the StatsTopSize first.
+func topCounts(counts map[string]*StatsCount) []StatsCount {
+	top := make([]StatsCount, 0, len(counts))
+	for _, c := range counts {
+		top = append(top, *c)
+	}
+
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Plays != top[j].Plays {
+			return top[i].Plays > top[j].Plays
+		}
+
+		if top[i].ListeningTime != top[j].ListeningTime {
+			return top[i].ListeningTime > top[j].ListeningTime
+		}
+
+		return top[i].Name < top[j].Name
+	})
+
+	if len(top) > StatsTopSize {
+		top = top[:StatsTopSize]
+	}
+
+	return top
+}
+
+// startListening starts counting how long a track that just started is listened to.
+func (s *MusicSession) startListening(track *lavalink.Track) {
+	s.listening = track.Encoded
+	s.listenedFor = 0
+	s.listeningSince = time.Time{}
+
+	if !s.playerPaused {
+		s.listeningSince = time.Now()
+	}
+}
+
+// pauseListening stops or resumes counting the listening time of the current track.
+func (s *MusicSession) pauseListening(paused bool) {
+	if s.listening == "" {
+		return
+	}
+
+	if paused && !s.listeningSince.IsZero() {
+		s.listenedFor += time.Since(s.listeningSince)
+		s.listeningSince = time.Time{}
+	} else if !paused && s.listeningSince.IsZero() {
+		s.listeningSince = time.Now()
+	}
+}
+
+// stopListening stops counting the listening time of a track that ended, and returns it.
+// Tracks that aren't being counted, like ones that never started, weren't listened to.
+func (s *MusicSession) stopListening(track *lavalink.Track) time.Duration {
+	if s.listening != track.Encoded {
+		return 0
+	}
+
+	listened := s.listenedFor
+	if !s.listeningSince.IsZero() {
+		listened += time.Since(s.listeningSince)
+	}
+
+	s.listening = ""
+
+	return listened
+}
@@ -0,0 +1,99 @@
+package music
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/disgoorg/disgolink/v3/lavalink"
+
+	"unreal.sh/neo/internal/database"
+)
+
+func TestComputeStats(t *testing.T) {
+	minutes := func(n int) int64 { return int64(n) * time.Minute.Milliseconds() }
+
+	rows := []database.MusicHistoryEntry{
+		{Title: "One", Author: "Artist", URI: "https://example.com/1", RequestedBy: "a", Outcome: "played", ListenedFor: minutes(3)},
+		{Title: "One", Author: "Artist", URI: "https://example.com/1", RequestedBy: "b", Outcome: "skipped", ListenedFor: minutes(1)},
+		{Title: "Two", Author: "artist", URI: "https://example.com/2", RequestedBy: "b", Outcome: "played", ListenedFor: minutes(4)},
+		{Title: "Three", Author: "Other", URI: "https://example.com/3", Outcome: "played", ListenedFor: minutes(2)},
+		{Title: "Broken", Author: "Other", URI: "https://example.com/4", RequestedBy: "a", Outcome: "failed"},
+	}
+
+	stats := ComputeStats(rows, time.Time{})
+
+	if stats.Plays != 3 || stats.Skips != 1 || stats.Failures != 1 {
+		t.Fatalf("expected 3 plays, 1 skip and 1 failure, got %d, %d and %d", stats.Plays, stats.Skips, stats.Failures)
+	}
+
+	if stats.ListeningTime != 10*time.Minute {
+		t.Fatalf("expected 10m of listening, got %s", stats.ListeningTime)
+	}
+
+	if len(stats.TopTracks) != 3 || stats.TopTracks[0].Name != "One" || stats.TopTracks[0].Plays != 2 {
+		t.Fatalf("expected One to be the top of 3 tracks, got %+v", stats.TopTracks)
+	}
+
+	// Artists are counted regardless of how their names are capitalized.
+	if len(stats.TopArtists) != 2 || stats.TopArtists[0].Plays != 3 {
+		t.Fatalf("expected Artist to be the top of 2 artists with 3 plays, got %+v", stats.TopArtists)
+	}
+
+	// b listened longer than a, and autoplay isn't a requester.
+	if len(stats.TopRequesters) != 2 || stats.TopRequesters[0].Name != "b" {
+		t.Fatalf("expected b to be the top of 2 requesters, got %+v", stats.TopRequesters)
+	}
+}
+
+func TestListeningTimeSkipsPauses(t *testing.T) {
+	session := newTestSession(t)
+	ctx := context.Background()
+
+	track := testTrack("track")
+	if _, err := session.PlayOrEnqueue(ctx, track, TrackRequestData{}); err != nil {
+		t.Fatalf("PlayOrEnqueue: %v", err)
+	}
+
+	var listened time.Duration
+	err := session.do(ctx, func() error {
+		if err := session.handleTrackStart(track); err != nil {
+			return err
+		}
+
+		// Pretend the track played for a minute before being paused.
+		session.listeningSince = time.Now().Add(-time.Minute)
+		if err := session.setPaused(ctx, true); err != nil {
+			return err
+		}
+
+		if !session.listeningSince.IsZero() {
+			t.Error("listening time is still counted while paused")
+		}
+
+		session.record(track, lavalink.TrackEndReasonStopped)
+		listened = session.history[len(session.history)-1].ListenedFor
+
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if listened < time.Minute || listened > time.Minute+time.Second {
+		t.Fatalf("expected about a minute of listening, got %s", listened)
+	}
+}
+
+func TestStatsCountTrackLink(t *testing.T) {
+	linked := StatsCount{Name: "Song", URI: "https://example.com/song"}
+	if link := linked.TrackLink(); link != "[Song](https://example.com/song)" {
+		t.Errorf("expected a link, got %q", link)
+	}
+
+	// Tracks played from files or streams without a link are stored with an empty one.
+	unlinked := StatsCount{Name: "Song"}
+	if link := unlinked.TrackLink(); link != "Song" {
+		t.Errorf("expected just the title, got %q", link)
+	}
+}
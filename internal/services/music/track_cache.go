@@ -0,0 +1,132 @@
+package music
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/disgoorg/disgolink/v3/lavalink"
+
+	stringutils "unreal.sh/neo/internal/utils/stringutils"
+)
+
+const (
+	// TrackCacheSize is how many load results the track cache remembers.
+	TrackCacheSize = 4096
+
+	// TrackCacheTTL is how long the track cache remembers a load result. Encoded tracks
+	// stay playable for long, but search results change and links go down.
+	TrackCacheTTL = 6 * time.Hour
+)
+
+// TrackCache is a Node that remembers what queries and links loaded,
+// so popular tracks aren't looked up again every time someone plays them.
+// Only loaded tracks are remembered: errors and empty results are tried again next time.
+type TrackCache struct {
+	node    Node // Where tracks are loaded from when they aren't cached.
+	results *lruCache[lavalink.LoadResult]
+
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// TrackCacheStats are how many load results a track cache has and how often it had them.
+type TrackCacheStats struct {
+	Entries int
+	Hits    int64
+	Misses  int64
+}
+
+// HitRate returns how many of the lookups were cached, from 0 to 1.
+func (stats TrackCacheStats) HitRate() float64 {
+	if stats.Hits+stats.Misses == 0 {
+		return 0
+	}
+
+	return float64(stats.Hits) / float64(stats.Hits+stats.Misses)
+}
+
+// NewTrackCache creates a cache of up to size load results from node, each kept for ttl.
+func NewTrackCache(node Node, size int, ttl time.Duration) *TrackCache {
+	return &TrackCache{
+		node:    node,
+		results: newLRUCache[lavalink.LoadResult](size, ttl),
+	}
+}
+
+// LoadTracks loads identifier from the cache, or from the node if it isn't cached.
+func (c *TrackCache) LoadTracks(ctx context.Context, identifier string) (*lavalink.LoadResult, error) {
+	key := trackCacheKey(identifier)
+
+	if result, ok := c.results.get(key); ok {
+		c.hits.Add(1)
+		return cloneLoadResult(result), nil
+	}
+
+	c.misses.Add(1)
+
+	result, err := c.node.LoadTracks(ctx, identifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if cacheable(result) {
+		c.results.put(key, *cloneLoadResult(*result))
+	}
+
+	return result, nil
+}
+
+// Stats returns how many load results the cache has and how often it had them.
+func (c *TrackCache) Stats() TrackCacheStats {
+	return TrackCacheStats{
+		Entries: c.results.len(),
+		Hits:    c.hits.Load(),
+		Misses:  c.misses.Load(),
+	}
+}
+
+// trackCacheKey normalizes an identifier, so searches differing only in case or spacing share their results.
+// Links are left alone, since their paths and queries may be case-sensitive.
+func trackCacheKey(identifier string) string {
+	identifier = strings.TrimSpace(identifier)
+	if stringutils.IsUrl(identifier) {
+		return identifier
+	}
+
+	return strings.ToLower(strings.Join(strings.Fields(identifier), " "))
+}
+
+// cacheable returns whether a load result found any tracks.
+func cacheable(result *lavalink.LoadResult) bool {
+	switch data := result.Data.(type) {
+	case lavalink.Track:
+		return true
+	case lavalink.Playlist:
+		return len(data.Tracks) > 0
+	case lavalink.Search:
+		return len(data) > 0
+	default:
+		return false
+	}
+}
+
+// cloneLoadResult copies a load result's tracks, so callers can't change what's cached,
+// nor share tracks with other callers.
+func cloneLoadResult(result lavalink.LoadResult) *lavalink.LoadResult {
+	switch data := result.Data.(type) {
+	case lavalink.Playlist:
+		data.Tracks = append([]lavalink.Track(nil), data.Tracks...)
+		result.Data = data
+	case lavalink.Search:
+		result.Data = append(lavalink.Search(nil), data...)
+	}
+
+	return &result
+}
+
+// TrackCacheStats returns how well the track cache shared by /play, playlists and autoplay works.
+func (s *MusicService) TrackCacheStats() TrackCacheStats {
+	return s.tracks.Stats()
+}
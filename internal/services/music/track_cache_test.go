@@ -0,0 +1,130 @@
+package music
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/disgoorg/disgolink/v3/lavalink"
+)
+
+// countingNode loads every identifier as a single search result, or fails to, and counts how often it was asked to.
+type countingNode struct {
+	loads int
+	err   error
+	empty bool
+}
+
+func (n *countingNode) LoadTracks(ctx context.Context, identifier string) (*lavalink.LoadResult, error) {
+	n.loads++
+
+	if n.err != nil {
+		return nil, n.err
+	}
+
+	if n.empty {
+		return &lavalink.LoadResult{LoadType: lavalink.LoadTypeEmpty, Data: lavalink.Empty{}}, nil
+	}
+
+	return &lavalink.LoadResult{LoadType: lavalink.LoadTypeSearch, Data: lavalink.Search{*testTrack(identifier)}}, nil
+}
+
+func TestTrackCacheHits(t *testing.T) {
+	node := &countingNode{}
+	cache := NewTrackCache(node, TrackCacheSize, TrackCacheTTL)
+	ctx := context.Background()
+
+	for _, query := range []string{"dzsearch:Never Gonna Give You Up", "dzsearch:never  gonna give you up "} {
+		if _, err := cache.LoadTracks(ctx, query); err != nil {
+			t.Fatalf("LoadTracks(%q): %v", query, err)
+		}
+	}
+
+	if node.loads != 1 {
+		t.Fatalf("expected searches differing in case and spacing to share their results, got %d loads", node.loads)
+	}
+
+	// Links may be case-sensitive.
+	for _, url := range []string{"https://youtu.be/dQw4w9WgXcQ", "https://youtu.be/DQW4W9WGXCQ"} {
+		if _, err := cache.LoadTracks(ctx, url); err != nil {
+			t.Fatalf("LoadTracks(%q): %v", url, err)
+		}
+	}
+
+	if node.loads != 3 {
+		t.Fatalf("expected links differing in case to be loaded separately, got %d loads", node.loads)
+	}
+
+	stats := cache.Stats()
+	if stats.Entries != 3 || stats.Hits != 1 || stats.Misses != 3 || stats.HitRate() != 0.25 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
+
+func TestTrackCacheKeepsResultsApart(t *testing.T) {
+	cache := NewTrackCache(&countingNode{}, TrackCacheSize, TrackCacheTTL)
+	ctx := context.Background()
+
+	first, err := cache.LoadTracks(ctx, "dzsearch:track")
+	if err != nil {
+		t.Fatalf("LoadTracks: %v", err)
+	}
+
+	first.Data.(lavalink.Search)[0].Encoded = "changed"
+
+	second, err := cache.LoadTracks(ctx, "dzsearch:track")
+	if err != nil {
+		t.Fatalf("LoadTracks: %v", err)
+	}
+
+	if second.Data.(lavalink.Search)[0].Encoded == "changed" {
+		t.Fatal("expected changing a result not to change what's cached")
+	}
+}
+
+func TestTrackCacheSkipsFailures(t *testing.T) {
+	ctx := context.Background()
+
+	failing := &countingNode{err: errors.New("node is down")}
+	cache := NewTrackCache(failing, TrackCacheSize, TrackCacheTTL)
+
+	for i := 0; i < 2; i++ {
+		if _, err := cache.LoadTracks(ctx, "dzsearch:track"); err == nil {
+			t.Fatal("expected the node's error")
+		}
+	}
+
+	empty := &countingNode{empty: true}
+	cache = NewTrackCache(empty, TrackCacheSize, TrackCacheTTL)
+
+	for i := 0; i < 2; i++ {
+		if _, err := cache.LoadTracks(ctx, "dzsearch:track"); err != nil {
+			t.Fatalf("LoadTracks: %v", err)
+		}
+	}
+
+	if failing.loads != 2 || empty.loads != 2 {
+		t.Fatalf("expected failures and empty results to be loaded again, got %d and %d loads", failing.loads, empty.loads)
+	}
+}
+
+func TestTrackCacheExpires(t *testing.T) {
+	node := &countingNode{}
+	cache := NewTrackCache(node, TrackCacheSize, time.Millisecond)
+	ctx := context.Background()
+
+	if _, err := cache.LoadTracks(ctx, "dzsearch:track"); err != nil {
+		t.Fatalf("LoadTracks: %v", err)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+
+	if _, err := cache.LoadTracks(ctx, "dzsearch:track"); err != nil {
+		t.Fatalf("LoadTracks: %v", err)
+	}
+
+	if node.loads != 2 {
+		t.Fatalf("expected an expired result to be loaded again, got %d loads", node.loads)
+	}
+}
@@ -0,0 +1,136 @@
+package music
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/disgoorg/disgolink/v3/lavalink"
+)
+
+// RejoinTimeout is how long the bot may take to be back in its voice channel after joining it again,
+// before the session gives up on it and ends.
+const RejoinTimeout = 30 * time.Second
+
+// handleVoiceChannel is called when the bot joined or was moved to a voice channel.
+// Playback carries on in the new channel, since Lavalink follows the bot there.
+func (s *MusicSession) handleVoiceChannel(channelID string) {
+	previous := s.voiceChannelID
+
+	s.voiceChannelID = channelID
+	s.stopRejoining()
+
+	if previous == "" || previous == channelID {
+		return
+	}
+
+	s.announce(fmt.Sprintf("🔀 I was moved to <#%s>, so I'll keep playing there.", channelID))
+}
+
+// handleDisconnect is called when the bot left or was disconnected from its voice channel.
+// It stops playback for good and reports whether the session is over, which it isn't
+// while the bot is joining its channel again after reconnecting to the gateway.
+func (s *MusicSession) handleDisconnect() bool {
+	if s.rejoining != nil {
+		return false
+	}
+
+	// Lavalink doesn't end the track of a player that's destroyed, so it's recorded here.
+	if s.current != nil {
+		s.record(s.current, lavalink.TrackEndReasonCleanup)
+	}
+
+	s.queue = make([]*lavalink.Track, 0)
+	s.data = make([]TrackRequestData, 0)
+	s.current = nil
+
+	s.cancelSkipVote()
+	s.cancelSleepTimer()
+	s.closePanel()
+
+	if !s.leaving {
+		s.announce("👋 I was disconnected from the voice channel, so I stopped playing.")
+	}
+
+	s.voiceChannelID = ""
+
+	return true
+}
+
+// rejoin joins the session's voice channel again if the bot isn't in it anymore after resuming
+// its connection to the gateway. After a new connection, the bot's voice states aren't known
+// until Discord sends them again, so joinVoice is what's needed then.
+func (s *MusicSession) rejoin() error {
+	if s.voiceChannelID == "" || s.session == nil {
+		return nil
+	}
+
+	state, err := s.session.State.VoiceState(s.GuildID, s.session.State.User.ID)
+	if err == nil && state.ChannelID == s.voiceChannelID {
+		return nil
+	}
+
+	return s.joinVoice()
+}
+
+// joinVoice joins the session's voice channel from the bot's current gateway connection,
+// which hands the voice connection over to it if the bot was in the channel already.
+func (s *MusicSession) joinVoice() error {
+	if s.voiceChannelID == "" || s.session == nil {
+		return nil
+	}
+
+	// Discord may tell us about the lost connection while we're joining again.
+	s.stopRejoining()
+
+	var timer *time.Timer
+	timer = time.AfterFunc(RejoinTimeout, func() {
+		s.post(func(ctx context.Context) {
+			// The bot may have made it back in the meantime, or be joining again since.
+			if s.rejoining == timer {
+				s.giveUpRejoining(ctx)
+			}
+		})
+	})
+
+	s.rejoining = timer
+
+	err := s.session.ChannelVoiceJoinManual(s.GuildID, s.voiceChannelID, false, false)
+	if err != nil {
+		s.stopRejoining()
+	}
+
+	return err
+}
+
+func (s *MusicSession) stopRejoining() {
+	if s.rejoining == nil {
+		return
+	}
+
+	s.rejoining.Stop()
+	s.rejoining = nil
+}
+
+// giveUpRejoining ends the session when the bot didn't make it back into its voice channel within RejoinTimeout.
+// The bot leaves the channel for good, in case it does make it back after all.
+func (s *MusicSession) giveUpRejoining(ctx context.Context) {
+	s.rejoining = nil
+
+	// Saying why is enough, without also hearing about being disconnected.
+	s.leaving = true
+	s.handleDisconnect()
+
+	s.announce("👋 I couldn't get back into the voice channel after reconnecting, so I stopped playing.")
+
+	if s.session != nil {
+		if err := s.session.ChannelVoiceJoinManual(s.GuildID, "", false, false); err != nil {
+			slog.Warn("Failed to leave voice channel.", slog.String("guild_id", s.GuildID), slog.String("error", err.Error()))
+		}
+	}
+
+	if s.onGone != nil {
+		s.onGone(ctx)
+	}
+}
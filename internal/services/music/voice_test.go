@@ -0,0 +1,158 @@
+package music
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestVoiceMoveKeepsPlaying(t *testing.T) {
+	session := newTestSession(t)
+	ctx := context.Background()
+
+	if _, err := session.PlayOrEnqueue(ctx, testTrack("track"), TrackRequestData{}); err != nil {
+		t.Fatalf("PlayOrEnqueue: %v", err)
+	}
+
+	var channelID string
+	err := session.do(ctx, func() error {
+		session.handleVoiceChannel("10")
+		session.handleVoiceChannel("20")
+		channelID = session.voiceChannelID
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("do: %v", err)
+	}
+
+	if channelID != "20" {
+		t.Fatalf("expected the session to follow the bot to channel 20, got %q", channelID)
+	}
+
+	state, err := session.State(ctx)
+	if err != nil {
+		t.Fatalf("State: %v", err)
+	}
+
+	if !state.IsPlaying() {
+		t.Fatal("expected the track to keep playing after being moved")
+	}
+}
+
+func TestVoiceDisconnectStops(t *testing.T) {
+	session := newTestSession(t)
+	ctx := context.Background()
+
+	for _, id := range []string{"first", "second"} {
+		if _, err := session.PlayOrEnqueue(ctx, testTrack(id), TrackRequestData{}); err != nil {
+			t.Fatalf("PlayOrEnqueue: %v", err)
+		}
+	}
+
+	if _, err := session.SetSleepTimer(ctx, SleepAfterQueue, 0); err != nil {
+		t.Fatalf("SetSleepTimer: %v", err)
+	}
+
+	var over bool
+	err := session.do(ctx, func() error {
+		session.handleVoiceChannel("10")
+		over = session.handleDisconnect()
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("do: %v", err)
+	}
+
+	if !over {
+		t.Fatal("expected the session to be over after being disconnected")
+	}
+
+	state, err := session.State(ctx)
+	if err != nil {
+		t.Fatalf("State: %v", err)
+	}
+
+	if state.IsPlaying() || len(state.Queue) != 0 || state.SleepTimer != nil {
+		t.Fatalf("expected the session to have stopped, got %+v", state)
+	}
+
+	history, err := session.History(ctx)
+	if err != nil {
+		t.Fatalf("History: %v", err)
+	}
+
+	if len(history) != 1 || history[0].Track.Encoded != "first" || history[0].Outcome != HistoryOutcomeStopped {
+		t.Fatalf("expected the playing track to be recorded as stopped, got %+v", history)
+	}
+}
+
+func TestVoiceDisconnectWhileRejoining(t *testing.T) {
+	session := newTestSession(t)
+	ctx := context.Background()
+
+	if _, err := session.PlayOrEnqueue(ctx, testTrack("track"), TrackRequestData{}); err != nil {
+		t.Fatalf("PlayOrEnqueue: %v", err)
+	}
+
+	var over bool
+	err := session.do(ctx, func() error {
+		session.handleVoiceChannel("10")
+		session.rejoining = time.AfterFunc(time.Hour, func() {})
+		over = session.handleDisconnect()
+		session.stopRejoining()
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("do: %v", err)
+	}
+
+	if over {
+		t.Fatal("expected the session to carry on while rejoining")
+	}
+
+	state, err := session.State(ctx)
+	if err != nil {
+		t.Fatalf("State: %v", err)
+	}
+
+	if !state.IsPlaying() {
+		t.Fatal("expected the track to keep playing while rejoining")
+	}
+}
+
+func TestVoiceRejoinGivesUp(t *testing.T) {
+	session := newTestSession(t)
+	ctx := context.Background()
+
+	for _, id := range []string{"first", "second"} {
+		if _, err := session.PlayOrEnqueue(ctx, testTrack(id), TrackRequestData{}); err != nil {
+			t.Fatalf("PlayOrEnqueue: %v", err)
+		}
+	}
+
+	gone := false
+	session.onGone = func(ctx context.Context) { gone = true }
+
+	err := session.do(ctx, func() error {
+		session.handleVoiceChannel("10")
+		session.rejoining = time.AfterFunc(time.Hour, func() {})
+		session.giveUpRejoining(ctx)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("do: %v", err)
+	}
+
+	if !gone {
+		t.Fatal("expected the session to end after failing to rejoin")
+	}
+
+	state, err := session.State(ctx)
+	if err != nil {
+		t.Fatalf("State: %v", err)
+	}
+
+	if state.IsPlaying() || len(state.Queue) != 0 {
+		t.Fatalf("expected the session to have stopped, got %+v", state)
+	}
+}
@@ -1,36 +1,37 @@
 package colorutils
 
 import (
+	"errors"
 	"image"
 	"image/color"
-	"net/http"
 	"sort"
 	"strconv"
 
+	// Artworks are JPEGs and PNGs, which image.Decode needs to be taught to read.
+	_ "image/jpeg"
+	_ "image/png"
+
 	"github.com/cenkalti/dominantcolor"
 	"github.com/lucasb-eyer/go-colorful"
 
 	sliceutils "unreal.sh/neo/internal/utils/sliceutils"
 )
 
-func GetDominantColorFromImageURL(url string) (int, error) {
-	response, e := http.Get(url)
-	if e != nil {
-		return 0, e
-	}
-	defer response.Body.Close()
-
-	img, _, err := image.Decode(response.Body)
-	if err != nil {
-		return 0, err
-	}
+var ErrNoColors = errors.New("image has no colors")
 
+// GetDominantColorFromImage returns the most vivid of an image's dominant colors.
+func GetDominantColorFromImage(img image.Image) (int, error) {
 	colors := sliceutils.Map(dominantcolor.FindN(img, 5), func(color color.RGBA) colorful.Color {
 		hex := dominantcolor.Hex(color)
 		c, _ := colorful.Hex(hex)
 		return c
 	})
 
+	// Fully transparent images have no colors to speak of.
+	if len(colors) == 0 {
+		return 0, ErrNoColors
+	}
+
 	// Sort colors by saturation and value
 	sort.Slice(colors, func(i, j int) bool {
 		_, cis, civ := colors[i].Hsv()
@@ -1,7 +1,10 @@
 package datetime
 
 import (
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/disgoorg/disgolink/v3/lavalink"
@@ -11,6 +14,78 @@ func ToDuration(d lavalink.Duration) time.Duration {
 	return time.Duration(d.Milliseconds() * 1000000)
 }
 
+func FromDuration(d time.Duration) lavalink.Duration {
+	return lavalink.Duration(d.Milliseconds())
+}
+
+var ErrInvalidTimestamp = errors.New("invalid timestamp")
+
+// ParseTimestamp parses a position within a track, such as "1:23", "1:02:03", "83", "83s" or "1m23s".
+// A leading "+" or "-" makes the timestamp relative, in which case the returned duration is signed.
+func ParseTimestamp(input string) (d time.Duration, relative bool, err error) {
+	input = strings.TrimSpace(input)
+
+	sign := time.Duration(1)
+	if strings.HasPrefix(input, "+") || strings.HasPrefix(input, "-") {
+		if input[0] == '-' {
+			sign = -1
+		}
+
+		relative = true
+		input = input[1:]
+	}
+
+	if input == "" {
+		return 0, false, ErrInvalidTimestamp
+	}
+
+	if strings.Contains(input, ":") {
+		// Clock format, the last part being seconds.
+		parts := strings.Split(input, ":")
+		if len(parts) > 3 {
+			return 0, false, ErrInvalidTimestamp
+		}
+
+		for i, part := range parts {
+			n, err := strconv.Atoi(part)
+			if err != nil || n < 0 {
+				return 0, false, ErrInvalidTimestamp
+			}
+
+			// Only the leading part may exceed its unit, like the minutes of "83:00".
+			if i > 0 && n >= 60 {
+				return 0, false, ErrInvalidTimestamp
+			}
+
+			d = d*60 + time.Duration(n)*time.Second
+		}
+	} else if n, err := strconv.Atoi(input); err == nil {
+		if n < 0 {
+			return 0, false, ErrInvalidTimestamp
+		}
+
+		d = time.Duration(n) * time.Second
+	} else {
+		d, err = time.ParseDuration(input)
+		if err != nil || d < 0 {
+			return 0, false, ErrInvalidTimestamp
+		}
+	}
+
+	return sign * d, relative, nil
+}
+
+// ParseDuration parses a length of time such as "30m" or "1h30m", taking plain numbers as minutes.
+func ParseDuration(input string) (time.Duration, error) {
+	input = strings.TrimSpace(input)
+
+	if n, err := strconv.Atoi(input); err == nil {
+		return time.Duration(n) * time.Minute, nil
+	}
+
+	return time.ParseDuration(input)
+}
+
 // A function that prints durations with the format "3m 2s", omitting 0 values.
 func Pretty(dur time.Duration) string {
 	var output string
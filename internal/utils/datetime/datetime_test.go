@@ -0,0 +1,96 @@
+package datetime
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTimestamp(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected time.Duration
+		relative bool
+		valid    bool
+	}{
+		{"1:23", 83 * time.Second, false, true},
+		{"1:02:03", time.Hour + 2*time.Minute + 3*time.Second, false, true},
+		{"83:00", 83 * time.Minute, false, true},
+		{"0:05", 5 * time.Second, false, true},
+		{"83", 83 * time.Second, false, true},
+		{"83s", 83 * time.Second, false, true},
+		{"1m23s", 83 * time.Second, false, true},
+		{" 1:23 ", 83 * time.Second, false, true},
+		{"+30s", 30 * time.Second, true, true},
+		{"-1:00", -time.Minute, true, true},
+		{"+15", 15 * time.Second, true, true},
+		{"1:99", 0, false, false},
+		{"1:60:00", 0, false, false},
+		{"1:2:3:4", 0, false, false},
+		{"1:-5", 0, false, false},
+		{"-", 0, false, false},
+		{"", 0, false, false},
+		{"abc", 0, false, false},
+		{"-5s:", 0, false, false},
+	}
+
+	for _, test := range tests {
+		d, relative, err := ParseTimestamp(test.input)
+
+		if !test.valid {
+			if err == nil {
+				t.Errorf("ParseTimestamp(%q) = %s, expected it to be invalid", test.input, d)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("ParseTimestamp(%q): %v", test.input, err)
+			continue
+		}
+
+		if d != test.expected || relative != test.relative {
+			t.Errorf("ParseTimestamp(%q) = %s, relative %t, expected %s, relative %t",
+				test.input, d, relative, test.expected, test.relative)
+		}
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected time.Duration
+		valid    bool
+	}{
+		{"30", 30 * time.Minute, true},
+		{" 30 ", 30 * time.Minute, true},
+		{"30m", 30 * time.Minute, true},
+		{"1h30m", 90 * time.Minute, true},
+		{"45s", 45 * time.Second, true},
+		{"0", 0, true},
+		{"", 0, false},
+		{"1:30", 0, false},
+		{"soon", 0, false},
+	}
+
+	for _, test := range tests {
+		d, err := ParseDuration(test.input)
+
+		if !test.valid {
+			if err == nil {
+				t.Errorf("ParseDuration(%q) = %s, expected it to be invalid", test.input, d)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("ParseDuration(%q): %v", test.input, err)
+			continue
+		}
+
+		if d != test.expected {
+			t.Errorf("ParseDuration(%q) = %s, expected %s", test.input, d, test.expected)
+		}
+	}
+}
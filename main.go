@@ -10,6 +10,7 @@ import (
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/disgoorg/disgolink/v3/disgolink"
+	"github.com/gorilla/websocket"
 	"github.com/joho/godotenv"
 	"github.com/lmittmann/tint"
 	"github.com/zekrotja/ken"
@@ -73,26 +74,44 @@ func main() {
 
 	err = k.RegisterCommands(
 		new(slash.AvatarCommand),
+		new(slash.BackCommand),
 		new(slash.BanCommand),
+		new(slash.CaseCommand),
+		new(slash.FilterCommand),
+		new(slash.HistoryCommand),
+		new(slash.ImportCommand),
+		new(slash.InfractionsCommand),
 		new(slash.KickCommand),
 		new(slash.ModuleCommand),
+		new(slash.MusicSettingsCommand),
+		new(slash.NoteCommand),
 		new(slash.NowPlayingCommand),
 		new(slash.PauseCommand),
 		new(slash.PingCommand),
 		new(slash.PlayCommand),
+		new(slash.PlaylistCommand),
 		new(slash.PurgeCommand),
+		new(slash.ReplayCommand),
 		new(slash.ResumeCommand),
+		new(slash.SearchCommand),
+		new(slash.SeekCommand),
 		new(slash.SkipCommand),
+		new(slash.SleepCommand),
 		new(slash.SoftbanCommand),
+		new(slash.StatsCommand),
 		new(slash.StopCommand),
+		new(slash.TimeoutCommand),
 		new(slash.QueueCommand),
+		new(slash.UnbanCommand),
 		new(slash.VolumeCommand),
+		new(slash.WarnCommand),
 		new(slash.WhoIsCommand),
 	)
 	utils.MUST(err)
 
 	err = k.RegisterMiddlewares(
 		new(middlewares.PermissionsMiddleware),
+		new(middlewares.DJMiddleware),
 		new(middlewares.VoiceChannelMiddleware),
 	)
 	utils.MUST(err)
@@ -101,7 +120,10 @@ func main() {
 
 	err = session.Open()
 	utils.MUST(err)
-	defer session.Close()
+
+	// Closing normally would make Discord drop the bot from its voice channels right away. Closing for a
+	// restart keeps it in them, so the sessions Lavalink keeps playing can be resumed once the bot is back.
+	defer session.CloseWithCode(websocket.CloseServiceRestart)
 
 	dependencyProvider.Register("Database", db)
 
@@ -110,18 +132,28 @@ func main() {
 	_ = db.AssureGuildSettings(guilds...)
 
 	// Open session before creating MusicService, which depends on it.
-	musicService, err := music.NewMusicService(session)
+	musicService, err := music.NewMusicService(context.Background(), session, db)
 	utils.MUST(err)
+	defer musicService.Close()
 	dependencyProvider.Register("MusicService", musicService)
 
-	musicService.AddNode(context.Background(), disgolink.NodeConfig{
+	// Adding the node resumes the sessions it kept playing, which need the bot's events to rejoin their voice channels.
+	musicService.HookEvents()
+
+	nodeCtx, cancelNode := context.WithTimeout(context.Background(), music.StartupTimeout)
+	defer cancelNode()
+
+	musicService.AddNode(nodeCtx, disgolink.NodeConfig{
 		Name:     "main",
 		Address:  os.Getenv("LAVALINK_ADDRESS"),
 		Password: os.Getenv("LAVALINK_PASSWORD"),
 		Secure:   false,
 	})
 
-	musicService.HookEvents()
+	// The local library is optional. LAVALINK_MUSIC_LIBRARY_DIR is where Lavalink sees the same files, if it runs elsewhere.
+	if dir := os.Getenv("MUSIC_LIBRARY_DIR"); dir != "" {
+		musicService.OpenLibrary(dir, os.Getenv("LAVALINK_MUSIC_LIBRARY_DIR"))
+	}
 
 	// Start module system.
 	moduleManager := modules.NewModuleManager(session, db)